@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"time"
 
 	"github.com/devingoodsell/go-links-free/internal/auth"
@@ -11,6 +13,10 @@ import (
 	"github.com/devingoodsell/go-links-free/internal/jobs"
 	"github.com/devingoodsell/go-links-free/internal/middleware"
 	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/notify"
+	"github.com/devingoodsell/go-links-free/internal/safehttp"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/devingoodsell/go-links-free/internal/webhooks"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -32,6 +38,100 @@ func main() {
 	analyticsRepo := models.NewAnalyticsRepository(database)
 	userRepo := models.NewUserRepository(database)
 	requestLogRepo := models.NewRequestLogRepository(database)
+	aliasRuleRepo := models.NewAliasRuleRepository(database)
+	auditRepo := models.NewAuditLogRepository(database)
+	notificationRepo := models.NewNotificationRepository(database)
+	webhookRepo := models.NewWebhookRepository(database)
+
+	// Initialize alias policy from config defaults and stored rules
+	aliasPolicy, err := services.NewAliasPolicy(aliasRuleRepo, cfg.ReservedAliases, cfg.BlockedAliasPatterns)
+	if err != nil {
+		log.Fatalf("Failed to initialize alias policy: %v", err)
+	}
+	if err := aliasPolicy.Reload(context.Background()); err != nil {
+		log.Fatalf("Failed to load alias rules: %v", err)
+	}
+
+	// Random aliases for links created without one
+	aliasGen, err := services.NewAliasGenerator(cfg.GeneratedAliasAlphabet, cfg.GeneratedAliasLength, aliasPolicy)
+	if err != nil {
+		log.Fatalf("Failed to initialize alias generator: %v", err)
+	}
+
+	// Load the malicious domain blocklist, if configured
+	blocklist := services.NewDomainBlocklist(cfg.DomainBlocklistPath)
+	if _, err := blocklist.Reload(); err != nil {
+		log.Fatalf("Failed to load domain blocklist: %v", err)
+	}
+
+	// Initialize destination URL policy
+	urlPolicy := services.NewURLPolicy(linkRepo, services.URLPolicyConfig{
+		AllowedSchemes: cfg.AllowedURLSchemes,
+		AllowedDomains: cfg.AllowedDomains,
+		DeniedDomains:  cfg.DeniedDomains,
+		ForceHTTPS:     cfg.ForceHTTPS,
+		PublicURL:      cfg.PublicURL,
+		SelfHosts:      cfg.SelfHosts,
+		Blocklist:      blocklist,
+	})
+
+	// Build the in-memory alias suggestion index and keep it in step with
+	// link changes
+	suggestIndex := services.NewSuggestIndex(linkRepo, cfg.SuggestRefreshInterval)
+	if err := suggestIndex.Refresh(context.Background()); err != nil {
+		log.Fatalf("Failed to build suggest index: %v", err)
+	}
+	linkRepo.AddChangeListener(func(models.LinkChange) { suggestIndex.MarkStale() })
+	suggestIndex.Start()
+	defer suggestIndex.Stop()
+
+	// Tell users about changes to their links. Messages go through the
+	// outbox, which the notification job sends with retries.
+	notifier, err := notify.New(notify.Config{
+		Channel:      cfg.Notifier,
+		SMTPHost:     cfg.SMTPHost,
+		SMTPPort:     cfg.SMTPPort,
+		SMTPUsername: cfg.SMTPUsername,
+		SMTPPassword: cfg.SMTPPassword,
+		SMTPFrom:     cfg.SMTPFrom,
+		WebhookURL:   cfg.NotifyWebhookURL,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize notifier: %v", err)
+	}
+	notifications := notify.NewService(notificationRepo, userRepo, linkRepo, notify.NewTemplates(cfg.PublicURL))
+	auditRepo.AddListener(notifications.AuditRecorded)
+
+	notificationJob := jobs.NewNotificationOutboxJob(notificationRepo, notifier, jobs.NotificationOutboxConfig{
+		Interval:    cfg.NotifyInterval,
+		MaxAttempts: cfg.NotifyMaxAttempts,
+		RetryBase:   cfg.NotifyRetryBase,
+		RetryMax:    6 * time.Hour,
+		Timeout:     30 * time.Second,
+	})
+	notificationJob.Start()
+	defer notificationJob.Stop()
+
+	// Send every link write, expiry and click threshold to subscribed
+	// webhook endpoints. Writes reach the dispatcher through the link change
+	// outbox, which the webhook job drains.
+	webhookDispatcher := webhooks.NewDispatcher(webhookRepo, linkRepo, cfg.PublicURL)
+
+	// Endpoints are user-supplied, so deliveries only go to public addresses.
+	// A redirect is not a delivery; receivers should register the final URL.
+	webhookClient := safehttp.NewClient(0)
+	webhookClient.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	webhookJob := jobs.NewWebhookJob(webhookRepo, webhookDispatcher, webhookClient, jobs.WebhookConfig{
+		Interval:        cfg.WebhookInterval,
+		MaxAttempts:     cfg.WebhookMaxAttempts,
+		RetryBase:       cfg.WebhookRetryBase,
+		RetryMax:        12 * time.Hour,
+		Timeout:         cfg.WebhookTimeout,
+		ClickThresholds: cfg.WebhookClickThresholds,
+		Lookback:        24 * time.Hour,
+	})
+	webhookJob.Start()
+	defer webhookJob.Stop()
 
 	// Initialize JWT manager
 	jwtManager := auth.NewJWTManager(cfg.JWTSecret, 24*time.Hour)
@@ -52,6 +152,13 @@ func main() {
 		linkRepo,
 		analyticsRepo,
 		userRepo,
+		aliasPolicy,
+		urlPolicy,
+		auditRepo,
+		suggestIndex,
+		aliasGen,
+		notificationRepo,
+		webhookRepo,
 	)
 
 	// Print all registered routes
@@ -59,7 +166,7 @@ func main() {
 	for _, route := range router.Routes() {
 		log.Printf("Route: %s\t%s", route.Method, route.Path)
 	}
-	log.Println("=== End Routes ===\n")
+	log.Println("=== End Routes ===")
 
 	// Print registered routes
 	for _, route := range router.Routes() {
@@ -81,6 +188,55 @@ func main() {
 	cleanupJob.Start()
 	defer cleanupJob.Stop()
 
+	// Re-scan links whenever the domain blocklist file changes
+	if cfg.DomainBlocklistPath != "" {
+		blocklistJob := jobs.NewBlocklistScanJob(blocklist, linkRepo, auditRepo,
+			cfg.DomainBlocklistAction == "deactivate", cfg.DomainBlocklistInterval)
+		blocklistJob.Start()
+		defer blocklistJob.Stop()
+	}
+
+	// Check link destinations for broken pages
+	if cfg.LinkCheckEnabled {
+		linkHealthJob := jobs.NewLinkHealthJob(linkRepo, safehttp.NewClient(0), jobs.LinkHealthConfig{
+			Interval:     cfg.LinkCheckInterval,
+			MaxAge:       cfg.LinkCheckMaxAge,
+			BatchSize:    cfg.LinkCheckBatchSize,
+			Concurrency:  cfg.LinkCheckConcurrency,
+			HostInterval: cfg.LinkCheckHostInterval,
+			Timeout:      cfg.LinkCheckTimeout,
+			UserAgent:    "go-links-checker (+" + cfg.PublicURL + ")",
+		})
+		linkHealthJob.OnResult = func(link *models.Link, health *models.LinkHealth) {
+			// Alert once per outage, after enough failures to rule out a blip
+			if health.Healthy || health.ConsecutiveFailures != cfg.LinkCheckAlertAfter {
+				return
+			}
+			if err := notifications.LinkBroken(context.Background(), link, health); err != nil {
+				log.Printf("Error queueing broken link alert for link %d: %v", link.ID, err)
+			}
+		}
+		linkHealthJob.Start()
+		defer linkHealthJob.Stop()
+	}
+
+	// Remind owners of unused and expiring links
+	if cfg.StaleLinkEnabled {
+		staleLinkJob := jobs.NewStaleLinkJob(linkRepo, services.NewLinkActionTokens(cfg.LinkActionSecret, cfg.LinkActionTTL), jobs.StaleLinkConfig{
+			Interval:           cfg.StaleLinkInterval,
+			UnusedAfter:        cfg.StaleLinkUnusedAfter,
+			OwnerInactiveAfter: cfg.StaleLinkOwnerInactiveAfter,
+			ExpiringWithin:     cfg.StaleLinkExpiringWithin,
+			NudgeEvery:         cfg.StaleLinkNudgeEvery,
+			BaseURL:            cfg.PublicURL,
+		})
+		staleLinkJob.Send = func(ctx context.Context, digest *jobs.StaleDigest) error {
+			return notifications.Notify(ctx, digest.OwnerID, models.NotifyLinkDigest, digest)
+		}
+		staleLinkJob.Start()
+		defer staleLinkJob.Stop()
+	}
+
 	// Enable CORS
 	r := gin.Default()
 	r.Use(cors.New(cors.Config{
@@ -100,6 +256,13 @@ func main() {
 		linkRepo,
 		analyticsRepo,
 		userRepo,
+		aliasPolicy,
+		urlPolicy,
+		auditRepo,
+		suggestIndex,
+		aliasGen,
+		notificationRepo,
+		webhookRepo,
 	)
 
 	// Start server
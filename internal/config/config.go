@@ -2,7 +2,11 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +19,113 @@ type Config struct {
 	OktaClientID     string `json:"okta_client_id,omitempty"`
 	OktaClientSecret string `json:"okta_client_secret,omitempty"`
 	JWTSecret        string `json:"jwt_secret"`
+	CursorSecret     string `json:"-"` // signs page tokens; defaults to JWTSecret
+	LinkActionSecret string `json:"-"` // signs stale link action URLs; defaults to JWTSecret
+
+	// Alias policy
+	ReservedAliases      []string `json:"reserved_aliases"`
+	BlockedAliasPatterns []string `json:"blocked_alias_patterns"`
+
+	// Destination URL policy
+	PublicURL         string   `json:"public_url"`
+	SelfHosts         []string `json:"self_hosts"`
+	AllowedURLSchemes []string `json:"allowed_url_schemes"`
+	AllowedDomains    []string `json:"allowed_domains,omitempty"`
+	DeniedDomains     []string `json:"denied_domains,omitempty"`
+	ForceHTTPS        bool     `json:"force_https"`
+
+	// Malicious domain blocklist
+	DomainBlocklistPath     string        `json:"domain_blocklist_path,omitempty"`
+	DomainBlocklistInterval time.Duration `json:"domain_blocklist_interval"`
+	DomainBlocklistAction   string        `json:"domain_blocklist_action"` // "flag" or "deactivate"
+
+	// Generated aliases for links created without one
+	GeneratedAliasAlphabet string `json:"generated_alias_alphabet"`
+	GeneratedAliasLength   int    `json:"generated_alias_length"`
+
+	// Alias autocomplete
+	SuggestRefreshInterval time.Duration `json:"suggest_refresh_interval"`
+
+	// Broken destination checks
+	LinkCheckEnabled      bool          `json:"link_check_enabled"`
+	LinkCheckInterval     time.Duration `json:"link_check_interval"`
+	LinkCheckMaxAge       time.Duration `json:"link_check_max_age"`
+	LinkCheckBatchSize    int           `json:"link_check_batch_size"`
+	LinkCheckConcurrency  int           `json:"link_check_concurrency"`
+	LinkCheckHostInterval time.Duration `json:"link_check_host_interval"`
+	LinkCheckTimeout      time.Duration `json:"link_check_timeout"`
+	LinkCheckAlertAfter   int           `json:"link_check_alert_after"` // failed checks before the owner is told
+
+	// Stale link digests
+	StaleLinkEnabled            bool          `json:"stale_link_enabled"`
+	StaleLinkInterval           time.Duration `json:"stale_link_interval"`
+	StaleLinkUnusedAfter        time.Duration `json:"stale_link_unused_after"`
+	StaleLinkOwnerInactiveAfter time.Duration `json:"stale_link_owner_inactive_after"`
+	StaleLinkExpiringWithin     time.Duration `json:"stale_link_expiring_within"`
+	StaleLinkNudgeEvery         time.Duration `json:"stale_link_nudge_every"`
+	StaleLinkKeepFor            time.Duration `json:"stale_link_keep_for"`
+	LinkActionTTL               time.Duration `json:"link_action_ttl"`
+
+	// Notifications
+	Notifier          string        `json:"notifier"` // smtp, webhook, log or memory
+	SMTPHost          string        `json:"smtp_host,omitempty"`
+	SMTPPort          int           `json:"smtp_port,omitempty"`
+	SMTPUsername      string        `json:"smtp_username,omitempty"`
+	SMTPPassword      string        `json:"-"`
+	SMTPFrom          string        `json:"smtp_from,omitempty"`
+	NotifyWebhookURL  string        `json:"notify_webhook_url,omitempty"`
+	NotifyInterval    time.Duration `json:"notify_interval"`
+	NotifyMaxAttempts int           `json:"notify_max_attempts"`
+	NotifyRetryBase   time.Duration `json:"notify_retry_base"`
+
+	// Outbound webhooks
+	WebhookInterval        time.Duration `json:"webhook_interval"`
+	WebhookMaxAttempts     int           `json:"webhook_max_attempts"`
+	WebhookRetryBase       time.Duration `json:"webhook_retry_base"`
+	WebhookTimeout         time.Duration `json:"webhook_timeout"`
+	WebhookClickThresholds []int         `json:"webhook_click_thresholds"`
+
+	// Slack slash command; served only when a signing secret is set
+	SlackSigningSecret string `json:"-"`
+	SlackBotToken      string `json:"-"`
+	SlackAPIURL        string `json:"slack_api_url,omitempty"`
+}
+
+// String formats the configuration for logs with its secrets, and the
+// password in the database URL, redacted.
+func (c Config) String() string {
+	type plain Config
+	redacted := plain(c)
+	for _, secret := range []*string{
+		&redacted.OktaClientSecret,
+		&redacted.JWTSecret,
+		&redacted.CursorSecret,
+		&redacted.LinkActionSecret,
+		&redacted.SMTPPassword,
+		&redacted.SlackSigningSecret,
+		&redacted.SlackBotToken,
+	} {
+		if *secret != "" {
+			*secret = "REDACTED"
+		}
+	}
+	if u, err := url.Parse(c.DatabaseURL); err == nil {
+		redacted.DatabaseURL = u.Redacted()
+	} else {
+		redacted.DatabaseURL = "REDACTED"
+	}
+	return fmt.Sprintf("%+v", redacted)
+}
+
+// defaultGeneratedAliasAlphabet has no vowels, so generated aliases cannot
+// spell words, and none of the easily confused 0/o, 1/l/i.
+const defaultGeneratedAliasAlphabet = "23456789bcdfghjkmnpqrstvwxz"
+
+// defaultReservedAliases are aliases that collide with routes served by the
+// go server itself and are rejected unless an admin overrides them.
+var defaultReservedAliases = []string{
+	"admin", "api", "auth", "directory", "go", "health", "link-actions", "login",
+	"logout", "ping", "register", "search", "slack", "static",
 }
 
 func Load() (*Config, error) {
@@ -44,7 +155,94 @@ func Load() (*Config, error) {
 		Port:          port,
 		DatabaseURL:   dbURL,
 		JWTSecret:     jwtSecret,
+		CursorSecret:  getEnvOrDefault("CURSOR_SECRET", jwtSecret),
 		EnableOktaSSO: enableOktaSSO,
+
+		ReservedAliases:      getEnvList("RESERVED_ALIASES", defaultReservedAliases),
+		BlockedAliasPatterns: getEnvList("BLOCKED_ALIAS_PATTERNS", nil),
+
+		PublicURL:         strings.TrimRight(getEnvOrDefault("PUBLIC_URL", "http://localhost:"+port), "/"),
+		SelfHosts:         getEnvList("SELF_HOSTS", []string{"go"}),
+		AllowedURLSchemes: getEnvList("URL_ALLOWED_SCHEMES", []string{"http", "https"}),
+		AllowedDomains:    getEnvList("URL_ALLOWED_DOMAINS", nil),
+		DeniedDomains:     getEnvList("URL_DENIED_DOMAINS", nil),
+		ForceHTTPS:        os.Getenv("URL_FORCE_HTTPS") == "true",
+
+		DomainBlocklistPath:     os.Getenv("DOMAIN_BLOCKLIST_PATH"),
+		DomainBlocklistInterval: getEnvDuration("DOMAIN_BLOCKLIST_RELOAD_INTERVAL", time.Minute),
+		DomainBlocklistAction:   getEnvOrDefault("DOMAIN_BLOCKLIST_ACTION", "deactivate"),
+
+		GeneratedAliasAlphabet: getEnvOrDefault("GENERATED_ALIAS_ALPHABET", defaultGeneratedAliasAlphabet),
+		GeneratedAliasLength:   getEnvInt("GENERATED_ALIAS_LENGTH", 7),
+
+		SuggestRefreshInterval: getEnvDuration("SUGGEST_REFRESH_INTERVAL", 5*time.Minute),
+
+		LinkCheckEnabled:      os.Getenv("LINK_CHECK_ENABLED") == "true",
+		LinkCheckInterval:     getEnvDuration("LINK_CHECK_INTERVAL", time.Hour),
+		LinkCheckMaxAge:       getEnvDuration("LINK_CHECK_MAX_AGE", 24*time.Hour),
+		LinkCheckBatchSize:    getEnvInt("LINK_CHECK_BATCH_SIZE", 500),
+		LinkCheckConcurrency:  getEnvInt("LINK_CHECK_CONCURRENCY", 8),
+		LinkCheckHostInterval: getEnvDuration("LINK_CHECK_HOST_INTERVAL", time.Second),
+		LinkCheckTimeout:      getEnvDuration("LINK_CHECK_TIMEOUT", 10*time.Second),
+		LinkCheckAlertAfter:   getEnvInt("LINK_CHECK_ALERT_AFTER", 2),
+
+		StaleLinkEnabled:            os.Getenv("STALE_LINK_DIGESTS_ENABLED") == "true",
+		StaleLinkInterval:           getEnvDuration("STALE_LINK_INTERVAL", 24*time.Hour),
+		StaleLinkUnusedAfter:        getEnvDuration("STALE_LINK_UNUSED_AFTER", 90*24*time.Hour),
+		StaleLinkOwnerInactiveAfter: getEnvDuration("STALE_LINK_OWNER_INACTIVE_AFTER", 180*24*time.Hour),
+		StaleLinkExpiringWithin:     getEnvDuration("STALE_LINK_EXPIRING_WITHIN", 14*24*time.Hour),
+		StaleLinkNudgeEvery:         getEnvDuration("STALE_LINK_NUDGE_EVERY", 30*24*time.Hour),
+		StaleLinkKeepFor:            getEnvDuration("STALE_LINK_KEEP_FOR", 90*24*time.Hour),
+		LinkActionTTL:               getEnvDuration("LINK_ACTION_TTL", 14*24*time.Hour),
+		LinkActionSecret:            getEnvOrDefault("LINK_ACTION_SECRET", jwtSecret),
+
+		Notifier:          getEnvOrDefault("NOTIFIER", "log"),
+		SMTPHost:          os.Getenv("SMTP_HOST"),
+		SMTPPort:          getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:      os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:      os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:          os.Getenv("SMTP_FROM"),
+		NotifyWebhookURL:  os.Getenv("NOTIFY_WEBHOOK_URL"),
+		NotifyInterval:    getEnvDuration("NOTIFY_INTERVAL", 30*time.Second),
+		NotifyMaxAttempts: getEnvInt("NOTIFY_MAX_ATTEMPTS", 8),
+		NotifyRetryBase:   getEnvDuration("NOTIFY_RETRY_BASE", time.Minute),
+
+		WebhookInterval:    getEnvDuration("WEBHOOK_INTERVAL", 10*time.Second),
+		WebhookMaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", 10),
+		WebhookRetryBase:   getEnvDuration("WEBHOOK_RETRY_BASE", 30*time.Second),
+		WebhookTimeout:     getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+
+		SlackSigningSecret: os.Getenv("SLACK_SIGNING_SECRET"),
+		SlackBotToken:      os.Getenv("SLACK_BOT_TOKEN"),
+		SlackAPIURL:        getEnvOrDefault("SLACK_API_URL", "https://slack.com/api"),
+	}
+
+	if cfg.DomainBlocklistAction != "flag" && cfg.DomainBlocklistAction != "deactivate" {
+		return nil, fmt.Errorf("DOMAIN_BLOCKLIST_ACTION must be flag or deactivate")
+	}
+
+	thresholds, err := getEnvIntList("WEBHOOK_CLICK_THRESHOLDS", []int{100, 1000, 10000})
+	if err != nil {
+		return nil, err
+	}
+	cfg.WebhookClickThresholds = thresholds
+
+	switch cfg.Notifier {
+	case "smtp":
+		if cfg.SMTPHost == "" || cfg.SMTPFrom == "" {
+			return nil, fmt.Errorf("NOTIFIER=smtp needs SMTP_HOST and SMTP_FROM")
+		}
+	case "webhook":
+		if cfg.NotifyWebhookURL == "" {
+			return nil, fmt.Errorf("NOTIFIER=webhook needs NOTIFY_WEBHOOK_URL")
+		}
+	case "log", "memory":
+	default:
+		return nil, fmt.Errorf("NOTIFIER must be smtp, webhook, log or memory")
+	}
+
+	if cfg.SlackSigningSecret != "" && cfg.SlackBotToken == "" {
+		return nil, fmt.Errorf("SLACK_SIGNING_SECRET needs SLACK_BOT_TOKEN to look up Slack users")
 	}
 
 	if cfg.EnableOktaSSO {
@@ -62,3 +260,56 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvList reads a comma-separated environment variable, trimming blanks.
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvInt reads a positive integer from the environment.
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvIntList reads a comma-separated list of positive integers from the
+// environment.
+func getEnvIntList(key string, defaultValue []int) ([]int, error) {
+	items := getEnvList(key, nil)
+	if items == nil {
+		return defaultValue, nil
+	}
+
+	values := make([]int, len(items))
+	for i, item := range items {
+		value, err := strconv.Atoi(item)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("%s must be a comma-separated list of positive integers", key)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// getEnvDuration reads a duration such as "30s" or "5m" from the environment.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,9 @@
+-- Reserved words and blocked patterns managed by admins at runtime
+CREATE TABLE IF NOT EXISTS alias_rules (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('reserved', 'blocked')),
+    pattern VARCHAR(255) NOT NULL,
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kind, pattern)
+);
@@ -0,0 +1,18 @@
+-- Audit trail for security and admin actions on links
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id SERIAL PRIMARY KEY,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(50) NOT NULL,
+    link_id INTEGER,
+    target_user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    details JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target_user ON audit_logs(target_user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_link ON audit_logs(link_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at);
+
+-- Links whose destination matched the malicious domain blocklist
+ALTER TABLE links ADD COLUMN IF NOT EXISTS flagged_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS flag_reason TEXT;
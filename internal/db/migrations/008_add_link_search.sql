@@ -0,0 +1,51 @@
+-- Full-text and trigram search across the link directory
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+-- Aliases and destinations are split on punctuation so "eng-oncall" and
+-- "wiki.example.com/runbooks" match their individual words. Descriptions are
+-- stemmed; everything else is indexed as-is.
+CREATE OR REPLACE FUNCTION links_search_vector_trigger() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('simple',
+            coalesce(NEW.alias, '') || ' ' || regexp_replace(coalesce(NEW.alias, ''), '[-_/.]+', ' ', 'g')), 'A') ||
+        setweight(to_tsvector('simple', coalesce((
+            SELECT string_agg(t.name, ' ')
+            FROM link_tags lt
+            JOIN tags t ON t.id = lt.tag_id
+            WHERE lt.link_id = NEW.id), '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(NEW.description, '')), 'C') ||
+        setweight(to_tsvector('simple',
+            regexp_replace(coalesce(NEW.destination_url, ''), '[:/.?=&#_-]+', ' ', 'g')), 'D');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS links_search_vector_update ON links;
+CREATE TRIGGER links_search_vector_update
+    BEFORE INSERT OR UPDATE OF alias, description, destination_url, search_vector ON links
+    FOR EACH ROW EXECUTE FUNCTION links_search_vector_trigger();
+
+-- Tags live in their own table, so changing them forces a recompute.
+CREATE OR REPLACE FUNCTION link_tags_search_vector_trigger() RETURNS trigger AS $$
+BEGIN
+    UPDATE links SET search_vector = NULL
+    WHERE id = CASE WHEN TG_OP = 'DELETE' THEN OLD.link_id ELSE NEW.link_id END;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS link_tags_search_vector_update ON link_tags;
+CREATE TRIGGER link_tags_search_vector_update
+    AFTER INSERT OR DELETE ON link_tags
+    FOR EACH ROW EXECUTE FUNCTION link_tags_search_vector_trigger();
+
+-- Backfill existing links
+UPDATE links SET search_vector = NULL;
+
+CREATE INDEX IF NOT EXISTS idx_links_search_vector ON links USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_links_alias_trgm ON links USING GIN (alias gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_links_destination_trgm ON links USING GIN (destination_url gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_links_description_trgm ON links USING GIN (description gin_trgm_ops);
@@ -0,0 +1,6 @@
+-- Who can discover a link: public links appear in the directory, search and
+-- suggestions; private ones only for their owner
+ALTER TABLE links ADD COLUMN IF NOT EXISTS visibility VARCHAR(20) NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'private'));
+
+CREATE INDEX IF NOT EXISTS idx_links_visibility ON links(visibility);
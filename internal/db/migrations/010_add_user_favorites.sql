@@ -0,0 +1,10 @@
+-- Links a user has starred, in the order they arranged them
+CREATE TABLE IF NOT EXISTS user_favorites (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    link_id INTEGER NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, link_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_favorites_link_id ON user_favorites(link_id);
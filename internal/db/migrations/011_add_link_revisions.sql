@@ -0,0 +1,12 @@
+-- Destination history of links changed by find-and-replace migrations
+CREATE TABLE IF NOT EXISTS link_revisions (
+    id SERIAL PRIMARY KEY,
+    link_id INTEGER NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+    changed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    old_destination_url TEXT NOT NULL,
+    new_destination_url TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_revisions_link ON link_revisions(link_id, created_at);
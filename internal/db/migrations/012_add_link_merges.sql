@@ -0,0 +1,4 @@
+-- Links merged into another link forward to it instead of their own destination
+ALTER TABLE links ADD COLUMN IF NOT EXISTS merged_into INTEGER REFERENCES links(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_links_merged_into ON links(merged_into) WHERE merged_into IS NOT NULL;
@@ -0,0 +1,14 @@
+-- Result of the latest broken-destination check of each link
+CREATE TABLE IF NOT EXISTS link_health (
+    link_id INTEGER PRIMARY KEY REFERENCES links(id) ON DELETE CASCADE,
+    destination_url TEXT NOT NULL,
+    status_code INTEGER,
+    error TEXT,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    healthy BOOLEAN NOT NULL,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    checked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_health_checked_at ON link_health(checked_at);
+CREATE INDEX IF NOT EXISTS idx_link_health_broken ON link_health(link_id) WHERE NOT healthy;
@@ -0,0 +1,8 @@
+-- When each link was last put in its owner's stale-link digest, and until
+-- when the owner asked to keep it out of the digest
+CREATE TABLE IF NOT EXISTS link_nudges (
+    link_id INTEGER PRIMARY KEY REFERENCES links(id) ON DELETE CASCADE,
+    reasons TEXT[] NOT NULL DEFAULT '{}',
+    nudged_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    snoozed_until TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1,3 @@
+-- Email lookups ignore case, as identity providers such as Slack do not
+-- keep the casing a user signed up with.
+CREATE INDEX IF NOT EXISTS idx_users_email_lower ON users(LOWER(email));
@@ -0,0 +1,10 @@
+-- Outbox of writes to links. Each write records its change here in the
+-- same transaction; the webhook job turns pending changes into deliveries
+-- and removes them.
+CREATE TABLE IF NOT EXISTS link_changes (
+    id BIGSERIAL PRIMARY KEY,
+    change_type VARCHAR(20) NOT NULL,
+    link_ids BIGINT[] NOT NULL,
+    removed JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -12,17 +15,29 @@ type AdminHandler struct {
 	analyticsRepo *models.AnalyticsRepository
 	linkRepo      *models.LinkRepository
 	userRepo      *models.UserRepository
+	aliasPolicy   *services.AliasPolicy
+	urlPolicy     *services.URLPolicy
+	auditRepo     *models.AuditLogRepository
+	cursors       *CursorCodec
 }
 
 func NewAdminHandler(
 	analyticsRepo *models.AnalyticsRepository,
 	linkRepo *models.LinkRepository,
 	userRepo *models.UserRepository,
+	aliasPolicy *services.AliasPolicy,
+	urlPolicy *services.URLPolicy,
+	auditRepo *models.AuditLogRepository,
+	cursors *CursorCodec,
 ) *AdminHandler {
 	return &AdminHandler{
 		analyticsRepo: analyticsRepo,
 		linkRepo:      linkRepo,
 		userRepo:      userRepo,
+		aliasPolicy:   aliasPolicy,
+		urlPolicy:     urlPolicy,
+		auditRepo:     auditRepo,
+		cursors:       cursors,
 	}
 }
 
@@ -49,15 +64,235 @@ func (h *AdminHandler) GetRedirectsOverTime(c *gin.Context) {
 	c.JSON(200, data)
 }
 
-// Add admin-specific link management endpoints
+// ListAllLinks handles GET /api/admin/links: every user's links, filtered by
+// the usual list parameters plus owner, sorted by any column and paged with
+// a cursor. The total is estimated unless total=exact is asked for.
 func (h *AdminHandler) ListAllLinks(c *gin.Context) {
-	// TODO: Implement with pagination and filtering options
-	c.JSON(501, gin.H{"error": "not implemented"})
+	opts, err := listOptionsFromQuery(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	opts.OwnerEmail = c.Query("owner")
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	links, response := listLinksPage(c, h.linkRepo, h.cursors, cursorKindAdminLinks, opts, limit, totalApprox)
+	if links == nil {
+		return
+	}
+	response["items"] = links
+
+	c.JSON(200, response)
+}
+
+// ListBrokenLinks handles GET /api/admin/links/broken: every link whose
+// destination failed its latest check, with the result of that check.
+// Accepts the filters of ListAllLinks. The total is estimated unless
+// total=exact is asked for.
+func (h *AdminHandler) ListBrokenLinks(c *gin.Context) {
+	opts, err := listOptionsFromQuery(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	opts.OwnerEmail = c.Query("owner")
+	opts.Status = models.LinkStatusBroken
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	links, response := listLinksPage(c, h.linkRepo, h.cursors, cursorKindBrokenLinks, opts, limit, totalApprox)
+	if links == nil {
+		return
+	}
+	if err := h.linkRepo.LoadHealth(c.Request.Context(), links); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	response["items"] = links
+
+	c.JSON(200, response)
+}
+
+// adminUpdateLinkRequest lists the fields an admin may change on any link.
+// Omitted fields are left alone; ClearExpiry removes the expiry.
+type adminUpdateLinkRequest struct {
+	DestinationURL *string    `json:"destinationUrl"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+	ClearExpiry    bool       `json:"clearExpiry"`
+	IsActive       *bool      `json:"isActive"`
+	OwnerEmail     *string    `json:"ownerEmail"`
+	Reason         string     `json:"reason"`
 }
 
+// UpdateLinkAdmin handles PUT /api/admin/links/:alias. The edit is recorded
+// in the audit log addressed to the original owner, and to the new owner too
+// when the link changes hands, in the same transaction as the edit.
 func (h *AdminHandler) UpdateLinkAdmin(c *gin.Context) {
-	// TODO: Implement
-	c.JSON(501, gin.H{"error": "not implemented"})
+	var req adminUpdateLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		c.JSON(400, gin.H{"error": "a reason is required for admin edits"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	link, err := h.linkRepo.GetByAlias(ctx, c.Param("alias"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	before := *link
+	changes := map[string]interface{}{}
+
+	if req.DestinationURL != nil && *req.DestinationURL != link.DestinationURL {
+		destination, err := h.urlPolicy.Apply(ctx, link.Alias, *req.DestinationURL)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+		link.DestinationURL = destination
+		changes["destinationUrl"] = gin.H{"from": before.DestinationURL, "to": destination}
+	}
+
+	if req.ClearExpiry {
+		link.ExpiresAt = nil
+	} else if req.ExpiresAt != nil {
+		link.ExpiresAt = req.ExpiresAt
+	}
+	if !sameTime(before.ExpiresAt, link.ExpiresAt) {
+		changes["expiresAt"] = gin.H{"from": before.ExpiresAt, "to": link.ExpiresAt}
+	}
+
+	if req.IsActive != nil && *req.IsActive != link.IsActive {
+		link.IsActive = *req.IsActive
+		changes["isActive"] = gin.H{"from": before.IsActive, "to": link.IsActive}
+	}
+
+	if req.OwnerEmail != nil && !strings.EqualFold(*req.OwnerEmail, link.OwnerEmail) {
+		owner, err := h.userRepo.GetByEmail(ctx, *req.OwnerEmail)
+		if errors.Is(err, models.ErrNotFound) {
+			c.JSON(400, gin.H{"error": "no user with email " + *req.OwnerEmail})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		link.CreatedBy = owner.ID
+		link.OwnerEmail = owner.Email
+		changes["owner"] = gin.H{"from": before.OwnerEmail, "to": owner.Email}
+	}
+
+	if len(changes) == 0 {
+		c.JSON(200, link)
+		return
+	}
+
+	actorID := getUserIDFromContext(c)
+	details := gin.H{"alias": link.Alias, "reason": req.Reason, "changes": changes}
+	entry, err := models.NewAuditLog(models.AuditLinkAdminEdit, actorID, link.ID, before.CreatedBy, details)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	audit := []*models.AuditLog{entry}
+	if link.CreatedBy != before.CreatedBy {
+		entry, err := models.NewAuditLog(models.AuditLinkTransferred, actorID, link.ID, link.CreatedBy, details)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+		audit = append(audit, entry)
+	}
+
+	if err := h.linkRepo.AdminUpdate(ctx, link, audit...); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	h.auditRepo.Recorded(ctx, audit...)
+
+	c.JSON(200, link)
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// Alias rule management
+func (h *AdminHandler) ListAliasRules(c *gin.Context) {
+	rules, err := h.aliasPolicy.ListRules(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	reserved, blocked := h.aliasPolicy.Defaults()
+	c.JSON(200, gin.H{
+		"rules": rules,
+		"defaults": gin.H{
+			"reserved": reserved,
+			"blocked":  blocked,
+		},
+	})
+}
+
+type aliasRuleRequest struct {
+	Kind    string `json:"kind" binding:"required"`
+	Pattern string `json:"pattern" binding:"required"`
+}
+
+func (h *AdminHandler) CreateAliasRule(c *gin.Context) {
+	var req aliasRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userID := getUserIDFromContext(c)
+	rule := &models.AliasRule{
+		Kind:      req.Kind,
+		Pattern:   req.Pattern,
+		CreatedBy: &userID,
+	}
+
+	if err := h.aliasPolicy.AddRule(c.Request.Context(), rule); err != nil {
+		if errors.Is(err, models.ErrDuplicate) {
+			c.JSON(409, gin.H{"error": "rule already exists"})
+			return
+		}
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(201, rule)
+}
+
+func (h *AdminHandler) DeleteAliasRule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid rule ID"})
+		return
+	}
+
+	if err := h.aliasPolicy.RemoveRule(c.Request.Context(), id); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.Status(204)
 }
 
 func (h *AdminHandler) GetPopularLinks(c *gin.Context) {
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditRepo *models.AuditLogRepository
+}
+
+func NewAuditHandler(auditRepo *models.AuditLogRepository) *AuditHandler {
+	return &AuditHandler{
+		auditRepo: auditRepo,
+	}
+}
+
+// ListMine returns the audit entries addressed to the current user, such as
+// notices that one of their links was flagged.
+func (h *AuditHandler) ListMine(c *gin.Context) {
+	filter := auditFilterFromQuery(c)
+	filter.TargetUserID = getUserIDFromContext(c)
+
+	entries, err := h.auditRepo.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"items": entries})
+}
+
+// ListAll returns audit entries for every user. Admin only.
+func (h *AuditHandler) ListAll(c *gin.Context) {
+	filter := auditFilterFromQuery(c)
+	filter.TargetUserID, _ = strconv.ParseInt(c.Query("user_id"), 10, 64)
+
+	entries, err := h.auditRepo.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"items": entries})
+}
+
+func auditFilterFromQuery(c *gin.Context) models.AuditLogFilter {
+	linkID, _ := strconv.ParseInt(c.Query("link_id"), 10, 64)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	return models.AuditLogFilter{
+		LinkID: linkID,
+		Action: c.Query("action"),
+		Limit:  limit,
+		Offset: offset,
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"log"
 
 	"github.com/devingoodsell/go-links-free/internal/auth"
+	"github.com/devingoodsell/go-links-free/internal/middleware"
 	"github.com/devingoodsell/go-links-free/internal/models"
 	"github.com/gin-gonic/gin"
 )
@@ -16,12 +17,14 @@ import (
 type AuthHandler struct {
 	authService *auth.AuthService
 	userRepo    *models.UserRepository
+	linkRepo    *models.LinkRepository
 }
 
-func NewAuthHandler(authService *auth.AuthService, userRepo *models.UserRepository) *AuthHandler {
+func NewAuthHandler(authService *auth.AuthService, userRepo *models.UserRepository, linkRepo *models.LinkRepository) *AuthHandler {
 	return &AuthHandler{
 		authService: authService,
 		userRepo:    userRepo,
+		linkRepo:    linkRepo,
 	}
 }
 
@@ -69,6 +72,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		// Don't fail the login if this fails
 	}
 
+	setSessionCookie(c, token)
 	c.JSON(http.StatusOK, gin.H{"token": token})
 }
 
@@ -85,9 +89,32 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	setSessionCookie(c, token)
 	c.JSON(200, authResponse{Token: token})
 }
 
+// Logout handles POST /api/auth/logout by expiring the session cookie.
+// Tokens are not tracked server-side, so ones already issued stay valid
+// until they expire.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	writeSessionCookie(c, "", -1)
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// setSessionCookie lets server-rendered pages such as the directory
+// authenticate the browser with the token just issued.
+func setSessionCookie(c *gin.Context, token string) {
+	writeSessionCookie(c, token, int((24 * time.Hour).Seconds()))
+}
+
+// writeSessionCookie sets the session cookie with SameSite=Lax, so it goes
+// along when users follow links to the go server but not with requests
+// other sites make in the background.
+func writeSessionCookie(c *gin.Context, token string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(middleware.SessionCookie, token, maxAge, "/", "", true, true)
+}
+
 // OKTA SSO handlers
 func (h *AuthHandler) OktaLogin(c *gin.Context) {
 	state := generateState()
@@ -128,6 +155,7 @@ func (h *AuthHandler) OktaCallback(c *gin.Context) {
 		return
 	}
 
+	setSessionCookie(c, token)
 	c.JSON(200, authResponse{Token: token})
 }
 
@@ -137,7 +165,8 @@ func generateState() string {
 	return base64.URLEncoding.EncodeToString(b)
 }
 
-// GetCurrentUser handles the /api/auth/me endpoint
+// GetCurrentUser handles the /api/auth/me endpoint. With
+// include=favorites the response also summarizes the user's favorites.
 func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 	// Get user claims from context (set by auth middleware)
 	claims, exists := c.Get("user")
@@ -159,5 +188,26 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	if c.Query("include") != "favorites" {
+		c.JSON(http.StatusOK, user)
+		return
+	}
+
+	summary := &favoritesSummary{}
+	summary.Count, err = h.linkRepo.CountFavorites(c.Request.Context(), user.ID)
+	if err == nil {
+		summary.Top, err = h.linkRepo.ListFavorites(c.Request.Context(), user.ID, favoritesSummaryLimit)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get favorites"})
+		return
+	}
+	if summary.Top == nil {
+		summary.Top = []*models.Link{}
+	}
+
+	c.JSON(http.StatusOK, struct {
+		*models.User
+		Favorites *favoritesSummary `json:"favorites"`
+	}{user, summary})
 }
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Bulk modes.
+const (
+	bulkAtomic     = "atomic"
+	bulkBestEffort = "best_effort"
+)
+
+// BulkHandler applies one action to many links at once.
+type BulkHandler struct {
+	linkRepo  *models.LinkRepository
+	userRepo  *models.UserRepository
+	auditRepo *models.AuditLogRepository
+}
+
+func NewBulkHandler(linkRepo *models.LinkRepository, userRepo *models.UserRepository, auditRepo *models.AuditLogRepository) *BulkHandler {
+	return &BulkHandler{
+		linkRepo:  linkRepo,
+		userRepo:  userRepo,
+		auditRepo: auditRepo,
+	}
+}
+
+type bulkRequest struct {
+	Action     string              `json:"action"`
+	IDs        []int64             `json:"ids"`
+	Filter     *models.ListOptions `json:"filter"`
+	Mode       string              `json:"mode"` // atomic (default) or best_effort
+	AllUsers   bool                `json:"allUsers"`
+	ExpiresAt  *time.Time          `json:"expiresAt"`  // set_expiry; null clears the expiry
+	Tag        string              `json:"tag"`        // add_tag
+	OwnerEmail string              `json:"ownerEmail"` // transfer_owner
+}
+
+// Apply handles POST /api/links/bulk. Links are chosen by "ids" or by a
+// "filter" with the fields of the link list, and are limited to the
+// caller's own unless an admin sets allUsers. The response reports what
+// happened to every link.
+func (h *BulkHandler) Apply(c *gin.Context) {
+	var req bulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	if (len(req.IDs) == 0) == (req.Filter == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "specify either ids or filter"})
+		return
+	}
+	if req.AllUsers && !isAdminFromContext(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can change other users' links"})
+		return
+	}
+
+	opts := models.BulkOptions{
+		Filter:  req.Filter,
+		ActorID: getUserIDFromContext(c),
+		Admin:   req.AllUsers,
+	}
+	if len(req.IDs) > 0 {
+		opts.IDs = req.IDs
+	}
+	switch req.Mode {
+	case "", bulkAtomic:
+		req.Mode, opts.Atomic = bulkAtomic, true
+	case bulkBestEffort:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be atomic or best_effort"})
+		return
+	}
+
+	action := models.BulkAction{Type: req.Action, ExpiresAt: req.ExpiresAt}
+	switch req.Action {
+	case models.BulkActionAddTag:
+		tags, err := models.NormalizeTags([]string{req.Tag})
+		if err != nil || len(tags) != 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "add_tag needs one valid tag"})
+			return
+		}
+		action.Tag = tags[0]
+	case models.BulkActionTransfer:
+		owner, err := h.userRepo.GetByEmail(c.Request.Context(), req.OwnerEmail)
+		if errors.Is(err, models.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "new owner not found"})
+			return
+		}
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+		action.NewOwnerID = owner.ID
+	}
+
+	result, ok := h.run(c, opts, action)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"action":  req.Action,
+		"mode":    req.Mode,
+		"applied": result.Applied,
+		"counts":  result.Counts,
+		"items":   result.Items,
+	})
+}
+
+// run applies the action. Changes made to other users' links are audited
+// along with them.
+func (h *BulkHandler) run(c *gin.Context, opts models.BulkOptions, action models.BulkAction) (*models.BulkResult, bool) {
+	result, err := h.linkRepo.BulkApply(c.Request.Context(), opts, action)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	h.auditRepo.Recorded(c.Request.Context(), result.Audit...)
+	return result, true
+}
+
+// BulkDelete handles POST /api/links/bulk/delete, deleting the caller's
+// links by ID, all or none.
+func (h *BulkHandler) BulkDelete(c *gin.Context) {
+	var req struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	h.applyByID(c, req.IDs, models.BulkAction{Type: models.BulkActionDelete})
+}
+
+// BulkUpdateStatus handles POST /api/links/bulk/status, activating or
+// deactivating the caller's links by ID, all or none.
+func (h *BulkHandler) BulkUpdateStatus(c *gin.Context) {
+	var req struct {
+		IDs      []int64 `json:"ids"`
+		IsActive bool    `json:"is_active"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	action := models.BulkAction{Type: models.BulkActionDeactivate}
+	if req.IsActive {
+		action.Type = models.BulkActionActivate
+	}
+	h.applyByID(c, req.IDs, action)
+}
+
+// applyByID keeps the original bulk endpoints' responses: no content on
+// success, otherwise an error naming the first link at fault along with
+// the per-link report.
+func (h *BulkHandler) applyByID(c *gin.Context, ids []int64, action models.BulkAction) {
+	if len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no links specified"})
+		return
+	}
+
+	result, ok := h.run(c, models.BulkOptions{IDs: ids, ActorID: getUserIDFromContext(c), Atomic: true}, action)
+	if !ok {
+		return
+	}
+
+	err := result.Err()
+	status := http.StatusInternalServerError
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+		return
+	case errors.Is(err, models.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, models.ErrUnauthorized):
+		status = http.StatusForbidden
+	}
+	c.JSON(status, gin.H{"error": err.Error(), "items": result.Items})
+}
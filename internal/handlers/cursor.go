@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// Cursor kinds, so a token from one listing is not accepted by another.
+const (
+	cursorKindLinks       = "links"
+	cursorKindAdminLinks  = "admin_links"
+	cursorKindBrokenLinks = "broken_links"
+	cursorKindSearch      = "search"
+)
+
+// pageCursor is the payload of a page token: the keyset position of the
+// first or last item of the page, which listing and sort order it belongs to
+// and which way it pages.
+type pageCursor struct {
+	Kind     string          `json:"k"`
+	Sort     string          `json:"o,omitempty"`
+	Backward bool            `json:"b,omitempty"`
+	Position json.RawMessage `json:"p"`
+}
+
+// CursorCodec turns keyset positions into opaque page tokens signed with
+// HMAC-SHA256, so clients cannot forge positions or tamper with them.
+type CursorCodec struct {
+	key []byte
+}
+
+func NewCursorCodec(secret string) *CursorCodec {
+	return &CursorCodec{key: []byte(secret)}
+}
+
+// Encode makes a token for pos. backward marks a prev_cursor, which pages to
+// the items before pos.
+func (cc *CursorCodec) Encode(kind, sort string, backward bool, pos interface{}) string {
+	rawPos, _ := json.Marshal(pos)
+	payload, _ := json.Marshal(pageCursor{Kind: kind, Sort: sort, Backward: backward, Position: rawPos})
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(cc.sign(encoded))
+}
+
+// Decode verifies token, checks it was made for the same listing and sort
+// order and reads its position into pos. It reports whether the token pages
+// backward.
+func (cc *CursorCodec) Decode(token, kind, sort string, pos interface{}) (bool, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false, errInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, cc.sign(encoded)) {
+		return false, errInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return false, errInvalidCursor
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return false, errInvalidCursor
+	}
+	if cursor.Kind != kind || cursor.Sort != sort {
+		return false, errInvalidCursor
+	}
+	if err := json.Unmarshal(cursor.Position, pos); err != nil {
+		return false, errInvalidCursor
+	}
+	return cursor.Backward, nil
+}
+
+func (cc *CursorCodec) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, cc.key)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const cursorKindDirectory = "directory"
+
+// directorySorts are the orderings offered on the directory page.
+var directorySorts = []struct {
+	Value string
+	Label string
+}{
+	{"clicks_desc", "Most used"},
+	{"accessed_desc", "Recently used"},
+	{"created_desc", "Newest"},
+	{"alias_asc", "Alias"},
+	{"owner_asc", "Owner"},
+}
+
+// directoryEntry is what the directory shows about a link.
+type directoryEntry struct {
+	Alias          string     `json:"alias"`
+	DestinationURL string     `json:"destinationUrl"`
+	Description    string     `json:"description"`
+	Tags           []string   `json:"tags"`
+	OwnerEmail     string     `json:"ownerEmail"`
+	Clicks         int        `json:"clicks"`
+	WeeklyClicks   int        `json:"weeklyClicks"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt,omitempty"`
+}
+
+var directoryPageTemplate = template.Must(template.New("directory").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>go links directory</title>
+  <link rel="search" type="application/opensearchdescription+xml" title="go" href="{{.BaseURL}}/opensearch.xml">
+</head>
+<body>
+  <h1>go links directory</h1>
+  <form action="{{.BaseURL}}/directory" method="get">
+    <input type="search" name="search" value="{{.Search}}" placeholder="Search">
+    <input type="text" name="tag" value="{{.Tag}}" placeholder="Tag">
+    <input type="text" name="owner" value="{{.Owner}}" placeholder="Owner email">
+    <select name="sort">
+      {{range .Sorts}}<option value="{{.Value}}"{{if eq .Value $.Sort}} selected{{end}}>{{.Label}}</option>{{end}}
+    </select>
+    <button type="submit">Filter</button>
+  </form>
+  {{if .Total}}<p>About {{.Total}} links</p>{{end}}
+  <table>
+    <thead>
+      <tr><th>Link</th><th>Description</th><th>Tags</th><th>Owner</th><th>Clicks</th></tr>
+    </thead>
+    <tbody>
+      {{range .Entries}}
+      <tr>
+        <td><a href="{{$.BaseURL}}/go/{{.Alias}}">go/{{.Alias}}</a><div>{{.DestinationURL}}</div></td>
+        <td>{{.Description}}</td>
+        <td>{{range .Tags}}<a href="{{$.BaseURL}}/directory?tag={{.}}">{{.}}</a> {{end}}</td>
+        <td>{{.OwnerEmail}}</td>
+        <td>{{.Clicks}}</td>
+      </tr>
+      {{else}}
+      <tr><td colspan="5">No links match these filters.</td></tr>
+      {{end}}
+    </tbody>
+  </table>
+  {{if .PrevURL}}<a href="{{.PrevURL}}">Previous</a>{{end}}
+  {{if .NextURL}}<a href="{{.NextURL}}">Next</a>{{end}}
+</body>
+</html>
+`))
+
+// DirectoryHandler lets every signed-in user browse the public links of the
+// whole organisation.
+type DirectoryHandler struct {
+	linkRepo *models.LinkRepository
+	cursors  *CursorCodec
+	baseURL  string
+}
+
+func NewDirectoryHandler(linkRepo *models.LinkRepository, cursors *CursorCodec, baseURL string) *DirectoryHandler {
+	return &DirectoryHandler{
+		linkRepo: linkRepo,
+		cursors:  cursors,
+		baseURL:  baseURL,
+	}
+}
+
+// List handles GET /api/directory: active public links of every user,
+// filtered by the usual list parameters plus owner and most used first by
+// default.
+func (h *DirectoryHandler) List(c *gin.Context) {
+	entries, response := h.list(c)
+	if entries == nil {
+		return
+	}
+	response["items"] = entries
+	c.JSON(http.StatusOK, response)
+}
+
+// Page serves the same listing as a server-rendered page at /directory.
+func (h *DirectoryHandler) Page(c *gin.Context) {
+	entries, response := h.list(c)
+	if entries == nil {
+		return
+	}
+
+	data := gin.H{
+		"BaseURL": h.baseURL,
+		"Search":  c.Query("search"),
+		"Tag":     c.Query("tag"),
+		"Owner":   c.Query("owner"),
+		"Sort":    c.DefaultQuery("sort", "clicks_desc"),
+		"Sorts":   directorySorts,
+		"Entries": entries,
+		"Total":   response["totalCount"],
+	}
+	if cursor, ok := response["next_cursor"].(string); ok {
+		data["NextURL"] = h.pageURL(c, cursor)
+	}
+	if cursor, ok := response["prev_cursor"].(string); ok {
+		data["PrevURL"] = h.pageURL(c, cursor)
+	}
+
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := directoryPageTemplate.Execute(c.Writer, data); err != nil {
+		log.Printf("Error rendering directory page: %v", err)
+	}
+}
+
+func (h *DirectoryHandler) list(c *gin.Context) ([]directoryEntry, gin.H) {
+	opts, err := listOptionsFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, nil
+	}
+	opts.Status = models.LinkStatusActive
+	opts.Visibility = models.VisibilityPublic
+	opts.OwnerEmail = c.Query("owner")
+	if opts.SortBy == "" {
+		opts.SortBy = "clicks_desc"
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	links, response := listLinksPage(c, h.linkRepo, h.cursors, cursorKindDirectory, opts, limit, totalApprox)
+	if links == nil {
+		return nil, nil
+	}
+
+	entries := make([]directoryEntry, len(links))
+	for i, link := range links {
+		entries[i] = directoryEntry{
+			Alias:          link.Alias,
+			DestinationURL: link.DestinationURL,
+			Description:    link.Description,
+			Tags:           link.Tags,
+			OwnerEmail:     link.OwnerEmail,
+		}
+		if link.Stats != nil {
+			entries[i].Clicks = link.Stats.TotalCount
+			entries[i].WeeklyClicks = link.Stats.WeeklyCount
+			entries[i].LastAccessedAt = link.Stats.LastAccessedAt
+		}
+	}
+	return entries, response
+}
+
+// pageURL is the current page URL with its cursor replaced.
+func (h *DirectoryHandler) pageURL(c *gin.Context, cursor string) string {
+	query := url.Values{}
+	for key, values := range c.Request.URL.Query() {
+		query[key] = values
+	}
+	query.Set("cursor", cursor)
+	return h.baseURL + "/directory?" + query.Encode()
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// DuplicateHandler finds links leading to the same place and merges them.
+type DuplicateHandler struct {
+	linkRepo  *models.LinkRepository
+	auditRepo *models.AuditLogRepository
+}
+
+func NewDuplicateHandler(linkRepo *models.LinkRepository, auditRepo *models.AuditLogRepository) *DuplicateHandler {
+	return &DuplicateHandler{
+		linkRepo:  linkRepo,
+		auditRepo: auditRepo,
+	}
+}
+
+// List handles GET /api/links/duplicates: the caller's links grouped by
+// normalized destination, or every user's for admins passing all=true.
+func (h *DuplicateHandler) List(c *gin.Context) {
+	var scope models.ListOptions
+	if !(c.Query("all") == "true" && isAdminFromContext(c)) {
+		scope.OwnerID = getUserIDFromContext(c)
+	}
+
+	groups, err := services.FindDuplicates(c.Request.Context(), h.linkRepo, scope)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"groups": groups})
+}
+
+type mergeRequest struct {
+	Keep   string   `json:"keep"`
+	Merge  []string `json:"merge"`
+	Reason string   `json:"reason"`
+}
+
+// Merge handles POST /api/links/merge. The links in "merge" keep their
+// aliases but forward to "keep", which takes over their clicks. All of them
+// must lead to the same normalized destination. Merging other users' links
+// is for admins and needs a reason.
+func (h *DuplicateHandler) Merge(c *gin.Context) {
+	var req mergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Keep == "" || len(req.Merge) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "keep and merge are required"})
+		return
+	}
+	if len(req.Merge) > models.MaxBulkLinks {
+		c.JSON(http.StatusBadRequest, gin.H{"error": models.ErrBulkTooLarge.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	links, err := h.linkRepo.GetByAliases(ctx, append([]string{req.Keep}, req.Merge...))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	byAlias := linksByAlias(links)
+
+	keep := byAlias[req.Keep]
+	if keep == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no link with alias " + req.Keep})
+		return
+	}
+	if keep.MergedInto != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": req.Keep + " is itself merged into another link"})
+		return
+	}
+
+	actorID := getUserIDFromContext(c)
+	isAdmin := isAdminFromContext(c)
+	destination := services.NormalizeDestination(keep.DestinationURL)
+	var merged []*models.Link
+	seen := map[string]bool{req.Keep: true}
+	for _, alias := range req.Merge {
+		if seen[alias] {
+			continue
+		}
+		seen[alias] = true
+
+		link := byAlias[alias]
+		switch {
+		case link == nil:
+			c.JSON(http.StatusNotFound, gin.H{"error": "no link with alias " + alias})
+			return
+		case services.NormalizeDestination(link.DestinationURL) != destination:
+			c.JSON(http.StatusBadRequest, gin.H{"error": alias + " leads somewhere else than " + req.Keep})
+			return
+		}
+		merged = append(merged, link)
+	}
+	if len(merged) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nothing to merge"})
+		return
+	}
+
+	foreign := false
+	for _, link := range append([]*models.Link{keep}, merged...) {
+		foreign = foreign || link.CreatedBy != actorID
+	}
+	if foreign && !isAdmin {
+		respondWithError(c, models.ErrUnauthorized)
+		return
+	}
+	if foreign && strings.TrimSpace(req.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required to merge other users' links"})
+		return
+	}
+
+	ids := make([]int64, len(merged))
+	aliases := make([]string, len(merged))
+	for i, link := range merged {
+		ids[i], aliases[i] = link.ID, link.Alias
+	}
+	// Other users are told both when their links are merged away and when
+	// their kept link takes over someone's links and clicks.
+	var audit []*models.AuditLog
+	for _, link := range append([]*models.Link{keep}, merged...) {
+		if link.CreatedBy == actorID {
+			continue
+		}
+		changes := gin.H{"mergedInto": gin.H{"from": nil, "to": keep.Alias}}
+		if link == keep {
+			changes = gin.H{"merged": gin.H{"from": nil, "to": aliases}}
+		}
+		details := gin.H{"alias": link.Alias, "reason": req.Reason, "changes": changes}
+		entry, err := models.NewAuditLog(models.AuditLinkAdminEdit, actorID, link.ID, link.CreatedBy, details)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+		audit = append(audit, entry)
+	}
+
+	if err := h.linkRepo.MergeLinks(ctx, keep.ID, ids, audit...); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	h.auditRepo.Recorded(ctx, audit...)
+
+	kept, err := h.linkRepo.GetByID(ctx, keep.ID)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"link": kept, "merged": aliases})
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// respondWithError maps repository and policy errors to HTTP responses.
+// Policy violations are returned as structured errors so clients can point
+// at the offending field.
+func respondWithError(c *gin.Context, err error) {
+	var policyErr *services.PolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		c.JSON(http.StatusBadRequest, policyErr)
+	case errors.Is(err, models.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "alias already exists"})
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, models.ErrUnauthorized):
+		c.JSON(http.StatusForbidden, gin.H{"error": "unauthorized"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// favoritesSummaryLimit is how many favorites GetCurrentUser includes.
+const favoritesSummaryLimit = 5
+
+type reorderFavoritesRequest struct {
+	Aliases []string `json:"aliases" binding:"required"`
+}
+
+// favoritesSummary is the short view of a user's favorites included in
+// GetCurrentUser.
+type favoritesSummary struct {
+	Count int            `json:"count"`
+	Top   []*models.Link `json:"top"`
+}
+
+// FavoriteHandler manages the links a user starred, which may belong to
+// anyone.
+type FavoriteHandler struct {
+	linkRepo *models.LinkRepository
+	suggest  *services.SuggestIndex
+}
+
+func NewFavoriteHandler(linkRepo *models.LinkRepository, suggest *services.SuggestIndex) *FavoriteHandler {
+	return &FavoriteHandler{
+		linkRepo: linkRepo,
+		suggest:  suggest,
+	}
+}
+
+// List handles GET /api/favorites.
+func (h *FavoriteHandler) List(c *gin.Context) {
+	links, err := h.linkRepo.ListFavorites(c.Request.Context(), getUserIDFromContext(c), 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if links == nil {
+		links = []*models.Link{}
+	}
+	c.JSON(http.StatusOK, gin.H{"items": links})
+}
+
+// Star handles PUT /api/favorites/:alias. Other users' private links
+// cannot be starred and are reported as not found.
+func (h *FavoriteHandler) Star(c *gin.Context) {
+	link, err := h.linkRepo.GetByAlias(c.Request.Context(), c.Param("alias"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	userID := getUserIDFromContext(c)
+	if link.Visibility == models.VisibilityPrivate && link.CreatedBy != userID {
+		respondWithError(c, models.ErrNotFound)
+		return
+	}
+
+	if err := h.linkRepo.AddFavorite(c.Request.Context(), userID, link.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.suggest.MarkStale()
+	c.JSON(http.StatusOK, gin.H{"alias": link.Alias, "favorite": true})
+}
+
+// Unstar handles DELETE /api/favorites/:alias.
+func (h *FavoriteHandler) Unstar(c *gin.Context) {
+	link, err := h.linkRepo.GetByAlias(c.Request.Context(), c.Param("alias"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.linkRepo.RemoveFavorite(c.Request.Context(), getUserIDFromContext(c), link.ID); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	h.suggest.MarkStale()
+	c.JSON(http.StatusOK, gin.H{"alias": link.Alias, "favorite": false})
+}
+
+// Reorder handles PUT /api/favorites: the given aliases move to the front in
+// that order. Other users' private links are reported as unknown, like
+// aliases that do not exist.
+func (h *FavoriteHandler) Reorder(c *gin.Context) {
+	var req reorderFavoritesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	links, err := h.linkRepo.GetByAliases(ctx, req.Aliases)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byAlias := linksByAlias(links)
+
+	userID := getUserIDFromContext(c)
+	ids := make([]int64, 0, len(req.Aliases))
+	for _, alias := range req.Aliases {
+		link := byAlias[alias]
+		if link == nil || (link.Visibility == models.VisibilityPrivate && link.CreatedBy != userID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown alias " + alias})
+			return
+		}
+		ids = append(ids, link.ID)
+	}
+
+	if err := h.linkRepo.ReorderFavorites(ctx, userID, ids); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	favorites, err := h.linkRepo.ListFavorites(ctx, userID, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if favorites == nil {
+		favorites = []*models.Link{}
+	}
+	c.JSON(http.StatusOK, gin.H{"items": favorites})
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/linkio"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxImportBytes = 10 << 20
+	maxImportRows  = 5000
+
+	// exportFlushEvery is how many rows an export writes between flushes.
+	exportFlushEvery = 100
+)
+
+// ImportExportHandler moves links in and out of the go server in bulk.
+type ImportExportHandler struct {
+	linkRepo *models.LinkRepository
+	userRepo *models.UserRepository
+	importer *services.LinkImporter
+	baseURL  string
+}
+
+func NewImportExportHandler(linkRepo *models.LinkRepository, userRepo *models.UserRepository, importer *services.LinkImporter, baseURL string) *ImportExportHandler {
+	return &ImportExportHandler{
+		linkRepo: linkRepo,
+		userRepo: userRepo,
+		importer: importer,
+		baseURL:  baseURL,
+	}
+}
+
+// Import handles POST /api/links/import?format=&strategy=&dry_run=. The file
+// is the request body or the "file" field of a multipart form; its format
+// defaults to what the content type or file name says.
+//
+// Admins can pass map_owners=true to hand each link to the user the file
+// names as its owner, with fallback_owner=<email> for unknown owners. A
+// fallback owner without map_owners=true is rejected rather than ignored.
+// Admins can also pass override=true to skip the alias policy, which is
+// ignored for other users as when creating links one by one.
+func (h *ImportExportHandler) Import(c *gin.Context) {
+	opts := services.ImportOptions{
+		OwnerID:   getUserIDFromContext(c),
+		Strategy:  c.DefaultQuery("strategy", services.ImportSkip),
+		DryRun:    c.Query("dry_run") == "true",
+		MapOwners: c.Query("map_owners") == "true",
+		Override:  c.Query("override") == "true" && isAdminFromContext(c),
+	}
+	fallback := c.Query("fallback_owner")
+	if fallback != "" && !opts.MapOwners {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fallback_owner requires map_owners=true"})
+		return
+	}
+	if (opts.MapOwners || fallback != "") && !isAdminFromContext(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can import links for other users"})
+		return
+	}
+	if fallback != "" {
+		user, err := h.userRepo.GetByEmail(c.Request.Context(), fallback)
+		if errors.Is(err, models.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "fallback owner not found"})
+			return
+		}
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+		opts.FallbackOwnerID = user.ID
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	body, format, err := importFile(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	importer, err := linkio.LookupImporter(format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "formats": linkio.ImportFormats()})
+		return
+	}
+
+	records, err := importer.Parse(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(records) > maxImportRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("an import may contain at most %d links", maxImportRows)})
+		return
+	}
+
+	result, err := h.importer.Import(c.Request.Context(), records, opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// importFile returns the uploaded file and its format.
+func importFile(c *gin.Context) (io.ReadCloser, string, error) {
+	format := strings.ToLower(c.Query("format"))
+
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		if format == "" {
+			format = formatFromMediaType(mediaType)
+		}
+		if format == "" {
+			return nil, "", errors.New("format is required")
+		}
+		return c.Request.Body, format, nil
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		return nil, "", errors.New("multipart uploads need a file field")
+	}
+	if format == "" {
+		format = formatFromExtension(filepath.Ext(header.Filename))
+	}
+	if format == "" {
+		format = formatFromMediaType(header.Header.Get("Content-Type"))
+	}
+	if format == "" {
+		file.Close()
+		return nil, "", errors.New("format is required")
+	}
+	return file, format, nil
+}
+
+func formatFromMediaType(mediaType string) string {
+	switch {
+	case strings.Contains(mediaType, "csv"):
+		return "csv"
+	case strings.Contains(mediaType, "json"):
+		return "json"
+	case strings.Contains(mediaType, "html"):
+		return "bookmarks"
+	case strings.Contains(mediaType, "sql"):
+		return "yourls-sql"
+	}
+	return ""
+}
+
+func formatFromExtension(ext string) string {
+	switch ext = strings.TrimPrefix(strings.ToLower(ext), "."); ext {
+	case "htm", "html":
+		return "bookmarks"
+	case "sql":
+		return "yourls-sql"
+	}
+	return ext
+}
+
+// Export handles GET /api/links/export?format=csv|json|bookmarks|markdown.
+// It streams the caller's links matching the usual list filters, such as
+// tag and namespace, or every user's links for admins passing all=true.
+func (h *ImportExportHandler) Export(c *gin.Context) {
+	opts, err := listOptionsFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if opts.SortBy == "" {
+		opts.SortBy = "alias_asc"
+	}
+	if !(c.Query("all") == "true" && isAdminFromContext(c)) {
+		opts.OwnerID = getUserIDFromContext(c)
+	}
+
+	writer, contentType, extension, err := linkio.NewWriter(c.DefaultQuery("format", "csv"), c.Writer,
+		linkio.ExportOptions{BaseURL: h.baseURL})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("links-%s.%s", time.Now().UTC().Format("2006-01-02"), extension)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	rows := 0
+	err = h.linkRepo.EachLink(c.Request.Context(), opts, func(link *models.Link) error {
+		if err := writer.Write(link); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		// The response has started; all we can do is cut it short.
+		log.Printf("Error exporting links: %v", err)
+		c.Abort()
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+const openSearchShortName = "go"
+
+// openSearchTemplate is XML, so it uses text/template and escapes values
+// explicitly; html/template would mangle the XML declaration.
+var openSearchTemplate = texttemplate.Must(texttemplate.New("opensearch").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<OpenSearchDescription xmlns="http://a9.com/-/spec/opensearch/1.1/" xmlns:moz="http://www.mozilla.org/2006/browser/search/">
+  <ShortName>{{html .ShortName}}</ShortName>
+  <Description>Jump to go links, or search them</Description>
+  <InputEncoding>UTF-8</InputEncoding>
+  <Url type="text/html" method="get" template="{{html .BaseURL}}/search?q={searchTerms}"/>
+  <Url type="application/x-suggestions+json" method="get" template="{{html .BaseURL}}/search/suggest?q={searchTerms}"/>
+  <moz:SearchForm>{{html .BaseURL}}/search</moz:SearchForm>
+</OpenSearchDescription>
+`))
+
+var searchPageTemplate = template.Must(template.New("search").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>{{if .Query}}{{.Query}} - {{end}}go links</title>
+  <link rel="search" type="application/opensearchdescription+xml" title="go" href="{{.BaseURL}}/opensearch.xml">
+</head>
+<body>
+  <form action="{{.BaseURL}}/search" method="get">
+    <input type="search" name="q" value="{{.Query}}" autofocus>
+    <button type="submit">Search</button>
+  </form>
+  {{if .Query}}
+  {{if .Missed}}<p>No go link named <strong>{{.Missed}}</strong>.</p>{{end}}
+  {{if .Hits}}
+  <ol>
+    {{range .Hits}}
+    <li>
+      <a href="{{$.BaseURL}}/go/{{.Alias}}">go/{{.Alias}}</a>
+      <div>{{.DestinationURL}}</div>
+      {{if .Description}}<div>{{.Description}}</div>{{end}}
+    </li>
+    {{end}}
+  </ol>
+  {{else}}
+  <p>No links match your search.</p>
+  {{end}}
+  {{end}}
+</body>
+</html>
+`))
+
+// KeywordSearchHandler lets browsers use the go server as a search engine:
+// typing "go foo bar" opens the foo link with "bar" as its argument, and
+// anything that is not an alias lands on a search results page.
+type KeywordSearchHandler struct {
+	linkRepo *models.LinkRepository
+	suggest  *services.SuggestIndex
+	baseURL  string
+}
+
+func NewKeywordSearchHandler(linkRepo *models.LinkRepository, suggest *services.SuggestIndex, baseURL string) *KeywordSearchHandler {
+	return &KeywordSearchHandler{
+		linkRepo: linkRepo,
+		suggest:  suggest,
+		baseURL:  baseURL,
+	}
+}
+
+// OpenSearchDescription serves the OpenSearch descriptor browsers use to add
+// the go server as a search engine.
+func (h *KeywordSearchHandler) OpenSearchDescription(c *gin.Context) {
+	c.Header("Content-Type", "application/opensearchdescription+xml; charset=utf-8")
+	err := openSearchTemplate.Execute(c.Writer, gin.H{
+		"ShortName": openSearchShortName,
+		"BaseURL":   h.baseURL,
+	})
+	if err != nil {
+		log.Printf("Error rendering OpenSearch description: %v", err)
+	}
+}
+
+// Search handles GET /search?q=. The first word is looked up as an alias and
+// the rest become its arguments; if it is not a usable link the whole query
+// is searched instead.
+func (h *KeywordSearchHandler) Search(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	fields := strings.Fields(q)
+	if len(fields) == 0 {
+		h.renderResults(c, "", "", nil)
+		return
+	}
+
+	alias := strings.TrimPrefix(fields[0], "go/")
+	link, err := h.linkRepo.GetByAlias(c.Request.Context(), alias)
+	if err == nil {
+		link, err = h.linkRepo.Forward(c.Request.Context(), link)
+	}
+	if err == nil && link.IsActive && !link.IsExpired() {
+		if err := h.linkRepo.IncrementStats(c.Request.Context(), link.ID); err != nil {
+			log.Printf("Error incrementing stats for link %d: %v", link.ID, err)
+		}
+		if userID := getUserIDFromContext(c); userID != 0 {
+			h.suggest.RecordUse(userID, link.Alias)
+		}
+		c.Redirect(http.StatusFound, services.ExpandDestination(link.DestinationURL, fields[1:]))
+		return
+	}
+
+	var hits []*models.SearchHit
+	if len(q) >= 2 {
+		result, err := h.linkRepo.Search(c.Request.Context(), models.SearchOptions{
+			Query:  q,
+			UserID: getUserIDFromContext(c),
+			Limit:  20,
+		})
+		if err != nil {
+			log.Printf("Error searching links: %v", err)
+			c.String(http.StatusInternalServerError, "search failed")
+			return
+		}
+		hits = result.Hits
+	}
+
+	missed := ""
+	if len(fields) == 1 {
+		missed = alias
+	}
+	h.renderResults(c, q, missed, hits)
+}
+
+// Suggestions serves alias completions in the OpenSearch suggestions format
+// browsers show while typing in the address bar.
+func (h *KeywordSearchHandler) Suggestions(c *gin.Context) {
+	q := c.Query("q")
+	completions := []string{}
+	descriptions := []string{}
+	for _, s := range h.suggest.Suggest(getUserIDFromContext(c), q, 10) {
+		completions = append(completions, s.Alias)
+		descriptions = append(descriptions, s.DestinationURL)
+	}
+	c.JSON(http.StatusOK, []interface{}{q, completions, descriptions})
+}
+
+func (h *KeywordSearchHandler) renderResults(c *gin.Context, q, missed string, hits []*models.SearchHit) {
+	status := http.StatusOK
+	if missed != "" {
+		status = http.StatusNotFound
+	}
+
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	err := searchPageTemplate.Execute(c.Writer, gin.H{
+		"BaseURL": h.baseURL,
+		"Query":   q,
+		"Missed":  missed,
+		"Hits":    hits,
+	})
+	if err != nil {
+		log.Printf("Error rendering search page: %v", err)
+	}
+}
@@ -2,36 +2,65 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/devingoodsell/go-links-free/internal/auth"
 	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
 type LinkHandler struct {
-	linkRepo *models.LinkRepository
+	linkRepo    *models.LinkRepository
+	aliasPolicy *services.AliasPolicy
+	urlPolicy   *services.URLPolicy
+	suggest     *services.SuggestIndex
+	cursors     *CursorCodec
+	aliasGen    *services.AliasGenerator
 }
 
-func NewLinkHandler(linkRepo *models.LinkRepository) *LinkHandler {
+func NewLinkHandler(
+	linkRepo *models.LinkRepository,
+	aliasPolicy *services.AliasPolicy,
+	urlPolicy *services.URLPolicy,
+	suggest *services.SuggestIndex,
+	cursors *CursorCodec,
+	aliasGen *services.AliasGenerator,
+) *LinkHandler {
 	return &LinkHandler{
-		linkRepo: linkRepo,
+		linkRepo:    linkRepo,
+		aliasPolicy: aliasPolicy,
+		urlPolicy:   urlPolicy,
+		suggest:     suggest,
+		cursors:     cursors,
+		aliasGen:    aliasGen,
 	}
 }
 
 type createLinkRequest struct {
-	Alias          string     `json:"alias" binding:"required"`
+	Alias          string     `json:"alias,omitempty"` // generated when omitted
 	DestinationURL string     `json:"destinationUrl" binding:"required,url"`
+	Description    string     `json:"description,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
 	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	Visibility     string     `json:"visibility,omitempty"` // defaults to public
+	Override       bool       `json:"override,omitempty"`   // admins only: skip the alias policy
 }
 
 type updateLinkRequest struct {
+	Alias          string     `json:"alias,omitempty"` // renames the link when set
 	DestinationURL string     `json:"destinationUrl" binding:"required,url"`
+	Description    *string    `json:"description,omitempty"` // left unchanged when omitted
+	Tags           []string   `json:"tags"`                  // left unchanged when omitted
 	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	Visibility     string     `json:"visibility,omitempty"` // left unchanged when omitted
+	Override       bool       `json:"override,omitempty"`
 }
 
 type linkResponse struct {
@@ -62,9 +91,6 @@ type bulkStatusUpdateRequest struct {
 }
 
 func validateCreateLinkRequest(req *createLinkRequest) error {
-	if req.Alias == "" {
-		return errors.New("alias is required")
-	}
 	if len(req.Alias) > 100 {
 		return errors.New("alias must be 100 characters or less")
 	}
@@ -77,9 +103,22 @@ func validateCreateLinkRequest(req *createLinkRequest) error {
 	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
 		return errors.New("expiration time must be in the future")
 	}
+	if len(req.Description) > maxDescriptionLength {
+		return errors.New("description must be 1000 characters or less")
+	}
+	if req.Visibility != "" && !models.ValidVisibility(req.Visibility) {
+		return errors.New("visibility must be public or private")
+	}
+	tags, err := models.NormalizeTags(req.Tags)
+	if err != nil {
+		return err
+	}
+	req.Tags = tags
 	return nil
 }
 
+const maxDescriptionLength = 1000
+
 func (h *LinkHandler) Create(c *gin.Context) {
 	var req createLinkRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -87,26 +126,75 @@ func (h *LinkHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if err := validateCreateLinkRequest(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Get user from context
 	userClaims, _ := c.Get("user")
 	claims := userClaims.(*auth.Claims)
 
+	if req.Alias != "" && !(req.Override && claims.IsAdmin) {
+		if err := h.aliasPolicy.Check(req.Alias); err != nil {
+			respondWithError(c, err)
+			return
+		}
+	}
+
+	destination, err := h.urlPolicy.Apply(c.Request.Context(), req.Alias, req.DestinationURL)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
 	link := &models.Link{
 		Alias:          req.Alias,
-		DestinationURL: req.DestinationURL,
+		DestinationURL: destination,
+		Description:    req.Description,
+		Tags:           req.Tags,
 		CreatedBy:      claims.UserID,
 		ExpiresAt:      req.ExpiresAt,
 		IsActive:       true,
+		Visibility:     req.Visibility,
 	}
 
-	if err := h.linkRepo.Create(c.Request.Context(), link); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if req.Alias == "" {
+		err = h.createWithGeneratedAlias(c, link)
+	} else {
+		err = h.linkRepo.Create(c.Request.Context(), link)
+	}
+	if err != nil {
+		respondWithError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, link)
 }
 
+// maxAliasCollisions is how many generated aliases may turn out to be taken
+// before creating a link without an alias fails.
+const maxAliasCollisions = 5
+
+// createWithGeneratedAlias creates link under a random alias, drawing a new
+// one whenever it collides with an existing link.
+func (h *LinkHandler) createWithGeneratedAlias(c *gin.Context, link *models.Link) error {
+	var err error
+	for attempt := 0; attempt < maxAliasCollisions; attempt++ {
+		link.Alias, err = h.aliasGen.Generate()
+		if err != nil {
+			return err
+		}
+
+		err = h.linkRepo.Create(c.Request.Context(), link)
+		if !errors.Is(err, models.ErrDuplicate) {
+			return err
+		}
+		log.Printf("Generated alias %q is taken, retrying", link.Alias)
+	}
+	return fmt.Errorf("could not generate a free alias: %w", err)
+}
+
 func (h *LinkHandler) Redirect(c *gin.Context) {
 	alias := c.Param("alias")
 	link, err := h.linkRepo.GetByAlias(c.Request.Context(), alias)
@@ -115,7 +203,19 @@ func (h *LinkHandler) Redirect(c *gin.Context) {
 		return
 	}
 
-	if link.ExpiresAt != nil && link.ExpiresAt.Before(time.Now()) {
+	// Merged links forward to the link they were merged into
+	link, err = h.linkRepo.Forward(c.Request.Context(), link)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !link.IsActive {
+		c.JSON(404, gin.H{"error": "link is inactive"})
+		return
+	}
+
+	if link.IsExpired() {
 		c.JSON(410, gin.H{"error": "link has expired"})
 		return
 	}
@@ -125,9 +225,28 @@ func (h *LinkHandler) Redirect(c *gin.Context) {
 		// TODO: Add proper logging
 	}
 
-	c.Redirect(302, link.DestinationURL)
+	// Redirects are public; remember the alias for signed-in callers so it
+	// ranks higher in their suggestions.
+	if userID := getUserIDFromContext(c); userID != 0 {
+		h.suggest.RecordUse(userID, link.Alias)
+	}
+
+	c.Redirect(302, services.ExpandDestination(link.DestinationURL, nil))
+}
+
+// Suggest autocompletes aliases for a typed prefix from the in-memory index.
+func (h *LinkHandler) Suggest(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	suggestions := h.suggest.Suggest(getUserIDFromContext(c), c.Query("prefix"), limit)
+	c.JSON(http.StatusOK, gin.H{"items": suggestions})
 }
 
+// List handles GET /api/links, the caller's own links. The total is
+// estimated unless total=exact is asked for, or total=none skips it.
 func (h *LinkHandler) List(c *gin.Context) {
 	// Get user from context
 	userClaims, exists := c.Get("user")
@@ -140,35 +259,114 @@ func (h *LinkHandler) List(c *gin.Context) {
 	// Parse pagination params
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 10
+	}
 
-	// Get links for user
-	links, total, err := h.linkRepo.ListForUser(c.Request.Context(), claims.UserID, page, pageSize)
+	opts, err := listOptionsFromQuery(c)
 	if err != nil {
-		log.Printf("Error listing links: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts.OwnerID = claims.UserID
+	if c.Query("cursor") == "" {
+		opts.Offset = page * pageSize
+	}
+
+	// Get links for user
+	links, response := listLinksPage(c, h.linkRepo, h.cursors, cursorKindLinks, opts, pageSize, totalApprox)
+	if links == nil {
+		return
+	}
+	if err := h.linkRepo.LoadHealth(c.Request.Context(), links); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Convert to response format
-	response := make([]map[string]interface{}, len(links))
+	items := make([]map[string]interface{}, len(links))
 	for i, link := range links {
 		log.Printf("Link %d: createdAt=%v, isActive=%v", i, link.CreatedAt, link.IsActive) // More specific debug
-		response[i] = map[string]interface{}{
+		items[i] = map[string]interface{}{
 			"id":             link.ID,
 			"alias":          link.Alias,
 			"destinationUrl": link.DestinationURL,
+			"description":    link.Description,
+			"tags":           link.Tags,
 			"createdAt":      link.CreatedAt.Format(time.RFC3339), // Format the time explicitly
 			"updatedAt":      link.UpdatedAt.Format(time.RFC3339),
 			"expiresAt":      link.ExpiresAt,
 			"isActive":       link.IsActive,
+			"visibility":     link.Visibility,
 			"stats":          link.Stats,
+			"health":         link.Health,
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"items":      response,
-		"totalCount": total,
-	})
+	response["items"] = items
+	c.JSON(http.StatusOK, response)
+}
+
+// listOptionsFromQuery reads the filter and sort parameters shared by the
+// link listings. Dates are RFC 3339 timestamps or YYYY-MM-DD.
+func listOptionsFromQuery(c *gin.Context) (models.ListOptions, error) {
+	tags := c.QueryArray("tag")
+	if raw := c.Query("tags"); raw != "" {
+		tags = append(tags, strings.Split(raw, ",")...)
+	}
+
+	opts := models.ListOptions{
+		Search:     c.Query("search"),
+		Status:     c.Query("status"),
+		SortBy:     c.Query("sort"),
+		Domain:     c.Query("domain"),
+		Namespace:  c.Query("namespace"),
+		Tags:       tags,
+		TagMatch:   c.DefaultQuery("tag_match", models.TagMatchAny),
+		ZeroClicks: c.Query("zero_clicks") == "true",
+	}
+
+	for param, dest := range map[string]**time.Time{
+		"created_after":   &opts.CreatedAfter,
+		"created_before":  &opts.CreatedBefore,
+		"accessed_after":  &opts.AccessedAfter,
+		"accessed_before": &opts.AccessedBefore,
+	} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		t, err := parseQueryTime(raw)
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s: %s", param, raw)
+		}
+		*dest = &t
+	}
+
+	return opts, nil
+}
+
+func parseQueryTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
+
+// ListTags returns tags with usage counts for autocomplete.
+func (h *LinkHandler) ListTags(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	tags, err := h.linkRepo.ListTags(c.Request.Context(), getUserIDFromContext(c), c.Query("prefix"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": tags})
 }
 
 func getIntQueryParam(r *http.Request, key string, defaultValue int) int {
@@ -216,10 +414,57 @@ func (h *LinkHandler) Update(c *gin.Context) {
 		return
 	}
 
-	link.DestinationURL = req.DestinationURL
+	if req.Alias != "" && req.Alias != link.Alias {
+		if len(req.Alias) > 100 {
+			c.JSON(400, gin.H{"error": "alias must be 100 characters or less"})
+			return
+		}
+		if !(req.Override && isAdminFromContext(c)) {
+			if err := h.aliasPolicy.Check(req.Alias); err != nil {
+				respondWithError(c, err)
+				return
+			}
+		}
+		link.Alias = req.Alias
+	}
+
+	destination, err := h.urlPolicy.Apply(c.Request.Context(), link.Alias, req.DestinationURL)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if req.Description != nil {
+		if len(*req.Description) > maxDescriptionLength {
+			c.JSON(400, gin.H{"error": "description must be 1000 characters or less"})
+			return
+		}
+		link.Description = *req.Description
+	}
+	if req.Visibility != "" {
+		if !models.ValidVisibility(req.Visibility) {
+			c.JSON(400, gin.H{"error": "visibility must be public or private"})
+			return
+		}
+		link.Visibility = req.Visibility
+	}
+	if req.Tags != nil {
+		tags, err := models.NormalizeTags(req.Tags)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		link.Tags = tags
+	}
+
+	link.DestinationURL = destination
 	link.ExpiresAt = req.ExpiresAt
 
 	if err := h.linkRepo.Update(c.Request.Context(), link); err != nil {
+		if errors.Is(err, models.ErrDuplicate) {
+			respondWithError(c, err)
+			return
+		}
 		c.JSON(500, gin.H{"error": "failed to update link"})
 		return
 	}
@@ -247,55 +492,6 @@ func (h *LinkHandler) Delete(c *gin.Context) {
 	c.Status(204)
 }
 
-func (h *LinkHandler) BulkDelete(c *gin.Context) {
-	var req struct {
-		IDs []int64 `json:"ids"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "invalid request"})
-		return
-	}
-
-	if len(req.IDs) == 0 {
-		c.JSON(400, gin.H{"error": "no links specified"})
-		return
-	}
-
-	userID := getUserIDFromContext(c)
-	if err := h.linkRepo.BulkDelete(c.Request.Context(), userID, req.IDs); err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.Status(204)
-}
-
-func (h *LinkHandler) BulkUpdateStatus(c *gin.Context) {
-	var req struct {
-		IDs      []int64 `json:"ids"`
-		IsActive bool    `json:"is_active"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "invalid request"})
-		return
-	}
-
-	if len(req.IDs) == 0 {
-		c.JSON(400, gin.H{"error": "no links specified"})
-		return
-	}
-
-	userID := getUserIDFromContext(c)
-	if err := h.linkRepo.BulkUpdateStatus(c.Request.Context(), userID, req.IDs, req.IsActive); err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.Status(204)
-}
-
 func getUserIDFromContext(c *gin.Context) int64 {
 	if claims, exists := c.Get("user"); exists {
 		if userClaims, ok := claims.(*auth.Claims); ok {
@@ -305,6 +501,15 @@ func getUserIDFromContext(c *gin.Context) int64 {
 	return 0
 }
 
+func isAdminFromContext(c *gin.Context) bool {
+	if claims, exists := c.Get("user"); exists {
+		if userClaims, ok := claims.(*auth.Claims); ok {
+			return userClaims.IsAdmin
+		}
+	}
+	return false
+}
+
 func (h *LinkHandler) getLinkFromRequest(c *gin.Context) (*models.Link, error) {
 	alias := c.Param("alias")
 	link, err := h.linkRepo.GetByAlias(c.Request.Context(), alias)
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+var linkActionPageTemplate = template.Must(template.New("link-action").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <meta name="robots" content="noindex">
+  <title>go/{{.Alias}}</title>
+</head>
+<body>
+  {{if .Message}}
+  <p>{{.Message}}</p>
+  {{else}}
+  <h1>{{.Title}} go/{{.Alias}}?</h1>
+  <p>go/{{.Alias}} leads to {{.DestinationURL}}</p>
+  <form method="post">
+    <button type="submit">{{.Title}}</button>
+  </form>
+  {{end}}
+  <p><a href="{{.BaseURL}}/">Manage your links</a></p>
+</body>
+</html>
+`))
+
+var linkActionTitles = map[string]string{
+	services.LinkActionExtend:  "Keep",
+	services.LinkActionArchive: "Archive",
+	services.LinkActionDelete:  "Delete",
+}
+
+// LinkActionHandler serves the action URLs of stale link digests, which let
+// owners keep, archive or delete a link without signing in. Opening the URL
+// only asks for confirmation, as mail scanners open links too; the action
+// is taken when the owner submits the form.
+type LinkActionHandler struct {
+	linkRepo *models.LinkRepository
+	tokens   *services.LinkActionTokens
+	keepFor  time.Duration
+	baseURL  string
+}
+
+func NewLinkActionHandler(linkRepo *models.LinkRepository, tokens *services.LinkActionTokens, keepFor time.Duration, baseURL string) *LinkActionHandler {
+	return &LinkActionHandler{
+		linkRepo: linkRepo,
+		tokens:   tokens,
+		keepFor:  keepFor,
+		baseURL:  baseURL,
+	}
+}
+
+// Confirm handles GET /link-actions/:token.
+func (h *LinkActionHandler) Confirm(c *gin.Context) {
+	claims, link, ok := h.load(c)
+	if !ok {
+		return
+	}
+	h.render(c, http.StatusOK, gin.H{
+		"Title":          linkActionTitles[claims.Action],
+		"Alias":          link.Alias,
+		"DestinationURL": link.DestinationURL,
+	})
+}
+
+// Apply handles POST /link-actions/:token.
+func (h *LinkActionHandler) Apply(c *gin.Context) {
+	claims, link, ok := h.load(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	var message string
+	var err error
+	switch claims.Action {
+	case services.LinkActionExtend:
+		var expiresAt *time.Time
+		expiresAt, err = h.linkRepo.KeepLink(ctx, link.ID, h.keepFor)
+		message = "go/" + link.Alias + " is kept and will not be brought up again for a while."
+		if expiresAt != nil {
+			message = "go/" + link.Alias + " now expires on " + expiresAt.Format("January 2, 2006") + "."
+		}
+	case services.LinkActionArchive:
+		err = h.linkRepo.BulkUpdateStatus(ctx, claims.OwnerID, []int64{link.ID}, false)
+		message = "go/" + link.Alias + " is archived. You can activate it again from your links."
+	case services.LinkActionDelete:
+		err = h.linkRepo.Delete(ctx, link.ID, claims.OwnerID)
+		message = "go/" + link.Alias + " is deleted."
+	}
+	if errors.Is(err, models.ErrNotFound) || errors.Is(err, models.ErrUnauthorized) {
+		h.message(c, http.StatusConflict, "This link changed since the reminder was sent. Sign in to manage it.")
+		return
+	}
+	if err != nil {
+		log.Printf("Error applying %s to link %d: %v", claims.Action, link.ID, err)
+		h.message(c, http.StatusInternalServerError, "Something went wrong. Please try again later.")
+		return
+	}
+
+	h.message(c, http.StatusOK, message)
+}
+
+// load verifies the token of the request and finds the link it is for,
+// answering the request itself when either is no good.
+func (h *LinkActionHandler) load(c *gin.Context) (*services.LinkActionClaims, *models.Link, bool) {
+	claims, err := h.tokens.Verify(c.Param("token"))
+	switch {
+	case errors.Is(err, services.ErrActionTokenExpired):
+		h.message(c, http.StatusGone, "This link has expired. Sign in to manage your links.")
+		return nil, nil, false
+	case err != nil:
+		h.message(c, http.StatusBadRequest, "This link is not valid.")
+		return nil, nil, false
+	}
+
+	link, err := h.linkRepo.GetByID(c.Request.Context(), claims.LinkID)
+	if errors.Is(err, models.ErrNotFound) {
+		h.message(c, http.StatusNotFound, "This link no longer exists.")
+		return nil, nil, false
+	}
+	if err != nil {
+		log.Printf("Error loading link %d: %v", claims.LinkID, err)
+		h.message(c, http.StatusInternalServerError, "Something went wrong. Please try again later.")
+		return nil, nil, false
+	}
+	if claims.Check(link) != nil {
+		h.message(c, http.StatusConflict, "This link changed since the reminder was sent. Sign in to manage it.")
+		return nil, nil, false
+	}
+	return claims, link, true
+}
+
+func (h *LinkActionHandler) message(c *gin.Context, status int, message string) {
+	h.render(c, status, gin.H{"Message": message})
+}
+
+func (h *LinkActionHandler) render(c *gin.Context, status int, data gin.H) {
+	data["BaseURL"] = h.baseURL
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	if err := linkActionPageTemplate.Execute(c.Writer, data); err != nil {
+		log.Printf("Error rendering link action page: %v", err)
+	}
+}
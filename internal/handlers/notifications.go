@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationRepo *models.NotificationRepository
+}
+
+func NewNotificationHandler(notificationRepo *models.NotificationRepository) *NotificationHandler {
+	return &NotificationHandler{
+		notificationRepo: notificationRepo,
+	}
+}
+
+// ListMine returns the latest notifications sent, or waiting to be sent, to
+// the current user.
+func (h *NotificationHandler) ListMine(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	notifications, err := h.notificationRepo.ListForUser(c.Request.Context(), getUserIDFromContext(c), limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"items": notifications})
+}
+
+// GetPreferences returns, for every kind of notification, whether the
+// current user receives it.
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	prefs, err := h.notificationRepo.GetPreferences(c.Request.Context(), getUserIDFromContext(c))
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, prefs)
+}
+
+// UpdatePreferences turns kinds of notifications on or off for the current
+// user. Kinds left out of the request keep their setting.
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	var req map[string]bool
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	for kind := range req {
+		if !models.ValidNotificationKind(kind) {
+			c.JSON(400, gin.H{"error": "unknown notification kind " + kind})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	userID := getUserIDFromContext(c)
+	if err := h.notificationRepo.SetPreferences(ctx, userID, req); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefs, err := h.notificationRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, prefs)
+}
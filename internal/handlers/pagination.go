@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Values of the total query parameter of link listings.
+const (
+	totalExact  = "exact"
+	totalApprox = "approx"
+	totalNone   = "none"
+)
+
+// neighbourPages reports whether a page has pages after and before it, given
+// whether more items were found in the direction it was fetched, which way
+// that was and whether it was fetched from a cursor or offset at all.
+func neighbourPages(more, backward, paged bool) (hasNext, hasPrev bool) {
+	if backward {
+		return true, more
+	}
+	return more, paged
+}
+
+// listLinksPage runs a link listing for the cursor in the request, if any,
+// and returns the page with next_cursor, prev_cursor and the total requested
+// by the total parameter, defaultTotal when absent. Counting every matching
+// link is slow on large listings, so callers should default to an estimate
+// or none and leave exact totals to total=exact. It writes the error
+// response itself and returns nil when the listing fails.
+func listLinksPage(
+	c *gin.Context,
+	linkRepo *models.LinkRepository,
+	cursors *CursorCodec,
+	kind string,
+	opts models.ListOptions,
+	limit int,
+	defaultTotal string,
+) ([]*models.Link, gin.H) {
+	backward := false
+	if token := c.Query("cursor"); token != "" {
+		pos := &models.ListCursor{}
+		var err error
+		backward, err = cursors.Decode(token, kind, opts.SortBy, pos)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, nil
+		}
+		if backward {
+			opts.Before = pos
+		} else {
+			opts.After = pos
+		}
+	}
+
+	// Fetch one extra link to know whether there is another page
+	opts.Limit = limit + 1
+	links, err := linkRepo.List(c.Request.Context(), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil
+	}
+
+	more := len(links) > limit
+	if more && backward {
+		links = links[1:]
+	} else if more {
+		links = links[:limit]
+	}
+	if links == nil {
+		links = []*models.Link{}
+	}
+
+	response := gin.H{}
+	paged := opts.After != nil || opts.Before != nil || opts.Offset > 0
+	hasNext, hasPrev := neighbourPages(more, backward, paged)
+	if len(links) > 0 {
+		if hasNext {
+			response["next_cursor"] = cursors.Encode(kind, opts.SortBy, false, opts.CursorFor(links[len(links)-1]))
+		}
+		if hasPrev {
+			response["prev_cursor"] = cursors.Encode(kind, opts.SortBy, true, opts.CursorFor(links[0]))
+		}
+	}
+
+	mode := c.DefaultQuery("total", defaultTotal)
+	switch {
+	case mode == totalNone:
+	case !paged && !more:
+		// The page holds the whole listing, so the exact total costs nothing
+		response["totalCount"] = len(links)
+	case mode == totalExact:
+		total, err := linkRepo.Count(c.Request.Context(), opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return nil, nil
+		}
+		response["totalCount"] = total
+	case mode == totalApprox:
+		total, err := linkRepo.EstimateCount(c.Request.Context(), opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return nil, nil
+		}
+		response["totalCount"] = total
+		response["totalIsApproximate"] = true
+	}
+
+	return links, response
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// maxBatchResolve is the most aliases one batch resolve request may contain.
+const maxBatchResolve = 50
+
+const (
+	resolveStatusActive   = "active"
+	resolveStatusExpired  = "expired"
+	resolveStatusInactive = "inactive"
+	resolveStatusNotFound = "not_found"
+)
+
+// resolveResult describes where an alias goes without following it.
+type resolveResult struct {
+	Query          string     `json:"query"`
+	Alias          string     `json:"alias"`
+	ForwardsTo     string     `json:"forwardsTo,omitempty"` // alias of the link this one was merged into
+	Status         string     `json:"status"`
+	DestinationURL string     `json:"destinationUrl,omitempty"`
+	ResolvedURL    string     `json:"resolvedUrl,omitempty"`
+	OwnerID        int64      `json:"ownerId,omitempty"`
+	OwnerEmail     string     `json:"ownerEmail,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+}
+
+type batchResolveRequest struct {
+	Aliases []string `json:"aliases" binding:"required"`
+	Count   bool     `json:"count"`
+}
+
+// ResolveHandler lets extensions and bots look up links without following
+// the redirect.
+type ResolveHandler struct {
+	linkRepo *models.LinkRepository
+	suggest  *services.SuggestIndex
+}
+
+func NewResolveHandler(linkRepo *models.LinkRepository, suggest *services.SuggestIndex) *ResolveHandler {
+	return &ResolveHandler{
+		linkRepo: linkRepo,
+		suggest:  suggest,
+	}
+}
+
+// Resolve handles GET /api/resolve/:alias?arg=&count=. Each arg fills the
+// next parameter of the destination; count=true records a click as if the
+// link had been followed.
+func (h *ResolveHandler) Resolve(c *gin.Context) {
+	alias := c.Param("alias")
+	query := strings.Join(append([]string{alias}, c.QueryArray("arg")...), " ")
+
+	links, err := h.linkRepo.GetByAliases(c.Request.Context(), []string{alias})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := h.resolve(c, query, linksByAlias(links), c.Query("count") == "true")
+	if result.Status == resolveStatusNotFound {
+		c.JSON(http.StatusNotFound, result)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// BatchResolve handles POST /api/resolve. Each entry is an alias optionally
+// followed by space-separated arguments, as typed after "go".
+func (h *ResolveHandler) BatchResolve(c *gin.Context) {
+	var req batchResolveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Aliases) > maxBatchResolve {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d aliases can be resolved at once", maxBatchResolve)})
+		return
+	}
+
+	aliases := make([]string, 0, len(req.Aliases))
+	for _, query := range req.Aliases {
+		if fields := strings.Fields(query); len(fields) > 0 {
+			aliases = append(aliases, fields[0])
+		}
+	}
+
+	links, err := h.linkRepo.GetByAliases(c.Request.Context(), aliases)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byAlias := linksByAlias(links)
+
+	results := make([]*resolveResult, len(req.Aliases))
+	for i, query := range req.Aliases {
+		results[i] = h.resolve(c, query, byAlias, req.Count)
+	}
+	c.JSON(http.StatusOK, gin.H{"items": results})
+}
+
+func (h *ResolveHandler) resolve(c *gin.Context, query string, links map[string]*models.Link, count bool) *resolveResult {
+	fields := strings.Fields(query)
+	result := &resolveResult{Query: query, Status: resolveStatusNotFound}
+	if len(fields) == 0 {
+		return result
+	}
+	result.Alias = fields[0]
+
+	link := links[fields[0]]
+	if link == nil {
+		return result
+	}
+	result.Alias = link.Alias
+
+	if target, err := h.linkRepo.Forward(c.Request.Context(), link); err != nil {
+		log.Printf("Error following merged link %d: %v", link.ID, err)
+	} else if target != link {
+		result.ForwardsTo, link = target.Alias, target
+	}
+
+	result.DestinationURL = link.DestinationURL
+	result.ResolvedURL = services.ExpandDestination(link.DestinationURL, fields[1:])
+	result.OwnerID = link.CreatedBy
+	result.OwnerEmail = link.OwnerEmail
+	result.ExpiresAt = link.ExpiresAt
+
+	switch {
+	case !link.IsActive:
+		result.Status = resolveStatusInactive
+	case link.IsExpired():
+		result.Status = resolveStatusExpired
+	default:
+		result.Status = resolveStatusActive
+	}
+
+	if count && result.Status == resolveStatusActive {
+		if err := h.linkRepo.IncrementStats(c.Request.Context(), link.ID); err != nil {
+			log.Printf("Error incrementing stats for link %d: %v", link.ID, err)
+		}
+		h.suggest.RecordUse(getUserIDFromContext(c), link.Alias)
+	}
+	return result
+}
+
+func linksByAlias(links []*models.Link) map[string]*models.Link {
+	byAlias := make(map[string]*models.Link, len(links))
+	for _, link := range links {
+		byAlias[link.Alias] = link
+	}
+	return byAlias
+}
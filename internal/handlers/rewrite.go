@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RewriteHandler serves find-and-replace migrations of link destinations
+// and the revisions they leave behind.
+type RewriteHandler struct {
+	linkRepo  *models.LinkRepository
+	rewriter  *services.DestinationRewriter
+	auditRepo *models.AuditLogRepository
+}
+
+func NewRewriteHandler(linkRepo *models.LinkRepository, rewriter *services.DestinationRewriter, auditRepo *models.AuditLogRepository) *RewriteHandler {
+	return &RewriteHandler{
+		linkRepo:  linkRepo,
+		rewriter:  rewriter,
+		auditRepo: auditRepo,
+	}
+}
+
+type rewriteRequest struct {
+	Mode    string `json:"mode"` // prefix or regex
+	Match   string `json:"match"`
+	Replace string `json:"replace"`
+	Reason  string `json:"reason"`
+	DryRun  bool   `json:"dryRun"`
+}
+
+// Rewrite handles POST /api/links/rewrite. A dry run previews every
+// affected link with its destination before and after; otherwise all of
+// them change together or none do. Admins rewrite every user's links,
+// everyone else only their own.
+func (h *RewriteHandler) Rewrite(c *gin.Context) {
+	var req rewriteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	actorID := getUserIDFromContext(c)
+	var scope models.ListOptions
+	if !isAdminFromContext(c) {
+		scope.OwnerID = actorID
+	} else if !req.DryRun && strings.TrimSpace(req.Reason) == "" {
+		// Admin rewrites reach other users' links, like admin edits
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+		return
+	}
+
+	spec := services.RewriteSpec{Mode: req.Mode, Match: req.Match, Replace: req.Replace}
+	items, err := h.rewriter.Plan(c.Request.Context(), spec, scope)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"dryRun":  req.DryRun,
+		"applied": false,
+		"count":   len(items),
+		"changes": items,
+	}
+	if req.DryRun {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	var audit []*models.AuditLog
+	for _, item := range items {
+		if item.OwnerID == actorID {
+			continue
+		}
+		details := gin.H{
+			"alias":   item.Alias,
+			"reason":  req.Reason,
+			"changes": gin.H{"destinationUrl": gin.H{"from": item.Before, "to": item.After}},
+		}
+		entry, err := models.NewAuditLog(models.AuditLinkAdminEdit, actorID, item.LinkID, item.OwnerID, details)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+		audit = append(audit, entry)
+	}
+
+	err = h.rewriter.Apply(c.Request.Context(), items, actorID, req.Reason, audit...)
+	switch {
+	case errors.Is(err, services.ErrRewriteInvalid):
+		response["error"] = err.Error()
+		c.JSON(http.StatusBadRequest, response)
+		return
+	case errors.Is(err, models.ErrStaleDestination):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		respondWithError(c, err)
+		return
+	}
+
+	h.auditRepo.Recorded(c.Request.Context(), audit...)
+
+	response["applied"] = true
+	c.JSON(http.StatusOK, response)
+}
+
+// ListRevisions handles GET /api/links/:alias/revisions for the link's owner
+// and admins.
+func (h *RewriteHandler) ListRevisions(c *gin.Context) {
+	link, err := h.linkRepo.GetByAlias(c.Request.Context(), c.Param("alias"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	if link.CreatedBy != getUserIDFromContext(c) && !isAdminFromContext(c) {
+		respondWithError(c, models.ErrUnauthorized)
+		return
+	}
+
+	revisions, err := h.linkRepo.ListRevisions(c.Request.Context(), link.ID)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
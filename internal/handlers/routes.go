@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"log"
+	"net/http"
 	"time"
 
 	"github.com/devingoodsell/go-links-free/internal/auth"
 	"github.com/devingoodsell/go-links-free/internal/config"
 	"github.com/devingoodsell/go-links-free/internal/middleware"
 	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/devingoodsell/go-links-free/internal/slack"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -20,6 +23,13 @@ func SetupRoutes(
 	linkRepo *models.LinkRepository,
 	analyticsRepo *models.AnalyticsRepository,
 	userRepo *models.UserRepository,
+	aliasPolicy *services.AliasPolicy,
+	urlPolicy *services.URLPolicy,
+	auditRepo *models.AuditLogRepository,
+	suggestIndex *services.SuggestIndex,
+	aliasGen *services.AliasGenerator,
+	notificationRepo *models.NotificationRepository,
+	webhookRepo *models.WebhookRepository,
 ) *gin.Engine {
 	log.Println("Setting up routes...")
 	gin.SetMode(gin.DebugMode)
@@ -65,13 +75,14 @@ func SetupRoutes(
 	})
 
 	// Auth routes
-	authHandler := NewAuthHandler(authService, userRepo)
+	authHandler := NewAuthHandler(authService, userRepo, linkRepo)
 	// Protected routes with auth middleware
 	protected := router.Group("/api")
 	protected.Use(authMiddleware.AuthenticateGin)
 
 	router.POST("/api/auth/register", authHandler.Register)
 	router.POST("/api/auth/login", authHandler.Login)
+	router.POST("/api/auth/logout", authHandler.Logout)
 	protected.GET("/auth/me", authHandler.GetCurrentUser)
 
 	// OKTA routes (only if OKTA is enabled)
@@ -80,28 +91,108 @@ func SetupRoutes(
 		router.GET("/api/auth/okta/callback", authHandler.OktaCallback)
 	}
 
+	// Page tokens for every listing are signed so clients cannot forge them
+	cursors := NewCursorCodec(cfg.CursorSecret)
+
 	// Link routes
-	linkHandler := NewLinkHandler(linkRepo)
+	linkHandler := NewLinkHandler(linkRepo, aliasPolicy, urlPolicy, suggestIndex, cursors, aliasGen)
 
 	// Public redirect endpoint
-	router.GET("/go/:alias", linkHandler.Redirect)
+	router.GET("/go/:alias", authMiddleware.OptionalAuthGin, linkHandler.Redirect)
+
+	// Browser keyword search: "go foo bar" opens foo with "bar" as argument
+	keywordSearchHandler := NewKeywordSearchHandler(linkRepo, suggestIndex, cfg.PublicURL)
+	router.GET("/opensearch.xml", keywordSearchHandler.OpenSearchDescription)
+	router.GET("/search", authMiddleware.OptionalAuthGin, keywordSearchHandler.Search)
+	router.GET("/search/suggest", authMiddleware.OptionalAuthGin, keywordSearchHandler.Suggestions)
 
 	// Link management endpoints
 	protected.GET("/links", linkHandler.List)
+	protected.GET("/links/suggest", linkHandler.Suggest)
 	protected.POST("/links", linkHandler.Create)
 	protected.DELETE("/links/delete/:id", linkHandler.Delete)
 	protected.PUT("/links/:id", linkHandler.Update)
 	protected.GET("/links/:alias/stats", linkHandler.GetStats)
+	protected.GET("/tags", linkHandler.ListTags)
+
+	// Bulk import and streaming export
+	importExportHandler := NewImportExportHandler(linkRepo, userRepo, services.NewLinkImporter(linkRepo, userRepo, aliasPolicy, urlPolicy), cfg.PublicURL)
+	protected.POST("/links/import", importExportHandler.Import)
+	protected.GET("/links/export", importExportHandler.Export)
 
 	// Bulk operations
-	protected.POST("/links/bulk/delete", linkHandler.BulkDelete)
-	protected.POST("/links/bulk/status", linkHandler.BulkUpdateStatus)
+	bulkHandler := NewBulkHandler(linkRepo, userRepo, auditRepo)
+	protected.POST("/links/bulk", bulkHandler.Apply)
+	protected.POST("/links/bulk/delete", bulkHandler.BulkDelete)
+	protected.POST("/links/bulk/status", bulkHandler.BulkUpdateStatus)
+
+	// Find-and-replace over destinations, with per-link revisions
+	rewriteHandler := NewRewriteHandler(linkRepo, services.NewDestinationRewriter(linkRepo, urlPolicy), auditRepo)
+	protected.POST("/links/rewrite", rewriteHandler.Rewrite)
+	protected.GET("/links/:alias/revisions", rewriteHandler.ListRevisions)
+
+	// Links leading to the same place, and merging them
+	duplicateHandler := NewDuplicateHandler(linkRepo, auditRepo)
+	protected.GET("/links/duplicates", duplicateHandler.List)
+	protected.POST("/links/merge", duplicateHandler.Merge)
+
+	// Search across every link the caller can see
+	searchHandler := NewSearchHandler(linkRepo, cursors)
+	protected.GET("/search", searchHandler.Search)
+
+	// Resolve links without following them, for extensions and bots
+	resolveHandler := NewResolveHandler(linkRepo, suggestIndex)
+	protected.GET("/resolve/:alias", resolveHandler.Resolve)
+	protected.POST("/resolve", resolveHandler.BatchResolve)
+
+	// Favorites: links the current user starred, in their order
+	favoriteHandler := NewFavoriteHandler(linkRepo, suggestIndex)
+	protected.GET("/favorites", favoriteHandler.List)
+	protected.PUT("/favorites", favoriteHandler.Reorder)
+	protected.PUT("/favorites/:alias", favoriteHandler.Star)
+	protected.DELETE("/favorites/:alias", favoriteHandler.Unstar)
+
+	// Directory of every public link, as JSON and as a page
+	directoryHandler := NewDirectoryHandler(linkRepo, cursors, cfg.PublicURL)
+	protected.GET("/directory", directoryHandler.List)
+	router.GET("/directory", authMiddleware.AuthenticatePageGin, directoryHandler.Page)
+
+	// Keep, archive or delete a link from a stale link digest, without signing in
+	linkActionHandler := NewLinkActionHandler(linkRepo, services.NewLinkActionTokens(cfg.LinkActionSecret, cfg.LinkActionTTL),
+		cfg.StaleLinkKeepFor, cfg.PublicURL)
+	router.GET("/link-actions/:token", linkActionHandler.Confirm)
+	router.POST("/link-actions/:token", linkActionHandler.Apply)
+
+	// Audit entries addressed to the current user
+	auditHandler := NewAuditHandler(auditRepo)
+	protected.GET("/audit", auditHandler.ListMine)
+
+	// Notifications sent to the current user, and which ones they want
+	notificationHandler := NewNotificationHandler(notificationRepo)
+	protected.GET("/notifications", notificationHandler.ListMine)
+	protected.GET("/notifications/preferences", notificationHandler.GetPreferences)
+	protected.PUT("/notifications/preferences", notificationHandler.UpdatePreferences)
+
+	// Outbound webhooks for link events, with their delivery logs
+	webhookHandler := NewWebhookHandler(webhookRepo)
+	protected.GET("/webhooks", webhookHandler.List)
+	protected.POST("/webhooks", webhookHandler.Create)
+	protected.PUT("/webhooks/:id", webhookHandler.Update)
+	protected.DELETE("/webhooks/:id", webhookHandler.Delete)
+	protected.GET("/webhooks/:id/deliveries", webhookHandler.ListDeliveries)
+
+	// The /go slash command in Slack
+	if cfg.SlackSigningSecret != "" {
+		slackClient := slack.NewClient(cfg.SlackBotToken, cfg.SlackAPIURL, &http.Client{Timeout: 10 * time.Second})
+		slackHandler := NewSlackHandler(linkRepo, userRepo, aliasPolicy, urlPolicy, slackClient, cfg.SlackSigningSecret, cfg.PublicURL)
+		router.POST("/slack/commands", slackHandler.Command)
+	}
 
 	// Admin routes
 	admin := protected.Group("/admin")
 	admin.Use(authMiddleware.RequireAdminGin)
 
-	adminHandler := NewAdminHandler(analyticsRepo, linkRepo, userRepo)
+	adminHandler := NewAdminHandler(analyticsRepo, linkRepo, userRepo, aliasPolicy, urlPolicy, auditRepo, cursors)
 	admin.GET("/stats", adminHandler.GetSystemStats)
 	admin.GET("/stats/redirects", adminHandler.GetRedirectsOverTime)
 	admin.GET("/stats/popular", adminHandler.GetPopularLinks)
@@ -110,7 +201,12 @@ func SetupRoutes(
 	admin.GET("/stats/peak-usage", adminHandler.GetPeakUsage)
 	admin.GET("/stats/performance", adminHandler.GetPerformanceMetrics)
 	admin.GET("/links", adminHandler.ListAllLinks)
+	admin.GET("/links/broken", adminHandler.ListBrokenLinks)
 	admin.PUT("/links/:alias", adminHandler.UpdateLinkAdmin)
+	admin.GET("/alias-rules", adminHandler.ListAliasRules)
+	admin.POST("/alias-rules", adminHandler.CreateAliasRule)
+	admin.DELETE("/alias-rules/:id", adminHandler.DeleteAliasRule)
+	admin.GET("/audit", auditHandler.ListAll)
 
 	// Print all routes at the end
 	routes := router.Routes()
@@ -118,7 +214,7 @@ func SetupRoutes(
 	for _, route := range routes {
 		log.Printf("Route: %s\t%s", route.Method, route.Path)
 	}
-	log.Println("=== End Routes ===\n")
+	log.Println("=== End Routes ===")
 
 	return router
 }
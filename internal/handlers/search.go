@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	linkRepo *models.LinkRepository
+	cursors  *CursorCodec
+}
+
+func NewSearchHandler(linkRepo *models.LinkRepository, cursors *CursorCodec) *SearchHandler {
+	return &SearchHandler{
+		linkRepo: linkRepo,
+		cursors:  cursors,
+	}
+}
+
+// Search handles GET /api/search?q=&limit=&cursor= across every link the
+// caller can see.
+func (h *SearchHandler) Search(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if len(q) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query must be at least 2 characters"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	opts := models.SearchOptions{
+		Query:  q,
+		UserID: getUserIDFromContext(c),
+		Limit:  limit,
+	}
+
+	backward := false
+	if cursor := c.Query("cursor"); cursor != "" {
+		pos := &models.SearchPosition{}
+		var err error
+		backward, err = h.cursors.Decode(cursor, cursorKindSearch, q, pos)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if backward {
+			opts.Before = pos
+		} else {
+			opts.After = pos
+		}
+	}
+
+	result, err := h.linkRepo.Search(c.Request.Context(), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"items": result.Hits}
+	if result.Hits == nil {
+		response["items"] = []*models.SearchHit{}
+	}
+	hasNext, hasPrev := neighbourPages(result.HasMore, backward, opts.After != nil || opts.Before != nil)
+	if len(result.Hits) > 0 {
+		first, last := result.Hits[0], result.Hits[len(result.Hits)-1]
+		if hasNext {
+			response["next_cursor"] = h.cursors.Encode(cursorKindSearch, q, false, &models.SearchPosition{Score: last.Score, ID: last.ID})
+		}
+		if hasPrev {
+			response["prev_cursor"] = h.cursors.Encode(cursorKindSearch, q, true, &models.SearchPosition{Score: first.Score, ID: first.ID})
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/devingoodsell/go-links-free/internal/slack"
+	"github.com/gin-gonic/gin"
+)
+
+// maxSlackBodyBytes caps the form body of a slash command.
+const maxSlackBodyBytes = 64 << 10
+
+// slackCommandTimeout bounds the work done for one command, including the
+// reply to Slack.
+const slackCommandTimeout = 30 * time.Second
+
+const slackUsage = "Usage:\n" +
+	"• `/go foo` shows where go/foo leads\n" +
+	"• `/go create foo https://example.com` creates go/foo\n" +
+	"• `/go who foo` shows who owns go/foo and how much it is used"
+
+// SlackHandler serves the /go slash command. Slack users are matched to
+// go links users by the email of their Slack profile.
+type SlackHandler struct {
+	linkRepo      *models.LinkRepository
+	userRepo      *models.UserRepository
+	aliasPolicy   *services.AliasPolicy
+	urlPolicy     *services.URLPolicy
+	client        *slack.Client
+	signingSecret string
+	baseURL       string
+}
+
+func NewSlackHandler(
+	linkRepo *models.LinkRepository,
+	userRepo *models.UserRepository,
+	aliasPolicy *services.AliasPolicy,
+	urlPolicy *services.URLPolicy,
+	client *slack.Client,
+	signingSecret string,
+	baseURL string,
+) *SlackHandler {
+	return &SlackHandler{
+		linkRepo:      linkRepo,
+		userRepo:      userRepo,
+		aliasPolicy:   aliasPolicy,
+		urlPolicy:     urlPolicy,
+		client:        client,
+		signingSecret: signingSecret,
+		baseURL:       baseURL,
+	}
+}
+
+// Command handles POST /slack/commands. Slack expects an answer within
+// three seconds and finding the user alone is a call back to Slack, so the
+// request is acknowledged at once and the reply is posted to the command's
+// response URL.
+func (h *SlackHandler) Command(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSlackBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	if err := slack.Verify(h.signingSecret, c.Request.Header, body, time.Now()); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	cmd, err := slack.ParseCommand(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	go h.reply(cmd)
+	c.Status(http.StatusOK)
+}
+
+func (h *SlackHandler) reply(cmd *slack.Command) {
+	ctx, cancel := context.WithTimeout(context.Background(), slackCommandTimeout)
+	defer cancel()
+
+	response := h.run(ctx, cmd)
+	if err := h.client.Respond(ctx, cmd.ResponseURL, response); err != nil {
+		log.Printf("Error replying to Slack command of %s: %v", cmd.UserID, err)
+	}
+}
+
+// run carries out a command and returns the reply.
+func (h *SlackHandler) run(ctx context.Context, cmd *slack.Command) *slack.Response {
+	args := strings.Fields(cmd.Text)
+	if len(args) == 0 || args[0] == "help" {
+		return slackReply(slackUsage)
+	}
+
+	user, err := h.user(ctx, cmd.UserID)
+	if err != nil {
+		return slackReply(err.Error())
+	}
+
+	switch args[0] {
+	case "create":
+		if len(args) != 3 {
+			return slackReply("Usage: `/go create foo https://example.com`")
+		}
+		return h.create(ctx, user, args[1], slack.Unwrap(args[2]))
+	case "who":
+		if len(args) != 2 {
+			return slackReply("Usage: `/go who foo`")
+		}
+		return h.who(ctx, args[1])
+	default:
+		return h.lookup(ctx, args[0], args[1:])
+	}
+}
+
+// user finds the go links user with the email of a Slack user. Errors are
+// worded for the person who ran the command.
+func (h *SlackHandler) user(ctx context.Context, slackUserID string) (*models.User, error) {
+	email, err := h.client.UserEmail(ctx, slackUserID)
+	if err != nil {
+		log.Printf("Error looking up Slack user %s: %v", slackUserID, err)
+		return nil, errors.New("Sorry, your Slack account could not be looked up.")
+	}
+
+	user, err := h.userRepo.GetByEmail(ctx, email)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, fmt.Errorf("No go links account uses %s. Sign in at %s first.",
+			slack.Escape(email), h.baseURL)
+	}
+	if err != nil {
+		log.Printf("Error looking up user %s: %v", email, err)
+		return nil, errors.New("Sorry, something went wrong.")
+	}
+	return user, nil
+}
+
+// lookup replies with where go/alias leads, with args filled in as they
+// would be on a redirect.
+func (h *SlackHandler) lookup(ctx context.Context, alias string, args []string) *slack.Response {
+	link, ok, response := h.find(ctx, alias)
+	if !ok {
+		return response
+	}
+
+	forward, err := h.linkRepo.Forward(ctx, link)
+	if err != nil {
+		log.Printf("Error following merged link %d: %v", link.ID, err)
+		return slackReply("Sorry, something went wrong.")
+	}
+
+	name := "go/" + slack.Escape(link.Alias)
+	if forward != link {
+		name += " (now go/" + slack.Escape(forward.Alias) + ")"
+	}
+	switch {
+	case !forward.IsActive:
+		return slackReply(name + " is inactive.")
+	case forward.IsExpired():
+		return slackReply(name + " has expired.")
+	}
+	return slackReply(name + " → " + slack.Escape(services.ExpandDestination(forward.DestinationURL, args)))
+}
+
+// who replies with the owner of go/alias and how much it is used.
+func (h *SlackHandler) who(ctx context.Context, alias string) *slack.Response {
+	link, ok, response := h.find(ctx, alias)
+	if !ok {
+		return response
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "go/%s is owned by %s, created %s.\n", slack.Escape(link.Alias),
+		slack.Escape(link.OwnerEmail), link.CreatedAt.Format("2006-01-02"))
+	stats := link.Stats
+	if stats == nil {
+		stats = &models.LinkStats{}
+	}
+	fmt.Fprintf(&b, "Clicks: %d today, %d this week, %d in total.", stats.DailyCount, stats.WeeklyCount, stats.TotalCount)
+	if stats.LastAccessedAt != nil {
+		fmt.Fprintf(&b, " Last used %s.", stats.LastAccessedAt.Format("2006-01-02"))
+	}
+	switch {
+	case !link.IsActive:
+		b.WriteString("\nIt is inactive.")
+	case link.IsExpired():
+		b.WriteString("\nIt has expired.")
+	case link.ExpiresAt != nil:
+		fmt.Fprintf(&b, "\nIt expires %s.", link.ExpiresAt.Format("2006-01-02"))
+	}
+	return slackReply(b.String())
+}
+
+// create makes go/alias for user, holding it to the same policies as links
+// created through the API.
+func (h *SlackHandler) create(ctx context.Context, user *models.User, alias, destination string) *slack.Response {
+	if len(alias) > 100 {
+		return slackReply("Could not create go/" + slack.Escape(alias) + ": alias must be 100 characters or less")
+	}
+
+	var policyErr *services.PolicyError
+	err := h.aliasPolicy.Check(alias)
+	if err == nil {
+		destination, err = h.urlPolicy.Apply(ctx, alias, destination)
+	}
+	if errors.As(err, &policyErr) {
+		return slackReply("Could not create go/" + slack.Escape(alias) + ": " + slack.Escape(policyErr.Message))
+	}
+	if err != nil {
+		log.Printf("Error checking link go/%s from Slack: %v", alias, err)
+		return slackReply("Sorry, something went wrong.")
+	}
+
+	link := &models.Link{
+		Alias:          alias,
+		DestinationURL: destination,
+		CreatedBy:      user.ID,
+		IsActive:       true,
+	}
+	err = h.linkRepo.Create(ctx, link)
+	if errors.Is(err, models.ErrDuplicate) {
+		return slackReply("go/" + slack.Escape(alias) + " already exists. `/go who " + slack.Escape(alias) + "` shows who owns it.")
+	}
+	if err != nil {
+		log.Printf("Error creating link go/%s from Slack: %v", alias, err)
+		return slackReply("Sorry, something went wrong.")
+	}
+	return slackReply("Created go/" + slack.Escape(link.Alias) + " → " + slack.Escape(link.DestinationURL))
+}
+
+// find looks up go/alias, or returns the reply to send when there is none.
+func (h *SlackHandler) find(ctx context.Context, alias string) (*models.Link, bool, *slack.Response) {
+	link, err := h.linkRepo.GetByAlias(ctx, alias)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, false, slackReply("go/" + slack.Escape(alias) + " does not exist. `/go create " + slack.Escape(alias) + " &lt;url&gt;` creates it.")
+	}
+	if err != nil {
+		log.Printf("Error looking up go/%s from Slack: %v", alias, err)
+		return nil, false, slackReply("Sorry, something went wrong.")
+	}
+	return link, true, nil
+}
+
+func slackReply(text string) *slack.Response {
+	return &slack.Response{ResponseType: slack.ResponseEphemeral, Text: text}
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/safehttp"
+	"github.com/devingoodsell/go-links-free/internal/webhooks"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler manages the webhook endpoints of the current user and
+// shows their delivery logs. Admins can manage every endpoint and register
+// endpoints for all links.
+type WebhookHandler struct {
+	webhookRepo *models.WebhookRepository
+}
+
+func NewWebhookHandler(webhookRepo *models.WebhookRepository) *WebhookHandler {
+	return &WebhookHandler{
+		webhookRepo: webhookRepo,
+	}
+}
+
+type webhookRequest struct {
+	URL      *string  `json:"url"`
+	Events   []string `json:"events"`
+	AllLinks *bool    `json:"allLinks"`
+	IsActive *bool    `json:"isActive"`
+}
+
+// List handles GET /api/webhooks: the caller's endpoints, or every
+// endpoint for admins passing all=true.
+func (h *WebhookHandler) List(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if c.Query("all") == "true" && isAdminFromContext(c) {
+		userID = 0
+	}
+
+	endpoints, err := h.webhookRepo.ListEndpoints(c.Request.Context(), userID)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	for _, e := range endpoints {
+		e.Secret = ""
+	}
+	c.JSON(http.StatusOK, gin.H{"items": endpoints})
+}
+
+// Create handles POST /api/webhooks. The response carries the signing
+// secret, which is not shown again.
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.URL == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url is required"})
+		return
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	endpoint := &models.WebhookEndpoint{
+		Secret:    secret,
+		Events:    models.WebhookEventTypes,
+		CreatedBy: getUserIDFromContext(c),
+		IsActive:  true,
+	}
+	if !h.apply(c, endpoint, req) {
+		return
+	}
+
+	if err := h.webhookRepo.CreateEndpoint(c.Request.Context(), endpoint); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, endpoint)
+}
+
+// Update handles PUT /api/webhooks/:id. Fields left out keep their value.
+func (h *WebhookHandler) Update(c *gin.Context) {
+	endpoint, ok := h.load(c)
+	if !ok {
+		return
+	}
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	if !h.apply(c, endpoint, req) {
+		return
+	}
+
+	if err := h.webhookRepo.UpdateEndpoint(c.Request.Context(), endpoint); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	endpoint.Secret = ""
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// Delete handles DELETE /api/webhooks/:id, dropping its deliveries too.
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	endpoint, ok := h.load(c)
+	if !ok {
+		return
+	}
+	if err := h.webhookRepo.DeleteEndpoint(c.Request.Context(), endpoint.ID); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /api/webhooks/:id/deliveries: the latest
+// deliveries to the endpoint with their attempts and last response,
+// optionally filtered by status.
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	endpoint, ok := h.load(c)
+	if !ok {
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, delivered or failed"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	deliveries, err := h.webhookRepo.ListDeliveries(c.Request.Context(), endpoint.ID, status, limit)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": deliveries})
+}
+
+// load finds the endpoint of the request, which must be the caller's
+// unless they are an admin.
+func (h *WebhookHandler) load(c *gin.Context) (*models.WebhookEndpoint, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook ID"})
+		return nil, false
+	}
+	endpoint, err := h.webhookRepo.GetEndpoint(c.Request.Context(), id)
+	if err == nil && endpoint.CreatedBy != getUserIDFromContext(c) && !isAdminFromContext(c) {
+		err = models.ErrNotFound
+	}
+	if err != nil {
+		respondWithError(c, err)
+		return nil, false
+	}
+	return endpoint, true
+}
+
+// apply copies the fields of req onto endpoint, answering the request
+// itself if any of them is invalid.
+func (h *WebhookHandler) apply(c *gin.Context, endpoint *models.WebhookEndpoint, req webhookRequest) bool {
+	if req.URL != nil {
+		u, err := url.Parse(*req.URL)
+		if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "url must be an http or https URL"})
+			return false
+		}
+		// Plain HTTP would expose events and let them be tampered with on the
+		// way; admins may still use it where they trust the path.
+		if u.Scheme != "https" && !isAdminFromContext(c) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "url must use https"})
+			return false
+		}
+		// Deliveries never go to internal addresses. Names are checked when
+		// they are resolved for each delivery; addresses are refused here.
+		if ip := net.ParseIP(u.Hostname()); (ip != nil && !safehttp.Allowed(ip)) || u.Hostname() == "localhost" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "url must be a public address"})
+			return false
+		}
+		endpoint.URL = u.String()
+	}
+
+	if req.Events != nil {
+		if len(req.Events) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "subscribe to at least one event"})
+			return false
+		}
+		seen := make(map[string]bool)
+		events := []string{}
+		for _, event := range req.Events {
+			if !models.ValidWebhookEvent(event) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unknown event " + event})
+				return false
+			}
+			if !seen[event] {
+				seen[event] = true
+				events = append(events, event)
+			}
+		}
+		endpoint.Events = events
+	}
+
+	if req.AllLinks != nil {
+		if *req.AllLinks && !isAdminFromContext(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only admins can subscribe to all links"})
+			return false
+		}
+		endpoint.AllLinks = *req.AllLinks
+	}
+	if req.IsActive != nil {
+		endpoint.IsActive = *req.IsActive
+	}
+	return true
+}
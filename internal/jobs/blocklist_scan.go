@@ -0,0 +1,121 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+)
+
+// BlocklistScanJob watches the malicious domain blocklist file and, whenever
+// it changes, re-checks every link against it. Matching links are flagged
+// (and deactivated if configured) and an audit entry is addressed to the
+// link owner.
+type BlocklistScanJob struct {
+	blocklist  *services.DomainBlocklist
+	linkRepo   *models.LinkRepository
+	auditRepo  *models.AuditLogRepository
+	deactivate bool
+	interval   time.Duration
+	stopChan   chan struct{}
+}
+
+func NewBlocklistScanJob(
+	blocklist *services.DomainBlocklist,
+	linkRepo *models.LinkRepository,
+	auditRepo *models.AuditLogRepository,
+	deactivate bool,
+	interval time.Duration,
+) *BlocklistScanJob {
+	return &BlocklistScanJob{
+		blocklist:  blocklist,
+		linkRepo:   linkRepo,
+		auditRepo:  auditRepo,
+		deactivate: deactivate,
+		interval:   interval,
+		stopChan:   make(chan struct{}),
+	}
+}
+
+func (j *BlocklistScanJob) Start() {
+	ticker := time.NewTicker(j.interval)
+	go func() {
+		// Scan once at startup: the list may have changed while we were down.
+		j.scan()
+
+		for {
+			select {
+			case <-ticker.C:
+				changed, err := j.blocklist.Reload()
+				if err != nil {
+					log.Printf("Error reloading domain blocklist: %v", err)
+					continue
+				}
+				if changed {
+					log.Printf("Domain blocklist changed, %d domains loaded", j.blocklist.Len())
+					j.scan()
+				}
+			case <-j.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (j *BlocklistScanJob) Stop() {
+	close(j.stopChan)
+}
+
+func (j *BlocklistScanJob) scan() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	flagged, err := j.Scan(ctx)
+	if err != nil {
+		log.Printf("Error scanning links against domain blocklist: %v", err)
+		return
+	}
+	if flagged > 0 {
+		log.Printf("Flagged %d links pointing at blocklisted domains", flagged)
+	}
+}
+
+// Scan checks every unflagged link and returns how many were flagged.
+func (j *BlocklistScanJob) Scan(ctx context.Context) (int, error) {
+	links, err := j.linkRepo.ListUnflagged(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	flagged := 0
+	for _, link := range links {
+		u, err := url.Parse(link.DestinationURL)
+		if err != nil || !j.blocklist.Contains(u.Hostname()) {
+			continue
+		}
+
+		reason := "destination domain " + u.Hostname() + " is on the malicious domain blocklist"
+		entry, err := models.NewAuditLog(models.AuditLinkFlagged, 0, link.ID, link.CreatedBy, map[string]interface{}{
+			"alias":       link.Alias,
+			"destination": link.DestinationURL,
+			"domain":      u.Hostname(),
+			"reason":      reason,
+			"deactivated": j.deactivate && link.IsActive,
+		})
+		if err != nil {
+			return flagged, err
+		}
+		if err := j.linkRepo.Flag(ctx, link.ID, reason, j.deactivate, entry); err != nil {
+			log.Printf("Error flagging link %d: %v", link.ID, err)
+			continue
+		}
+		j.auditRepo.Recorded(ctx, entry)
+		flagged++
+	}
+
+	return flagged, nil
+}
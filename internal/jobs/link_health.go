@@ -0,0 +1,289 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/safehttp"
+)
+
+// Doer sends HTTP requests. *http.Client is one; the server passes a
+// safehttp client so destinations on internal addresses are never
+// requested, and tests pass a client for an httptest server.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type LinkHealthConfig struct {
+	Interval     time.Duration // between runs
+	MaxAge       time.Duration // links checked longer ago than this are due again
+	BatchSize    int           // links checked per run at most
+	Concurrency  int           // checks in flight at once
+	HostInterval time.Duration // least time between two requests to one host
+	Timeout      time.Duration // per request
+	UserAgent    string
+}
+
+// maxHealthBodyBytes is how much of a GET response is read before the
+// connection is dropped; the status is all that matters.
+const maxHealthBodyBytes = 64 << 10
+
+// LinkHealthJob periodically requests link destinations and records which
+// ones are broken. It sends HEAD first and falls back to GET for servers
+// that refuse or mishandle HEAD.
+type LinkHealthJob struct {
+	linkRepo *models.LinkRepository
+	client   Doer
+	cfg      LinkHealthConfig
+	hosts    *hostLimiter
+	stopChan chan struct{}
+
+	// OnResult, if set, is called after each check is recorded.
+	OnResult func(link *models.Link, health *models.LinkHealth)
+}
+
+func NewLinkHealthJob(linkRepo *models.LinkRepository, client Doer, cfg LinkHealthConfig) *LinkHealthJob {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if client == nil {
+		client = safehttp.NewClient(0)
+	}
+	return &LinkHealthJob{
+		linkRepo: linkRepo,
+		client:   client,
+		cfg:      cfg,
+		hosts:    newHostLimiter(cfg.HostInterval),
+		stopChan: make(chan struct{}),
+	}
+}
+
+func (j *LinkHealthJob) Start() {
+	ticker := time.NewTicker(j.cfg.Interval)
+	go func() {
+		j.run()
+
+		for {
+			select {
+			case <-ticker.C:
+				j.run()
+			case <-j.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (j *LinkHealthJob) Stop() {
+	close(j.stopChan)
+}
+
+func (j *LinkHealthJob) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), j.cfg.Interval)
+	defer cancel()
+
+	checked, broken, err := j.CheckDue(ctx)
+	if err != nil {
+		log.Printf("Error checking link destinations: %v", err)
+		return
+	}
+	if checked > 0 {
+		log.Printf("Checked %d link destinations, %d broken", checked, broken)
+	}
+}
+
+// CheckDue checks the links that are due and returns how many were checked
+// and how many of those are broken.
+func (j *LinkHealthJob) CheckDue(ctx context.Context) (int, int, error) {
+	links, err := j.linkRepo.ListDueForCheck(ctx, time.Now().Add(-j.cfg.MaxAge), j.cfg.BatchSize)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		mu      sync.Mutex
+		checked int
+		broken  int
+		wg      sync.WaitGroup
+	)
+	queue := make(chan *models.Link)
+	for i := 0; i < j.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range queue {
+				health := j.Check(ctx, link.DestinationURL)
+				health.LinkID = link.ID
+				if err := j.linkRepo.RecordHealth(ctx, health); err != nil {
+					log.Printf("Error recording health of link %d: %v", link.ID, err)
+					continue
+				}
+				if j.OnResult != nil {
+					j.OnResult(link, health)
+				}
+
+				mu.Lock()
+				checked++
+				if !health.Healthy {
+					broken++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, link := range links {
+		select {
+		case queue <- link:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	return checked, broken, ctx.Err()
+}
+
+// Check requests destination and reports whether it is reachable.
+func (j *LinkHealthJob) Check(ctx context.Context, destination string) *models.LinkHealth {
+	health := &models.LinkHealth{DestinationURL: destination}
+
+	status, latency, err := j.probe(ctx, http.MethodHead, destination)
+	if err != nil || status >= 400 {
+		status, latency, err = j.probe(ctx, http.MethodGet, destination)
+	}
+
+	health.CheckedAt = time.Now()
+	health.LatencyMS = latency.Milliseconds()
+	health.StatusCode = status
+	if err != nil {
+		health.Error = describeCheckError(err)
+	}
+	health.Healthy = err == nil && healthyStatus(status)
+	return health
+}
+
+// healthyStatus reports whether a final response status means the page is
+// there. Sign-in walls and rate limits count as there: the checker has no
+// credentials and should not be judged for being throttled.
+func healthyStatus(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return true
+	}
+	return status < 400
+}
+
+// describeCheckError names the kind of failure without the details of the
+// transport error, which owners see and which would otherwise tell them
+// about the network the checker runs in.
+func describeCheckError(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, safehttp.ErrAddressNotAllowed):
+		return "address not allowed"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timed out"
+	case errors.As(err, &dnsErr):
+		return "host not found"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && strings.Contains(urlErr.Err.Error(), "redirect") {
+		return "too many or invalid redirects"
+	}
+	return "could not connect"
+}
+
+func (j *LinkHealthJob) probe(ctx context.Context, method, destination string) (int, time.Duration, error) {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := j.hosts.wait(ctx, u.Hostname()); err != nil {
+		return 0, 0, err
+	}
+
+	if j.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.cfg.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, destination, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	if j.cfg.UserAgent != "" {
+		req.Header.Set("User-Agent", j.cfg.UserAgent)
+	}
+
+	start := time.Now()
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return 0, time.Since(start), fmt.Errorf("%s failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+	if method == http.MethodGet {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxHealthBodyBytes))
+	}
+	return resp.StatusCode, time.Since(start), nil
+}
+
+// hostLimiter spaces out requests to each host by at least interval.
+type hostLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     map[string]time.Time
+}
+
+func newHostLimiter(interval time.Duration) *hostLimiter {
+	return &hostLimiter{interval: interval, next: make(map[string]time.Time)}
+}
+
+// wait blocks until a request to host may be sent, reserving that slot.
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	if l.interval <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
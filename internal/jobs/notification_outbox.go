@@ -0,0 +1,129 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/notify"
+)
+
+type NotificationOutboxConfig struct {
+	Interval    time.Duration // between runs
+	BatchSize   int           // notifications sent per run at most
+	MaxAttempts int           // attempts before a notification is given up on
+	RetryBase   time.Duration // wait after the first failure, doubled after each one
+	RetryMax    time.Duration // longest wait between attempts
+	Timeout     time.Duration // per attempt
+}
+
+// NotificationOutboxJob sends the notifications waiting in the outbox and
+// retries failed ones with exponential backoff.
+type NotificationOutboxJob struct {
+	repo     *models.NotificationRepository
+	notifier notify.Notifier
+	cfg      NotificationOutboxConfig
+	stopChan chan struct{}
+}
+
+func NewNotificationOutboxJob(repo *models.NotificationRepository, notifier notify.Notifier, cfg NotificationOutboxConfig) *NotificationOutboxJob {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	return &NotificationOutboxJob{
+		repo:     repo,
+		notifier: notifier,
+		cfg:      cfg,
+		stopChan: make(chan struct{}),
+	}
+}
+
+func (j *NotificationOutboxJob) Start() {
+	ticker := time.NewTicker(j.cfg.Interval)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+				if _, _, err := j.Deliver(ctx); err != nil {
+					log.Printf("Error sending notifications: %v", err)
+				}
+				cancel()
+			case <-j.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (j *NotificationOutboxJob) Stop() {
+	close(j.stopChan)
+}
+
+// Deliver sends the notifications that are due and returns how many were
+// sent and how many failed.
+func (j *NotificationOutboxJob) Deliver(ctx context.Context) (int, int, error) {
+	// Hold claimed notifications for longer than sending them all can take.
+	lease := time.Duration(j.cfg.BatchSize)*j.cfg.Timeout + time.Minute
+	due, err := j.repo.ClaimDue(ctx, j.cfg.BatchSize, lease)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	sent, failed := 0, 0
+	for _, n := range due {
+		if err := j.send(ctx, n); err != nil {
+			failed++
+			var retryAt *time.Time
+			if n.Attempts+1 < j.cfg.MaxAttempts {
+				at := time.Now().Add(backoff(j.cfg.RetryBase, j.cfg.RetryMax, n.Attempts+1))
+				retryAt = &at
+			} else {
+				log.Printf("Giving up on notification %d to %s: %v", n.ID, n.Recipient, err)
+			}
+			if err := j.repo.MarkFailed(ctx, n.ID, err.Error(), retryAt); err != nil {
+				return sent, failed, err
+			}
+			continue
+		}
+
+		sent++
+		if err := j.repo.MarkSent(ctx, n.ID); err != nil {
+			return sent, failed, err
+		}
+	}
+	return sent, failed, nil
+}
+
+func (j *NotificationOutboxJob) send(ctx context.Context, n *models.Notification) error {
+	if j.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.cfg.Timeout)
+		defer cancel()
+	}
+	return j.notifier.Send(ctx, &notify.Message{
+		UserID:  n.UserID,
+		Kind:    n.Kind,
+		To:      n.Recipient,
+		Subject: n.Subject,
+		Body:    n.Body,
+	})
+}
+
+// backoff is how long to wait after the given number of failed attempts:
+// base, doubled after every further failure, and no longer than max.
+func backoff(base, max time.Duration, failures int) time.Duration {
+	delay := base
+	for i := 1; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -0,0 +1,172 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+)
+
+type StaleLinkConfig struct {
+	Interval           time.Duration // between runs
+	UnusedAfter        time.Duration // links without clicks for this long are stale
+	OwnerInactiveAfter time.Duration // links of owners who have not signed in for this long are stale
+	ExpiringWithin     time.Duration // links expiring within this are stale
+	NudgeEvery         time.Duration // least time between two digests mentioning one link
+	BatchSize          int           // links per run at most
+	BaseURL            string        // public URL the action links point at
+}
+
+// StaleDigest is one owner's list of stale links.
+type StaleDigest struct {
+	OwnerID    int64             `json:"ownerId"`
+	OwnerEmail string            `json:"ownerEmail"`
+	Items      []StaleDigestItem `json:"items"`
+}
+
+// StaleDigestItem is a stale link and the URLs its owner can follow to
+// keep, archive or delete it without signing in.
+type StaleDigestItem struct {
+	Alias          string     `json:"alias"`
+	DestinationURL string     `json:"destinationUrl"`
+	Reasons        []string   `json:"reasons"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt,omitempty"`
+	ExtendURL      string     `json:"extendUrl"`
+	ArchiveURL     string     `json:"archiveUrl"`
+	DeleteURL      string     `json:"deleteUrl"`
+}
+
+// StaleLinkJob periodically finds unused links, links of inactive owners
+// and links about to expire, and hands each owner a digest of theirs.
+type StaleLinkJob struct {
+	linkRepo *models.LinkRepository
+	tokens   *services.LinkActionTokens
+	cfg      StaleLinkConfig
+	stopChan chan struct{}
+
+	// Send delivers a digest. Links are only recorded as nudged when it
+	// succeeds, so a failed digest is retried on the next run. Digests are
+	// logged when it is nil.
+	Send func(ctx context.Context, digest *StaleDigest) error
+}
+
+func NewStaleLinkJob(linkRepo *models.LinkRepository, tokens *services.LinkActionTokens, cfg StaleLinkConfig) *StaleLinkJob {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+	return &StaleLinkJob{
+		linkRepo: linkRepo,
+		tokens:   tokens,
+		cfg:      cfg,
+		stopChan: make(chan struct{}),
+	}
+}
+
+func (j *StaleLinkJob) Start() {
+	ticker := time.NewTicker(j.cfg.Interval)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+				sent, err := j.Run(ctx)
+				if err != nil {
+					log.Printf("Error sending stale link digests: %v", err)
+				} else if sent > 0 {
+					log.Printf("Sent %d stale link digests", sent)
+				}
+				cancel()
+			case <-j.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (j *StaleLinkJob) Stop() {
+	close(j.stopChan)
+}
+
+// Run sends a digest to every owner with stale links that are due a nudge
+// and returns how many digests were sent.
+func (j *StaleLinkJob) Run(ctx context.Context) (int, error) {
+	now := time.Now()
+	stale, err := j.linkRepo.ListStale(ctx, models.StaleOptions{
+		UnusedSince:        now.Add(-j.cfg.UnusedAfter),
+		OwnerInactiveSince: now.Add(-j.cfg.OwnerInactiveAfter),
+		ExpiringBefore:     now.Add(j.cfg.ExpiringWithin),
+		NudgedBefore:       now.Add(-j.cfg.NudgeEvery),
+		Limit:              j.cfg.BatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for len(stale) > 0 {
+		// ListStale orders by owner, so each owner's links are consecutive.
+		n := 1
+		for n < len(stale) && stale[n].Link.CreatedBy == stale[0].Link.CreatedBy {
+			n++
+		}
+		links := stale[:n]
+		stale = stale[n:]
+
+		digest := j.Digest(links)
+		if err := j.send(ctx, digest); err != nil {
+			log.Printf("Error sending stale link digest to %s: %v", digest.OwnerEmail, err)
+			continue
+		}
+		if err := j.linkRepo.RecordNudges(ctx, links); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// Digest builds the digest for one owner's stale links.
+func (j *StaleLinkJob) Digest(links []*models.StaleLink) *StaleDigest {
+	digest := &StaleDigest{}
+	for _, stale := range links {
+		link := stale.Link
+		digest.OwnerID, digest.OwnerEmail = link.CreatedBy, link.OwnerEmail
+
+		item := StaleDigestItem{
+			Alias:          link.Alias,
+			DestinationURL: link.DestinationURL,
+			Reasons:        stale.Reasons,
+			ExpiresAt:      link.ExpiresAt,
+			ExtendURL:      j.actionURL(link, services.LinkActionExtend),
+			ArchiveURL:     j.actionURL(link, services.LinkActionArchive),
+			DeleteURL:      j.actionURL(link, services.LinkActionDelete),
+		}
+		if link.Stats != nil {
+			item.LastAccessedAt = link.Stats.LastAccessedAt
+		}
+		digest.Items = append(digest.Items, item)
+	}
+	return digest
+}
+
+func (j *StaleLinkJob) actionURL(link *models.Link, action string) string {
+	return strings.TrimRight(j.cfg.BaseURL, "/") + "/link-actions/" + url.PathEscape(j.tokens.Issue(link, action))
+}
+
+func (j *StaleLinkJob) send(ctx context.Context, digest *StaleDigest) error {
+	if j.Send != nil {
+		return j.Send(ctx, digest)
+	}
+	aliases := make([]string, len(digest.Items))
+	for i, item := range digest.Items {
+		aliases[i] = item.Alias
+	}
+	log.Printf("Stale links of %s: %s", digest.OwnerEmail, strings.Join(aliases, ", "))
+	return nil
+}
@@ -0,0 +1,133 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/webhooks"
+)
+
+type WebhookConfig struct {
+	Interval        time.Duration // between runs
+	BatchSize       int           // deliveries per run at most
+	MaxAttempts     int           // attempts before a delivery is given up on
+	RetryBase       time.Duration // wait after the first failure, doubled after each one
+	RetryMax        time.Duration // longest wait between attempts
+	Timeout         time.Duration // per attempt
+	ClickThresholds []int         // total clicks that emit link.threshold_reached
+	Lookback        time.Duration // how far back expiries and clicks are looked for
+}
+
+// WebhookJob turns the link change outbox into events, emits the link
+// events that are found by polling and delivers queued events to their
+// endpoints, retrying failures with exponential backoff.
+type WebhookJob struct {
+	repo       *models.WebhookRepository
+	dispatcher *webhooks.Dispatcher
+	client     *http.Client
+	cfg        WebhookConfig
+	stopChan   chan struct{}
+}
+
+func NewWebhookJob(repo *models.WebhookRepository, dispatcher *webhooks.Dispatcher, client *http.Client, cfg WebhookConfig) *WebhookJob {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	return &WebhookJob{
+		repo:       repo,
+		dispatcher: dispatcher,
+		client:     client,
+		cfg:        cfg,
+		stopChan:   make(chan struct{}),
+	}
+}
+
+func (j *WebhookJob) Start() {
+	ticker := time.NewTicker(j.cfg.Interval)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+				if err := j.ProcessChanges(ctx); err != nil {
+					log.Printf("Error processing link changes for webhooks: %v", err)
+				}
+				if _, err := j.dispatcher.EmitScheduled(ctx, j.cfg.ClickThresholds, j.cfg.Lookback, j.cfg.BatchSize); err != nil {
+					log.Printf("Error emitting scheduled link events: %v", err)
+				}
+				if _, _, err := j.Deliver(ctx); err != nil {
+					log.Printf("Error delivering webhooks: %v", err)
+				}
+				cancel()
+			case <-j.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (j *WebhookJob) Stop() {
+	close(j.stopChan)
+}
+
+// ProcessChanges queues events for every pending link change, a batch at a
+// time.
+func (j *WebhookJob) ProcessChanges(ctx context.Context) error {
+	for {
+		n, err := j.dispatcher.ProcessChanges(ctx, j.cfg.BatchSize)
+		if err != nil || n < j.cfg.BatchSize {
+			return err
+		}
+	}
+}
+
+// Deliver sends the deliveries that are due and returns how many succeeded
+// and how many failed.
+func (j *WebhookJob) Deliver(ctx context.Context) (int, int, error) {
+	lease := time.Duration(j.cfg.BatchSize)*j.cfg.Timeout + time.Minute
+	due, err := j.repo.ClaimDueDeliveries(ctx, j.cfg.BatchSize, lease)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	delivered, failed := 0, 0
+	for _, d := range due {
+		status, err := j.send(ctx, d)
+		if err != nil {
+			failed++
+			var retryAt *time.Time
+			if d.Attempts+1 < j.cfg.MaxAttempts {
+				at := time.Now().Add(backoff(j.cfg.RetryBase, j.cfg.RetryMax, d.Attempts+1))
+				retryAt = &at
+			} else {
+				log.Printf("Giving up on webhook delivery %d to %s: %v", d.ID, d.URL, err)
+			}
+			if err := j.repo.MarkDeliveryFailed(ctx, d.ID, status, err.Error(), retryAt); err != nil {
+				return delivered, failed, err
+			}
+			continue
+		}
+
+		delivered++
+		if err := j.repo.MarkDelivered(ctx, d.ID, status); err != nil {
+			return delivered, failed, err
+		}
+	}
+	return delivered, failed, nil
+}
+
+func (j *WebhookJob) send(ctx context.Context, d *models.WebhookDelivery) (int, error) {
+	if j.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.cfg.Timeout)
+		defer cancel()
+	}
+	return webhooks.Send(ctx, j.client, d.URL, d.Secret, d.EventID, d.EventType, d.Payload)
+}
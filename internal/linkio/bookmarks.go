@@ -0,0 +1,185 @@
+package linkio
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+func init() {
+	RegisterImporter("bookmarks", bookmarksImporter{})
+}
+
+const maxSlugLength = 50
+
+// bookmarksImporter reads browser bookmark exports in the Netscape bookmark
+// file format. The folders a bookmark sits in become its tags, apart from
+// the browser's own toolbar and "other bookmarks" folders. Aliases come from
+// Firefox keywords where set, and from the bookmark title otherwise.
+// Bookmarklets and other non-web URLs are skipped.
+type bookmarksImporter struct{}
+
+func (bookmarksImporter) Parse(r io.Reader) ([]Record, error) {
+	z := html.NewTokenizer(r)
+
+	var (
+		records []Record
+		folders []string // one entry per open <DL>; "" for untagged folders
+		pending *string  // heading of the folder whose <DL> comes next
+		current *Record  // bookmark whose title or description is being read
+		inTitle bool
+		inDesc  bool
+		heading strings.Builder
+		inHead  bool
+		skipDir bool
+		count   int
+	)
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return nil, fmt.Errorf("failed to parse bookmarks: %w", err)
+			}
+			if count == 0 {
+				return nil, fmt.Errorf("no bookmarks found")
+			}
+			return records, nil
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			attrs := tagAttrs(z, hasAttr)
+			inDesc = false
+
+			switch string(name) {
+			case "h3":
+				inHead = true
+				heading.Reset()
+				current = nil
+				skipDir = attrs["personal_toolbar_folder"] == "true" || attrs["unfiled_bookmarks_folder"] == "true"
+			case "dl":
+				folder := ""
+				if pending != nil {
+					folder = *pending
+				}
+				folders = append(folders, folder)
+				pending = nil
+			case "a":
+				count++
+				href := strings.TrimSpace(attrs["href"])
+				if !isWebURL(href) {
+					continue
+				}
+				records = append(records, Record{
+					Row:            count,
+					Alias:          attrs["shortcuturl"],
+					DestinationURL: href,
+					Tags:           folderTags(folders, attrs["tags"]),
+				})
+				current = &records[len(records)-1]
+				inTitle = true
+			case "dd":
+				inDesc = current != nil
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "h3":
+				inHead = false
+				folder := ""
+				if !skipDir {
+					folder = slugify(heading.String())
+				}
+				pending = &folder
+			case "dl":
+				if len(folders) > 0 {
+					folders = folders[:len(folders)-1]
+				}
+				current = nil
+			case "a":
+				inTitle = false
+				if current != nil && current.Alias == "" {
+					current.Alias = slugify(current.Description)
+				}
+				if current != nil && current.Alias == "" {
+					if u, err := url.Parse(current.DestinationURL); err == nil {
+						current.Alias = slugify(u.Hostname())
+					}
+				}
+			}
+
+		case html.TextToken:
+			text := strings.TrimSpace(string(z.Text()))
+			switch {
+			case inHead:
+				heading.WriteString(text)
+			case inTitle && current != nil:
+				current.Description += text
+			case inDesc && text != "":
+				current.Description = strings.TrimSpace(current.Description + " - " + text)
+				inDesc = false
+			}
+		}
+	}
+}
+
+func tagAttrs(z *html.Tokenizer, more bool) map[string]string {
+	attrs := make(map[string]string)
+	for more {
+		var key, val []byte
+		key, val, more = z.TagAttr()
+		attrs[string(key)] = string(val)
+	}
+	return attrs
+}
+
+func isWebURL(raw string) bool {
+	lower := strings.ToLower(raw)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// folderTags returns the tags for a bookmark: its folders from the outside
+// in, then any tags the browser exported for it.
+func folderTags(folders []string, extra string) []string {
+	var tags []string
+	for _, folder := range folders {
+		if folder != "" {
+			tags = append(tags, folder)
+		}
+	}
+	for _, tag := range strings.Split(extra, ",") {
+		if tag = slugify(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// slugify lowercases s and collapses everything but letters, digits, '.' and
+// '_' into single dashes, so titles and folder names make valid aliases and
+// tags.
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '_':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+		if b.Len() >= maxSlugLength {
+			break
+		}
+	}
+	return strings.Trim(b.String(), "-.")
+}
@@ -0,0 +1,80 @@
+package linkio
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+)
+
+func init() {
+	registerExportFormat("bookmarks", "text/html; charset=utf-8", "html", newBookmarksWriter)
+}
+
+const bookmarksHeader = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file. It will be read and overwritten. DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3>go links</H3>
+    <DL><p>
+`
+
+const bookmarksFooter = `    </DL><p>
+</DL><p>
+`
+
+// bookmarksWriter writes a Netscape bookmark file that browsers can import,
+// with every link in one "go links" folder. Bookmarks point at the go
+// server, carry the alias as their Firefox keyword and their tags in the
+// TAGS attribute.
+type bookmarksWriter struct {
+	w       io.Writer
+	opts    ExportOptions
+	started bool
+}
+
+func newBookmarksWriter(w io.Writer, opts ExportOptions) LinkWriter {
+	return &bookmarksWriter{w: w, opts: opts}
+}
+
+func (bw *bookmarksWriter) Write(link *models.Link) error {
+	if err := bw.start(); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `        <DT><A HREF="%s" ADD_DATE="%d" LAST_MODIFIED="%d" SHORTCUTURL="%s"`,
+		html.EscapeString(bw.opts.ShortURL(link.Alias)), link.CreatedAt.Unix(), link.UpdatedAt.Unix(),
+		html.EscapeString(link.Alias))
+	if len(link.Tags) > 0 {
+		fmt.Fprintf(&b, ` TAGS="%s"`, html.EscapeString(strings.Join(link.Tags, ",")))
+	}
+	fmt.Fprintf(&b, ">go/%s</A>\n", html.EscapeString(link.Alias))
+	if link.Description != "" {
+		fmt.Fprintf(&b, "        <DD>%s\n", html.EscapeString(strings.Join(strings.Fields(link.Description), " ")))
+	}
+
+	_, err := io.WriteString(bw.w, b.String())
+	return err
+}
+
+func (bw *bookmarksWriter) Close() error {
+	if err := bw.start(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(bw.w, bookmarksFooter)
+	return err
+}
+
+func (bw *bookmarksWriter) start() error {
+	if bw.started {
+		return nil
+	}
+	bw.started = true
+	_, err := io.WriteString(bw.w, bookmarksHeader)
+	return err
+}
@@ -0,0 +1,169 @@
+package linkio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+)
+
+// csvColumns is the header of exported CSV files. Imports accept the columns
+// in any order, plus a few common aliases for them.
+var csvColumns = []string{"alias", "destination_url", "description", "tags", "expires_at", "owner_email"}
+
+var csvColumnAliases = map[string]string{
+	"destinationurl": "destination_url",
+	"destination":    "destination_url",
+	"url":            "destination_url",
+	"expiresat":      "expires_at",
+	"owner":          "owner_email",
+	"owneremail":     "owner_email",
+}
+
+func init() {
+	RegisterImporter("csv", csvImporter{})
+	registerExportFormat("csv", "text/csv; charset=utf-8", "csv", newCSVWriter)
+}
+
+// csvImporter reads CSV files with a header row. Tags are separated by
+// commas or semicolons within their cell.
+type csvImporter struct{}
+
+func (csvImporter) Parse(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if canonical, ok := csvColumnAliases[name]; ok {
+			name = canonical
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["alias"]; !ok {
+		return nil, errors.New("CSV header must include an alias column")
+	}
+	if _, ok := columns["destination_url"]; !ok {
+		return nil, errors.New("CSV header must include a destination_url column")
+	}
+
+	var records []Record
+	for row := 2; ; row++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row %d: %w", row, err)
+		}
+
+		get := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(fields) {
+				return unescapeCSVCell(strings.TrimSpace(fields[i]))
+			}
+			return ""
+		}
+
+		record := Record{
+			Row:            row,
+			Alias:          get("alias"),
+			DestinationURL: get("destination_url"),
+			Description:    get("description"),
+			Tags:           splitTags(get("tags")),
+			OwnerEmail:     get("owner_email"),
+		}
+		if raw := get("expires_at"); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid expires_at %q on CSV row %d", raw, row)
+			}
+			record.ExpiresAt = &t
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func splitTags(raw string) []string {
+	return strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ';' })
+}
+
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVWriter(w io.Writer, opts ExportOptions) LinkWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (cw *csvWriter) Write(link *models.Link) error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+
+	expiresAt := ""
+	if link.ExpiresAt != nil {
+		expiresAt = link.ExpiresAt.Format(time.RFC3339)
+	}
+	return cw.w.Write([]string{
+		escapeCSVCell(link.Alias),
+		escapeCSVCell(link.DestinationURL),
+		escapeCSVCell(link.Description),
+		escapeCSVCell(strings.Join(link.Tags, ",")),
+		expiresAt,
+		escapeCSVCell(link.OwnerEmail),
+	})
+}
+
+// formulaPrefixes are the characters that make spreadsheets read a cell as
+// a formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeCSVCell prefixes cells that a spreadsheet would evaluate with a
+// quote, so exported links cannot run formulas when the file is opened.
+func escapeCSVCell(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// unescapeCSVCell undoes escapeCSVCell, so exports import unchanged.
+func unescapeCSVCell(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
+
+func (cw *csvWriter) Close() error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) writeHeader() error {
+	if cw.wroteHeader {
+		return nil
+	}
+	cw.wroteHeader = true
+	return cw.w.Write(csvColumns)
+}
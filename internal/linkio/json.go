@@ -0,0 +1,69 @@
+package linkio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+)
+
+func init() {
+	RegisterImporter("json", jsonImporter{})
+	registerExportFormat("json", "application/json; charset=utf-8", "json", newJSONWriter)
+}
+
+// jsonImporter reads an array of link objects with the same field names as
+// the API.
+type jsonImporter struct{}
+
+func (jsonImporter) Parse(r io.Reader) ([]Record, error) {
+	var records []Record
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	for i := range records {
+		records[i].Row = i + 1
+	}
+	return records, nil
+}
+
+// jsonWriter streams a JSON array, one link at a time.
+type jsonWriter struct {
+	w       io.Writer
+	enc     *json.Encoder
+	started bool
+}
+
+func newJSONWriter(w io.Writer, opts ExportOptions) LinkWriter {
+	return &jsonWriter{w: w, enc: json.NewEncoder(w)}
+}
+
+func (jw *jsonWriter) Write(link *models.Link) error {
+	sep := ","
+	if !jw.started {
+		sep = "["
+		jw.started = true
+	}
+	if _, err := io.WriteString(jw.w, sep); err != nil {
+		return err
+	}
+
+	return jw.enc.Encode(Record{
+		Alias:          link.Alias,
+		DestinationURL: link.DestinationURL,
+		Description:    link.Description,
+		Tags:           link.Tags,
+		ExpiresAt:      link.ExpiresAt,
+		OwnerEmail:     link.OwnerEmail,
+	})
+}
+
+func (jw *jsonWriter) Close() error {
+	end := "]\n"
+	if !jw.started {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(jw.w, end)
+	return err
+}
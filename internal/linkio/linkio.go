@@ -0,0 +1,108 @@
+// Package linkio reads and writes links in the file formats used to move
+// them in and out of the go server.
+package linkio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+)
+
+var ErrUnknownFormat = errors.New("unknown format")
+
+// Record is one link read from an import file. Row is its position in the
+// file, for reporting; fields an importer cannot fill are left empty.
+type Record struct {
+	Row            int        `json:"row,omitempty"`
+	Alias          string     `json:"alias"`
+	DestinationURL string     `json:"destinationUrl"`
+	Description    string     `json:"description,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	OwnerEmail     string     `json:"ownerEmail,omitempty"`
+}
+
+// Importer parses one import format into records.
+type Importer interface {
+	Parse(r io.Reader) ([]Record, error)
+}
+
+// LinkWriter writes links to an export file one at a time, so exports never
+// hold every link in memory. Close finishes the file.
+type LinkWriter interface {
+	Write(link *models.Link) error
+	Close() error
+}
+
+// ExportOptions configure export formats that link back to the go server.
+type ExportOptions struct {
+	BaseURL string // public URL of the go server
+}
+
+// ShortURL returns the go link for alias on the public go server.
+func (o ExportOptions) ShortURL(alias string) string {
+	return ShortURL(o.BaseURL, alias)
+}
+
+// ShortURL returns the go link for alias on the go server at baseURL. Each
+// segment of the alias is escaped, keeping the slashes between them.
+func ShortURL(baseURL, alias string) string {
+	segments := strings.Split(alias, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimRight(baseURL, "/") + "/go/" + strings.Join(segments, "/")
+}
+
+type exportFormat struct {
+	contentType string
+	extension   string
+	newWriter   func(w io.Writer, opts ExportOptions) LinkWriter
+}
+
+var importers = map[string]Importer{}
+var exportFormats = map[string]exportFormat{}
+
+// RegisterImporter makes an import format available under name.
+func RegisterImporter(name string, importer Importer) {
+	importers[name] = importer
+}
+
+func registerExportFormat(name, contentType, extension string, newWriter func(io.Writer, ExportOptions) LinkWriter) {
+	exportFormats[name] = exportFormat{contentType: contentType, extension: extension, newWriter: newWriter}
+}
+
+// LookupImporter returns the importer registered under name.
+func LookupImporter(name string) (Importer, error) {
+	importer, ok := importers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, name)
+	}
+	return importer, nil
+}
+
+// ImportFormats lists the registered import formats.
+func ImportFormats() []string {
+	names := make([]string, 0, len(importers))
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewWriter starts an export in the named format and returns the writer with
+// the content type and file extension of the format.
+func NewWriter(format string, w io.Writer, opts ExportOptions) (LinkWriter, string, string, error) {
+	f, ok := exportFormats[format]
+	if !ok {
+		return nil, "", "", fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+	return f.newWriter(w, opts), f.contentType, f.extension, nil
+}
@@ -0,0 +1,61 @@
+package linkio
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+)
+
+func init() {
+	registerExportFormat("markdown", "text/markdown; charset=utf-8", "md", newMarkdownWriter)
+}
+
+// markdownWriter writes a Markdown table of go links for READMEs and wiki
+// pages. Links point at the go server rather than their destinations, so the
+// table stays right when destinations change.
+type markdownWriter struct {
+	w       io.Writer
+	opts    ExportOptions
+	started bool
+}
+
+func newMarkdownWriter(w io.Writer, opts ExportOptions) LinkWriter {
+	return &markdownWriter{w: w, opts: opts}
+}
+
+func (mw *markdownWriter) Write(link *models.Link) error {
+	if err := mw.start(); err != nil {
+		return err
+	}
+
+	tags := make([]string, len(link.Tags))
+	for i, tag := range link.Tags {
+		tags[i] = "`" + tag + "`"
+	}
+	_, err := fmt.Fprintf(mw.w, "| [go/%s](%s) | %s | %s |\n",
+		markdownCell(link.Alias), mw.opts.ShortURL(link.Alias),
+		markdownCell(link.Description), strings.Join(tags, " "))
+	return err
+}
+
+func (mw *markdownWriter) Close() error {
+	return mw.start()
+}
+
+func (mw *markdownWriter) start() error {
+	if mw.started {
+		return nil
+	}
+	mw.started = true
+	_, err := io.WriteString(mw.w, "| Link | Description | Tags |\n| --- | --- | --- |\n")
+	return err
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "[", `\[`, "]", `\]`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;")
+
+// markdownCell escapes text for a table cell, which must stay on one line.
+func markdownCell(s string) string {
+	return markdownEscaper.Replace(strings.Join(strings.Fields(s), " "))
+}
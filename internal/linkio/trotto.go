@@ -0,0 +1,62 @@
+package linkio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func init() {
+	RegisterImporter("trotto", trottoImporter{})
+}
+
+// trottoLink is a link as Trotto's export and /_/api/links return it.
+type trottoLink struct {
+	Shortpath      string `json:"shortpath"`
+	DestinationURL string `json:"destination_url"`
+	Owner          string `json:"owner"`
+	Namespace      string `json:"namespace"`
+}
+
+// trottoImporter reads Trotto link exports: an array of links, or an object
+// holding one under "links". Namespaces other than the default "go" become
+// tags, and Trotto's %s placeholders carry over as they are.
+type trottoImporter struct{}
+
+func (trottoImporter) Parse(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Trotto export: %w", err)
+	}
+
+	var links []trottoLink
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var wrapped struct {
+			Links []trottoLink `json:"links"`
+		}
+		err = json.Unmarshal(trimmed, &wrapped)
+		links = wrapped.Links
+	} else {
+		err = json.Unmarshal(trimmed, &links)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Trotto export: %w", err)
+	}
+
+	records := make([]Record, 0, len(links))
+	for i, link := range links {
+		record := Record{
+			Row:            i + 1,
+			Alias:          strings.TrimPrefix(link.Shortpath, "/"),
+			DestinationURL: link.DestinationURL,
+			OwnerEmail:     link.Owner,
+		}
+		if ns := strings.TrimSpace(link.Namespace); ns != "" && ns != "go" {
+			record.Tags = []string{ns}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
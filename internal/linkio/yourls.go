@@ -0,0 +1,290 @@
+package linkio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	RegisterImporter("yourls-sql", yourlsSQLImporter{})
+	RegisterImporter("yourls-csv", yourlsCSVImporter{})
+}
+
+// yourlsColumns is the column order of the YOURLS url table, used when a
+// dump leaves the column list out.
+var yourlsColumns = []string{"keyword", "url", "title", "timestamp", "ip", "clicks"}
+
+// yourlsRecord maps one row of the YOURLS url table. YOURLS has no owners,
+// so every link goes to the importing user.
+func yourlsRecord(row int, get func(column string) string) Record {
+	return Record{
+		Row:            row,
+		Alias:          get("keyword"),
+		DestinationURL: get("url"),
+		Description:    get("title"),
+	}
+}
+
+// yourlsCSVImporter reads CSV exports of the YOURLS url table, with or
+// without a header row.
+type yourlsCSVImporter struct{}
+
+func (yourlsCSVImporter) Parse(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	columns := make(map[string]int, len(yourlsColumns))
+	for i, name := range yourlsColumns {
+		columns[name] = i
+	}
+
+	var records []Record
+	for row := 1; ; row++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row %d: %w", row, err)
+		}
+
+		if row == 1 && len(fields) > 0 && strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(fields[0], "\ufeff")), "keyword") {
+			columns = make(map[string]int, len(fields))
+			for i, name := range fields {
+				columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+			}
+			continue
+		}
+
+		records = append(records, yourlsRecord(row, func(column string) string {
+			if i, ok := columns[column]; ok && i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}))
+	}
+	return records, nil
+}
+
+var insertPattern = regexp.MustCompile("(?i)\\bINSERT\\s+(?:IGNORE\\s+)?INTO\\s+")
+
+// yourlsSQLImporter reads the INSERT statements for the url table out of a
+// mysqldump of a YOURLS database. Statements for other tables are skipped.
+type yourlsSQLImporter struct{}
+
+func (yourlsSQLImporter) Parse(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SQL dump: %w", err)
+	}
+
+	sc := &sqlScanner{s: string(data)}
+	var records []Record
+	for {
+		loc := insertPattern.FindStringIndex(sc.s[sc.pos:])
+		if loc == nil {
+			break
+		}
+		sc.pos += loc[1]
+
+		table := sc.ident()
+		if table == "" {
+			return nil, sc.errorf("expected a table name")
+		}
+		if !strings.HasSuffix(strings.ToLower(table), "url") {
+			continue
+		}
+
+		columns := yourlsColumns
+		sc.skipSpace()
+		if sc.consume('(') {
+			columns = nil
+			for {
+				name := sc.ident()
+				if name == "" {
+					return nil, sc.errorf("expected a column name")
+				}
+				columns = append(columns, strings.ToLower(name))
+				sc.skipSpace()
+				if sc.consume(')') {
+					break
+				}
+				if !sc.consume(',') {
+					return nil, sc.errorf("expected ',' or ')' in column list")
+				}
+			}
+		}
+		if !strings.EqualFold(sc.ident(), "VALUES") {
+			return nil, sc.errorf("expected VALUES")
+		}
+
+		for {
+			sc.skipSpace()
+			if !sc.consume('(') {
+				return nil, sc.errorf("expected '('")
+			}
+			values, err := sc.tuple()
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, yourlsRecord(len(records)+1, func(column string) string {
+				for i, name := range columns {
+					if name == column && i < len(values) {
+						return strings.TrimSpace(values[i])
+					}
+				}
+				return ""
+			}))
+
+			sc.skipSpace()
+			if !sc.consume(',') {
+				break
+			}
+		}
+	}
+
+	if len(records) == 0 {
+		return nil, errors.New("SQL dump has no rows for the YOURLS url table")
+	}
+	return records, nil
+}
+
+// sqlScanner reads the bits of MySQL syntax that INSERT statements in a
+// dump are made of.
+type sqlScanner struct {
+	s   string
+	pos int
+}
+
+func (sc *sqlScanner) errorf(format string, args ...interface{}) error {
+	line := strings.Count(sc.s[:sc.pos], "\n") + 1
+	return fmt.Errorf("invalid SQL on line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (sc *sqlScanner) skipSpace() {
+	for sc.pos < len(sc.s) {
+		switch {
+		case strings.ContainsRune(" \t\r\n", rune(sc.s[sc.pos])):
+			sc.pos++
+		case strings.HasPrefix(sc.s[sc.pos:], "/*"):
+			end := strings.Index(sc.s[sc.pos+2:], "*/")
+			if end < 0 {
+				sc.pos = len(sc.s)
+				return
+			}
+			sc.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+func (sc *sqlScanner) consume(c byte) bool {
+	if sc.pos < len(sc.s) && sc.s[sc.pos] == c {
+		sc.pos++
+		return true
+	}
+	return false
+}
+
+// ident reads a bare or quoted identifier, dropping any database prefix.
+func (sc *sqlScanner) ident() string {
+	sc.skipSpace()
+	var name string
+	for {
+		if sc.pos < len(sc.s) && (sc.s[sc.pos] == '`' || sc.s[sc.pos] == '"') {
+			quote := sc.s[sc.pos]
+			end := strings.IndexByte(sc.s[sc.pos+1:], quote)
+			if end < 0 {
+				return ""
+			}
+			name = sc.s[sc.pos+1 : sc.pos+1+end]
+			sc.pos += end + 2
+		} else {
+			start := sc.pos
+			for sc.pos < len(sc.s) && isIdentByte(sc.s[sc.pos]) {
+				sc.pos++
+			}
+			name = sc.s[start:sc.pos]
+		}
+		if name == "" || !sc.consume('.') {
+			return name
+		}
+	}
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// tuple reads the values of one row, after its opening parenthesis. NULL
+// reads as an empty string.
+func (sc *sqlScanner) tuple() ([]string, error) {
+	var values []string
+	for {
+		sc.skipSpace()
+		if sc.pos >= len(sc.s) {
+			return nil, sc.errorf("unterminated row")
+		}
+
+		if sc.s[sc.pos] == '\'' {
+			value, err := sc.quoted()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		} else {
+			start := sc.pos
+			for sc.pos < len(sc.s) && !strings.ContainsRune(",) \t\r\n", rune(sc.s[sc.pos])) {
+				sc.pos++
+			}
+			value := sc.s[start:sc.pos]
+			if strings.EqualFold(value, "NULL") {
+				value = ""
+			}
+			values = append(values, value)
+		}
+
+		sc.skipSpace()
+		if sc.consume(')') {
+			return values, nil
+		}
+		if !sc.consume(',') {
+			return nil, sc.errorf("expected ',' or ')' in row")
+		}
+	}
+}
+
+var sqlEscapes = map[byte]string{
+	'0': "\x00", 'b': "\b", 'n': "\n", 'r': "\r", 't': "\t", 'Z': "\x1a",
+}
+
+// quoted reads a single-quoted MySQL string with backslash escapes.
+func (sc *sqlScanner) quoted() (string, error) {
+	var b strings.Builder
+	for sc.pos++; sc.pos < len(sc.s); sc.pos++ {
+		c := sc.s[sc.pos]
+		switch {
+		case c == '\\' && sc.pos+1 < len(sc.s):
+			sc.pos++
+			if escaped, ok := sqlEscapes[sc.s[sc.pos]]; ok {
+				b.WriteString(escaped)
+			} else {
+				b.WriteByte(sc.s[sc.pos])
+			}
+		case c == '\'' && sc.pos+1 < len(sc.s) && sc.s[sc.pos+1] == '\'':
+			b.WriteByte('\'')
+			sc.pos++
+		case c == '\'':
+			sc.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", sc.errorf("unterminated string")
+}
@@ -8,6 +8,11 @@ import (
 	"github.com/devingoodsell/go-links-free/internal/auth"
 )
 
+// SessionCookie carries the JWT for server-rendered pages and browser
+// navigations, which cannot send an Authorization header. The API proper
+// only accepts the header.
+const SessionCookie = "go_links_session"
+
 type AuthMiddleware struct {
 	jwtManager *auth.JWTManager
 }
@@ -56,3 +61,39 @@ func (m *AuthMiddleware) RequireAdminGin(c *gin.Context) {
 
 	c.Next()
 }
+
+// OptionalAuthGin sets the user claims when a valid token is present but
+// lets anonymous requests through, for public endpoints that behave better
+// when they know the caller.
+func (m *AuthMiddleware) OptionalAuthGin(c *gin.Context) {
+	if claims, err := m.jwtManager.ValidateToken(pageToken(c)); err == nil {
+		c.Set("user", claims)
+	}
+	c.Next()
+}
+
+// AuthenticatePageGin is AuthenticateGin for server-rendered pages: the
+// token may also come from the session cookie.
+func (m *AuthMiddleware) AuthenticatePageGin(c *gin.Context) {
+	claims, err := m.jwtManager.ValidateToken(pageToken(c))
+	if err != nil {
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		c.AbortWithStatus(401)
+		c.Writer.WriteString("sign in to view this page")
+		return
+	}
+
+	c.Set("user", claims)
+	c.Next()
+}
+
+// pageToken returns the bearer token of a request, falling back to the
+// session cookie.
+func pageToken(c *gin.Context) string {
+	tokenParts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(tokenParts) == 2 && tokenParts[0] == "Bearer" {
+		return tokenParts[1]
+	}
+	token, _ := c.Cookie(SessionCookie)
+	return token
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/db"
+)
+
+const (
+	AliasRuleReserved = "reserved"
+	AliasRuleBlocked  = "blocked"
+)
+
+// AliasRule is either a reserved alias (exact match) or a blocked pattern
+// (regular expression) that new aliases are checked against.
+type AliasRule struct {
+	ID        int64     `json:"id"`
+	Kind      string    `json:"kind"`
+	Pattern   string    `json:"pattern"`
+	CreatedBy *int64    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type AliasRuleRepository struct {
+	db *db.DB
+}
+
+func NewAliasRuleRepository(db *db.DB) *AliasRuleRepository {
+	return &AliasRuleRepository{db: db}
+}
+
+func (r *AliasRuleRepository) List(ctx context.Context) ([]*AliasRule, error) {
+	query := `
+		SELECT id, kind, pattern, created_by, created_at
+		FROM alias_rules
+		ORDER BY kind, pattern`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*AliasRule
+	for rows.Next() {
+		rule := &AliasRule{}
+		if err := rows.Scan(&rule.ID, &rule.Kind, &rule.Pattern, &rule.CreatedBy, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func (r *AliasRuleRepository) Create(ctx context.Context, rule *AliasRule) error {
+	query := `
+		INSERT INTO alias_rules (kind, pattern, created_by)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query, rule.Kind, rule.Pattern, rule.CreatedBy).
+		Scan(&rule.ID, &rule.CreatedAt)
+	if isPgDuplicateError(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (r *AliasRuleRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM alias_rules WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
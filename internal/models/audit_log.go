@@ -0,0 +1,193 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/db"
+)
+
+// Audit actions
+const (
+	AuditLinkFlagged     = "link.flagged"
+	AuditLinkAdminEdit   = "link.admin_edit"
+	AuditLinkTransferred = "link.transferred"
+)
+
+// AuditLog records an action taken on a link. TargetUserID is the user the
+// entry is addressed to, usually the link owner; admins can see every entry.
+// ActorID is nil for actions taken by the system.
+type AuditLog struct {
+	ID           int64           `json:"id"`
+	ActorID      *int64          `json:"actorId,omitempty"`
+	Action       string          `json:"action"`
+	LinkID       *int64          `json:"linkId,omitempty"`
+	TargetUserID *int64          `json:"targetUserId,omitempty"`
+	Details      json.RawMessage `json:"details,omitempty"`
+	CreatedAt    time.Time       `json:"createdAt"`
+}
+
+type AuditLogFilter struct {
+	TargetUserID int64 // zero means any user
+	LinkID       int64 // zero means any link
+	Action       string
+	Limit        int
+	Offset       int
+}
+
+type AuditLogRepository struct {
+	db        *db.DB
+	listeners []func(context.Context, *AuditLog)
+}
+
+func NewAuditLogRepository(db *db.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// NewAuditLog builds an entry about a link by actorID addressed to
+// targetUserID; actorID 0 means the system. details is marshalled to JSON.
+func NewAuditLog(action string, actorID, linkID, targetUserID int64, details interface{}) (*AuditLog, error) {
+	entry := &AuditLog{
+		Action:       action,
+		LinkID:       &linkID,
+		TargetUserID: &targetUserID,
+	}
+	if actorID != 0 {
+		entry.ActorID = &actorID
+	}
+	if details != nil {
+		raw, err := json.Marshal(details)
+		if err != nil {
+			return nil, err
+		}
+		entry.Details = raw
+	}
+	return entry, nil
+}
+
+// Create stores an entry. details is marshalled to JSON.
+func (r *AuditLogRepository) Create(ctx context.Context, entry *AuditLog, details interface{}) error {
+	if details != nil {
+		raw, err := json.Marshal(details)
+		if err != nil {
+			return err
+		}
+		entry.Details = raw
+	}
+
+	if err := insertAuditLog(ctx, r.db, entry); err != nil {
+		return err
+	}
+	r.Recorded(ctx, entry)
+	return nil
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// insertAuditLog stores entry through q, which is the transaction of the
+// write the entry describes when there is one, so the write and its audit
+// trail commit or fail together.
+func insertAuditLog(ctx context.Context, q queryRower, entry *AuditLog) error {
+	query := `
+		INSERT INTO audit_logs (actor_id, action, link_id, target_user_id, details)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	var detailsArg interface{}
+	if entry.Details != nil {
+		detailsArg = []byte(entry.Details)
+	}
+
+	return q.QueryRowContext(
+		ctx, query,
+		entry.ActorID,
+		entry.Action,
+		entry.LinkID,
+		entry.TargetUserID,
+		detailsArg,
+	).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+func insertAuditLogs(ctx context.Context, tx *sql.Tx, entries []*AuditLog) error {
+	for _, entry := range entries {
+		if err := insertAuditLog(ctx, tx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddListener registers fn to be called with every entry after it is
+// stored, on the goroutine that created it. Register listeners before the
+// server starts handling requests.
+func (r *AuditLogRepository) AddListener(fn func(context.Context, *AuditLog)) {
+	r.listeners = append(r.listeners, fn)
+}
+
+// Recorded runs the listeners for entries once they are stored. Entries
+// that LinkRepository stores along with a write are passed here by the
+// caller after the write has committed.
+func (r *AuditLogRepository) Recorded(ctx context.Context, entries ...*AuditLog) {
+	for _, entry := range entries {
+		for _, fn := range r.listeners {
+			fn(ctx, entry)
+		}
+	}
+}
+
+func (r *AuditLogRepository) List(ctx context.Context, filter AuditLogFilter) ([]*AuditLog, error) {
+	query := `
+		SELECT id, actor_id, action, link_id, target_user_id, details, created_at
+		FROM audit_logs
+		WHERE 1=1`
+
+	var args []interface{}
+	if filter.TargetUserID != 0 {
+		args = append(args, filter.TargetUserID)
+		query += fmt.Sprintf(` AND target_user_id = $%d`, len(args))
+	}
+	if filter.LinkID != 0 {
+		args = append(args, filter.LinkID)
+		query += fmt.Sprintf(` AND link_id = $%d`, len(args))
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		query += fmt.Sprintf(` AND action = $%d`, len(args))
+	}
+
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 50
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*AuditLog
+	for rows.Next() {
+		entry := &AuditLog{}
+		var details []byte
+		err := rows.Scan(
+			&entry.ID, &entry.ActorID, &entry.Action, &entry.LinkID,
+			&entry.TargetUserID, &details, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if details != nil {
+			entry.Details = details
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -1,9 +1,16 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrUnauthorized = errors.New("unauthorized")
 	ErrNotFound     = errors.New("not found")
 	ErrDuplicate    = errors.New("duplicate entry")
+
+	// ErrUserNotFound keeps the message callers have always seen while
+	// matching ErrNotFound with errors.Is.
+	ErrUserNotFound = fmt.Errorf("user %w", ErrNotFound)
 )
@@ -15,12 +15,40 @@ type Link struct {
 	ID             int64      `json:"id"`
 	Alias          string     `json:"alias"`
 	DestinationURL string     `json:"destinationUrl"`
+	Description    string     `json:"description"`
+	Tags           []string   `json:"tags"`
 	CreatedBy      int64      `json:"createdBy"`
+	OwnerEmail     string     `json:"ownerEmail,omitempty"`
 	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
 	CreatedAt      time.Time  `json:"createdAt"`
 	UpdatedAt      time.Time  `json:"updatedAt"`
 	IsActive       bool       `json:"isActive"`
+	Visibility     string     `json:"visibility"`
+	FlaggedAt      *time.Time `json:"flaggedAt,omitempty"`
+	FlagReason     string     `json:"flagReason,omitempty"`
+	MergedInto     *int64     `json:"mergedInto,omitempty"` // ID of the link this one forwards to
 	Stats          *LinkStats `json:"stats,omitempty"`
+
+	// Health is the latest destination check, loaded only by the listings
+	// that show it.
+	Health *LinkHealth `json:"health,omitempty"`
+}
+
+// Link visibilities. Private links still redirect for anyone with the alias
+// but are hidden from the directory, search and suggestions of other users.
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+)
+
+// ValidVisibility reports whether v is a known visibility.
+func ValidVisibility(v string) bool {
+	return v == VisibilityPublic || v == VisibilityPrivate
+}
+
+// IsExpired reports whether the link's expiry time has passed.
+func (l *Link) IsExpired() bool {
+	return l.ExpiresAt != nil && l.ExpiresAt.Before(time.Now())
 }
 
 type ListOptions struct {
@@ -32,4 +60,20 @@ type ListOptions struct {
 	Offset   int    `json:"offset"`
 	Page     int    `json:"page"`
 	PageSize int    `json:"page_size"`
+
+	Tags     []string `json:"tags,omitempty"`
+	TagMatch string   `json:"tag_match,omitempty"` // TagMatchAny (default) or TagMatchAll
+
+	OwnerID        int64       `json:"owner_id,omitempty"` // zero lists every user's links
+	OwnerEmail     string      `json:"owner_email,omitempty"`
+	Namespace      string      `json:"namespace,omitempty"` // alias prefix before a '/', like "eng" in eng/oncall
+	Visibility     string      `json:"visibility,omitempty"`
+	DestPrefix     string      `json:"dest_prefix,omitempty"`
+	CreatedAfter   *time.Time  `json:"created_after,omitempty"`
+	CreatedBefore  *time.Time  `json:"created_before,omitempty"`
+	AccessedAfter  *time.Time  `json:"accessed_after,omitempty"`
+	AccessedBefore *time.Time  `json:"accessed_before,omitempty"`
+	ZeroClicks     bool        `json:"zero_clicks,omitempty"`
+	After          *ListCursor `json:"-"` // page forward from this position
+	Before         *ListCursor `json:"-"` // page backward from this position
 }
@@ -0,0 +1,436 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Bulk actions.
+const (
+	BulkActionDelete     = "delete"
+	BulkActionActivate   = "activate"
+	BulkActionDeactivate = "deactivate"
+	BulkActionSetExpiry  = "set_expiry"
+	BulkActionAddTag     = "add_tag"
+	BulkActionTransfer   = "transfer_owner"
+)
+
+// Per-link bulk outcomes.
+const (
+	BulkOK        = "ok"
+	BulkNotFound  = "not_found"
+	BulkForbidden = "forbidden"
+	BulkFailed    = "failed"
+	BulkSkipped   = "skipped" // not applied because an atomic batch was abandoned
+)
+
+// MaxBulkLinks caps how many links one bulk operation may touch.
+const MaxBulkLinks = 1000
+
+var ErrBulkTooLarge = fmt.Errorf("a bulk operation may touch at most %d links", MaxBulkLinks)
+
+// BulkAction is what to do to each link. ExpiresAt (nil clears the expiry),
+// Tag and NewOwnerID are read by the actions they belong to.
+type BulkAction struct {
+	Type       string
+	ExpiresAt  *time.Time
+	Tag        string
+	NewOwnerID int64
+}
+
+// BulkOptions select the links of a bulk operation, by IDs or else by
+// Filter. Links the actor does not own are refused unless Admin is set.
+// Atomic operations apply to every link or none; otherwise each link is
+// applied on its own and failures do not stop the rest.
+type BulkOptions struct {
+	IDs     []int64
+	Filter  *ListOptions
+	ActorID int64
+	Admin   bool
+	Atomic  bool
+}
+
+type BulkOutcome struct {
+	ID      int64  `json:"id"`
+	Alias   string `json:"alias,omitempty"`
+	OwnerID int64  `json:"-"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	destination string
+	audit       []*AuditLog
+}
+
+type BulkResult struct {
+	Applied bool           `json:"applied"` // false when an atomic batch was abandoned
+	Counts  map[string]int `json:"counts"`
+	Items   []BulkOutcome  `json:"items"`
+
+	// Audit holds the entries stored for changes to other users' links,
+	// for AuditLogRepository.Recorded.
+	Audit []*AuditLog `json:"-"`
+}
+
+// Err describes the first link that was not applied, matching ErrNotFound
+// or ErrUnauthorized where that is the reason.
+func (res *BulkResult) Err() error {
+	for _, item := range res.Items {
+		switch item.Status {
+		case BulkNotFound:
+			return fmt.Errorf("link %d: %w", item.ID, ErrNotFound)
+		case BulkForbidden:
+			return fmt.Errorf("link %d: %w", item.ID, ErrUnauthorized)
+		case BulkFailed:
+			return fmt.Errorf("link %d: %s", item.ID, item.Message)
+		}
+	}
+	return nil
+}
+
+// BulkApply runs action on the selected links and reports the outcome for
+// each of them.
+func (r *LinkRepository) BulkApply(ctx context.Context, opts BulkOptions, action BulkAction) (*BulkResult, error) {
+	switch action.Type {
+	case BulkActionDelete, BulkActionActivate, BulkActionDeactivate, BulkActionSetExpiry:
+	case BulkActionAddTag:
+		if action.Tag == "" {
+			return nil, errors.New("add_tag needs a tag")
+		}
+	case BulkActionTransfer:
+		if action.NewOwnerID == 0 {
+			return nil, errors.New("transfer_owner needs a new owner")
+		}
+	default:
+		return nil, fmt.Errorf("unknown bulk action %q", action.Type)
+	}
+
+	items, err := r.bulkTargets(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkResult{Counts: make(map[string]int), Items: items}
+	var applied []int64
+	if opts.Atomic {
+		applied = r.bulkApplyAtomic(ctx, items, opts, action)
+	} else {
+		for i := range items {
+			if items[i].Status != "" {
+				continue
+			}
+			if err := r.bulkApplyOne(ctx, &items[i], opts, action); err != nil {
+				items[i].Status, items[i].Message = bulkErrorStatus(err), err.Error()
+				continue
+			}
+			items[i].Status = BulkOK
+			applied = append(applied, items[i].ID)
+		}
+	}
+	result.Applied = !opts.Atomic || len(applied) > 0 || len(items) == 0
+
+	for _, item := range items {
+		result.Counts[item.Status]++
+	}
+	if len(applied) > 0 {
+		var done []BulkOutcome
+		for _, item := range items {
+			if item.Status == BulkOK {
+				done = append(done, item)
+				result.Audit = append(result.Audit, item.audit...)
+			}
+		}
+		if action.Type == BulkActionDelete {
+			r.notifyDeleted(removedLinks(done))
+		} else {
+			r.notifyChange(LinkChangeUpdated, applied...)
+		}
+	}
+	return result, nil
+}
+
+// removedLinks is what is known of deleted links for their change.
+func removedLinks(items []BulkOutcome) []*Link {
+	removed := make([]*Link, len(items))
+	for i, item := range items {
+		removed[i] = &Link{ID: item.ID, Alias: item.Alias, DestinationURL: item.destination, CreatedBy: item.OwnerID}
+	}
+	return removed
+}
+
+// recordBulkChange records the change made by applying action to items
+// within tx.
+func recordBulkChange(ctx context.Context, tx *sql.Tx, items []BulkOutcome, action BulkAction) error {
+	if action.Type == BulkActionDelete {
+		return recordDeleted(ctx, tx, removedLinks(items))
+	}
+	ids := make([]int64, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return recordChange(ctx, tx, LinkChangeUpdated, ids...)
+}
+
+// bulkTargets looks up the selected links in one query. Links that cannot
+// be applied already carry their outcome; the rest have an empty status.
+func (r *LinkRepository) bulkTargets(ctx context.Context, opts BulkOptions) ([]BulkOutcome, error) {
+	var rows *sql.Rows
+	var err error
+	if opts.IDs != nil {
+		if len(opts.IDs) > MaxBulkLinks {
+			return nil, ErrBulkTooLarge
+		}
+		rows, err = r.db.QueryContext(ctx,
+			`SELECT id, alias, created_by, destination_url FROM links WHERE id = ANY($1)`, pq.Array(opts.IDs))
+	} else {
+		if opts.Filter == nil {
+			return nil, errors.New("bulk operations need link IDs or a filter")
+		}
+		filter := *opts.Filter
+		if !opts.Admin {
+			filter.OwnerID = opts.ActorID
+		}
+		query, args := appendLinkFilters(`
+			SELECT l.id, l.alias, l.created_by, l.destination_url
+			FROM `+linkTables+`
+			WHERE TRUE`, nil, filter)
+		args = append(args, MaxBulkLinks+1)
+		query += fmt.Sprintf(` ORDER BY l.id LIMIT $%d`, len(args))
+		rows, err = r.db.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[int64]BulkOutcome)
+	var order []int64
+	for rows.Next() {
+		var item BulkOutcome
+		if err := rows.Scan(&item.ID, &item.Alias, &item.OwnerID, &item.destination); err != nil {
+			return nil, err
+		}
+		found[item.ID] = item
+		order = append(order, item.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(order) > MaxBulkLinks {
+		return nil, ErrBulkTooLarge
+	}
+
+	// Report ID selections in the order they were asked for, once each.
+	if opts.IDs != nil {
+		order = order[:0]
+		seen := make(map[int64]bool, len(opts.IDs))
+		for _, id := range opts.IDs {
+			if !seen[id] {
+				seen[id] = true
+				order = append(order, id)
+			}
+		}
+	}
+
+	items := make([]BulkOutcome, 0, len(order))
+	for _, id := range order {
+		item, ok := found[id]
+		switch {
+		case !ok:
+			item = BulkOutcome{ID: id, Status: BulkNotFound}
+		case !opts.Admin && item.OwnerID != opts.ActorID:
+			item.Status = BulkForbidden
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// bulkApplyAtomic applies action to every pending link in one transaction,
+// or to none of them if any link cannot be applied. It returns the IDs it
+// applied.
+func (r *LinkRepository) bulkApplyAtomic(ctx context.Context, items []BulkOutcome, opts BulkOptions, action BulkAction) []int64 {
+	abandon := func(failed int, err error) []int64 {
+		for i := range items {
+			switch {
+			case i == failed:
+				items[i].Status, items[i].Message = bulkErrorStatus(err), err.Error()
+			case items[i].Status == "" || items[i].Status == BulkOK:
+				items[i].Status, items[i].Message = BulkSkipped, ""
+			}
+		}
+		return nil
+	}
+
+	for _, item := range items {
+		if item.Status != "" {
+			return abandon(-1, nil)
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return abandon(-1, nil)
+	}
+	defer tx.Rollback()
+
+	ids := make([]int64, 0, len(items))
+	for i := range items {
+		if err := applyBulkAction(ctx, tx, &items[i], opts, action); err != nil {
+			return abandon(i, err)
+		}
+		items[i].Status = BulkOK
+		ids = append(ids, items[i].ID)
+	}
+	if err := recordBulkChange(ctx, tx, items, action); err != nil {
+		return abandon(-1, nil)
+	}
+	if err := tx.Commit(); err != nil {
+		return abandon(-1, nil)
+	}
+	return ids
+}
+
+func (r *LinkRepository) bulkApplyOne(ctx context.Context, item *BulkOutcome, opts BulkOptions, action BulkAction) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := applyBulkAction(ctx, tx, item, opts, action); err != nil {
+		return err
+	}
+	if err := recordBulkChange(ctx, tx, []BulkOutcome{*item}, action); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// bulkErrorStatus is the outcome of a link whose action failed with err.
+func bulkErrorStatus(err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return BulkNotFound
+	case errors.Is(err, ErrUnauthorized):
+		return BulkForbidden
+	}
+	return BulkFailed
+}
+
+// applyBulkAction applies action to one link within tx and audits it. The
+// link is locked and its owner checked again first, as it may have changed
+// hands since the batch was selected. Flagged links stay inactive unless an
+// admin activates them.
+func applyBulkAction(ctx context.Context, tx *sql.Tx, item *BulkOutcome, opts BulkOptions, action BulkAction) error {
+	var ownerID int64
+	var flagged bool
+	err := tx.QueryRowContext(ctx,
+		`SELECT created_by, flagged_at IS NOT NULL FROM links WHERE id = $1 FOR UPDATE`, item.ID).Scan(&ownerID, &flagged)
+	switch {
+	case err == sql.ErrNoRows:
+		return fmt.Errorf("link %d: %w", item.ID, ErrNotFound)
+	case err != nil:
+		return err
+	case !opts.Admin && ownerID != opts.ActorID:
+		return fmt.Errorf("link %d: %w", item.ID, ErrUnauthorized)
+	case !opts.Admin && flagged && action.Type == BulkActionActivate:
+		return fmt.Errorf("link %d is flagged and only an admin can activate it: %w", item.ID, ErrUnauthorized)
+	}
+	item.OwnerID = ownerID
+
+	var result sql.Result
+	switch action.Type {
+	case BulkActionDelete:
+		// link_stats has no ON DELETE CASCADE
+		if _, err = tx.ExecContext(ctx, `DELETE FROM link_stats WHERE link_id = $1`, item.ID); err == nil {
+			result, err = tx.ExecContext(ctx, `DELETE FROM links WHERE id = $1`, item.ID)
+		}
+	case BulkActionActivate, BulkActionDeactivate:
+		result, err = tx.ExecContext(ctx, `UPDATE links SET is_active = $2, updated_at = NOW() WHERE id = $1`,
+			item.ID, action.Type == BulkActionActivate)
+	case BulkActionSetExpiry:
+		result, err = tx.ExecContext(ctx, `UPDATE links SET expires_at = $2, updated_at = NOW() WHERE id = $1`,
+			item.ID, action.ExpiresAt)
+	case BulkActionAddTag:
+		err = addLinkTag(ctx, tx, item.ID, action.Tag)
+	case BulkActionTransfer:
+		result, err = tx.ExecContext(ctx, `UPDATE links SET created_by = $2, updated_at = NOW() WHERE id = $1`,
+			item.ID, action.NewOwnerID)
+	}
+	if err != nil {
+		return err
+	}
+	if result != nil {
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if rowsAffected == 0 {
+			return fmt.Errorf("link %d: %w", item.ID, ErrNotFound)
+		}
+	}
+	return auditBulkAction(ctx, tx, item, opts, action)
+}
+
+// auditBulkAction stores the audit entries for applying action to another
+// user's link within tx: an admin edit addressed to its owner, and for
+// transfers one addressed to its new owner.
+func auditBulkAction(ctx context.Context, tx *sql.Tx, item *BulkOutcome, opts BulkOptions, action BulkAction) error {
+	item.audit = nil
+	if item.OwnerID != opts.ActorID {
+		entry, err := NewAuditLog(AuditLinkAdminEdit, opts.ActorID, item.ID, item.OwnerID,
+			map[string]interface{}{"alias": item.Alias, "bulkAction": action.Type})
+		if err != nil {
+			return err
+		}
+		item.audit = append(item.audit, entry)
+	}
+	if action.Type == BulkActionTransfer && action.NewOwnerID != item.OwnerID {
+		entry, err := NewAuditLog(AuditLinkTransferred, opts.ActorID, item.ID, action.NewOwnerID,
+			map[string]interface{}{"alias": item.Alias, "bulkAction": action.Type, "fromUserId": item.OwnerID})
+		if err != nil {
+			return err
+		}
+		item.audit = append(item.audit, entry)
+	}
+	return insertAuditLogs(ctx, tx, item.audit)
+}
+
+// addLinkTag adds one tag to a link, keeping within MaxTagsPerLink. Adding
+// a tag the link already has is not an error.
+func addLinkTag(ctx context.Context, tx *sql.Tx, linkID int64, tag string) error {
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO tags (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`, tag); err != nil {
+		return err
+	}
+
+	var has bool
+	var count int
+	err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(BOOL_OR(t.name = $2), false), COUNT(*)
+		FROM link_tags lt
+		JOIN tags t ON t.id = lt.tag_id
+		WHERE lt.link_id = $1`,
+		linkID, tag).Scan(&has, &count)
+	switch {
+	case err != nil:
+		return err
+	case has:
+		return nil
+	case count >= MaxTagsPerLink:
+		return fmt.Errorf("a link can have at most %d tags", MaxTagsPerLink)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO link_tags (link_id, tag_id)
+		SELECT $1, id FROM tags WHERE name = $2`,
+		linkID, tag)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE links SET updated_at = NOW() WHERE id = $1`, linkID)
+	return err
+}
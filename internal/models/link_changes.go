@@ -0,0 +1,118 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	LinkChangeCreated = "created"
+	LinkChangeUpdated = "updated"
+	LinkChangeDeleted = "deleted"
+)
+
+// LinkChange describes a write to the links table.
+type LinkChange struct {
+	ID        int64 // set for changes read back from the outbox
+	Type      string
+	LinkIDs   []int64
+	CreatedAt time.Time
+
+	// Removed holds what is known of deleted links, which can no longer be
+	// loaded: their ID, alias, destination and owner.
+	Removed []*Link
+}
+
+// Every write to links also records its change in the link_changes outbox,
+// within the transaction of the write, so a committed write is never lost
+// to its consumers even if the server stops right after it. The webhook
+// job reads the outbox with PendingChanges.
+
+// recordChange records a create or update of the links with ids within tx.
+func recordChange(ctx context.Context, tx *sql.Tx, changeType string, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO link_changes (change_type, link_ids) VALUES ($1, $2)`,
+		changeType, pq.Array(ids))
+	return err
+}
+
+// recordDeleted records the deletion of links within tx.
+func recordDeleted(ctx context.Context, tx *sql.Tx, links []*Link) error {
+	if len(links) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(links))
+	for i, link := range links {
+		ids[i] = link.ID
+	}
+	removed, err := json.Marshal(links)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO link_changes (change_type, link_ids, removed) VALUES ($1, $2, $3)`,
+		LinkChangeDeleted, pq.Array(ids), removed)
+	return err
+}
+
+// PendingChanges returns up to limit changes from the outbox, oldest first.
+// They stay pending until their consumer removes them.
+func (r *LinkRepository) PendingChanges(ctx context.Context, limit int) ([]*LinkChange, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, change_type, link_ids, removed, created_at
+		FROM link_changes
+		ORDER BY id
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*LinkChange
+	for rows.Next() {
+		change := &LinkChange{}
+		var removed []byte
+		if err := rows.Scan(&change.ID, &change.Type, pq.Array(&change.LinkIDs), &removed, &change.CreatedAt); err != nil {
+			return nil, err
+		}
+		if removed != nil {
+			if err := json.Unmarshal(removed, &change.Removed); err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// AddChangeListener registers fn to be called after every successful write
+// to links. Listeners run synchronously on the writing goroutine and must not
+// block; register them before the server starts handling requests. Work
+// that needs the database belongs with the outbox instead.
+func (r *LinkRepository) AddChangeListener(fn func(LinkChange)) {
+	r.listeners = append(r.listeners, fn)
+}
+
+func (r *LinkRepository) notifyChange(changeType string, ids ...int64) {
+	change := LinkChange{Type: changeType, LinkIDs: ids}
+	for _, fn := range r.listeners {
+		fn(change)
+	}
+}
+
+func (r *LinkRepository) notifyDeleted(links []*Link) {
+	change := LinkChange{Type: LinkChangeDeleted, Removed: links}
+	for _, link := range links {
+		change.LinkIDs = append(change.LinkIDs, link.ID)
+	}
+	for _, fn := range r.listeners {
+		fn(change)
+	}
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Time-based link events are not caused by a write, so they are found by
+// polling. link_events_emitted remembers which were already emitted.
+
+// ThresholdCrossing is a link whose clicks reached a threshold.
+type ThresholdCrossing struct {
+	Link      *Link
+	Threshold int
+}
+
+// ListNewlyExpired returns links that expired after since and have not had
+// their link.expired event yet.
+func (r *LinkRepository) ListNewlyExpired(ctx context.Context, since time.Time, limit int) ([]*Link, error) {
+	query := `
+		SELECT ` + linkColumns + `
+		FROM ` + linkTables + `
+		WHERE l.expires_at > $1 AND l.expires_at <= NOW()
+			AND NOT EXISTS (
+				SELECT 1 FROM link_events_emitted e
+				WHERE e.link_id = l.id AND e.event_type = '` + WebhookLinkExpired + `'
+					AND e.event_key = ` + expiryEventKey + `)
+		ORDER BY l.expires_at, l.id
+		LIMIT $2`
+
+	return r.queryLinks(ctx, query, since, limit)
+}
+
+// expiryEventKey keys link.expired events by the expiry time in unix
+// seconds, so a link that is given a new expiry can expire again.
+const expiryEventKey = `FLOOR(EXTRACT(EPOCH FROM l.expires_at))::bigint::text`
+
+// ListThresholdsReached returns links used since accessedSince whose total
+// clicks reached one of thresholds without a link.threshold_reached event
+// for it yet.
+func (r *LinkRepository) ListThresholdsReached(ctx context.Context, thresholds []int, accessedSince time.Time, limit int) ([]*ThresholdCrossing, error) {
+	query := `
+		SELECT ` + linkColumns + `, t.threshold
+		FROM ` + linkTables + `
+		JOIN UNNEST($1::int[]) AS t(threshold) ON s.total_count >= t.threshold
+		WHERE s.last_accessed_at >= $2
+			AND NOT EXISTS (
+				SELECT 1 FROM link_events_emitted e
+				WHERE e.link_id = l.id AND e.event_type = '` + WebhookLinkThreshold + `'
+					AND e.event_key = t.threshold::text)
+		ORDER BY l.id, t.threshold
+		LIMIT $3`
+
+	ints := make([]int64, len(thresholds))
+	for i, t := range thresholds {
+		ints[i] = int64(t)
+	}
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ints), accessedSince, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var crossings []*ThresholdCrossing
+	var links []*Link
+	for rows.Next() {
+		c := &ThresholdCrossing{}
+		link, err := scanLink(rows, &c.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		c.Link = link
+		crossings = append(crossings, c)
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadTags(ctx, links); err != nil {
+		return nil, err
+	}
+	return crossings, nil
+}
+
+// MarkEventEmitted records that a time-based event was emitted for a link, so it
+// is not listed again.
+func (r *LinkRepository) MarkEventEmitted(ctx context.Context, linkID int64, eventType, key string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO link_events_emitted (link_id, event_type, event_key)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`, linkID, eventType, key)
+	return err
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+// AddFavorite stars a link for a user, appending it to the end of their
+// favorites. Starring a link twice is a no-op.
+func (r *LinkRepository) AddFavorite(ctx context.Context, userID, linkID int64) error {
+	query := `
+		INSERT INTO user_favorites (user_id, link_id, position)
+		SELECT $1, $2, COALESCE(MAX(position), 0) + 1
+		FROM user_favorites
+		WHERE user_id = $1
+		ON CONFLICT (user_id, link_id) DO NOTHING`
+
+	_, err := r.db.ExecContext(ctx, query, userID, linkID)
+	return err
+}
+
+func (r *LinkRepository) RemoveFavorite(ctx context.Context, userID, linkID int64) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM user_favorites WHERE user_id = $1 AND link_id = $2`, userID, linkID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListFavorites returns a user's starred links in their order. limit <= 0
+// returns all of them. Links other users have made private since they were
+// starred are left out.
+func (r *LinkRepository) ListFavorites(ctx context.Context, userID int64, limit int) ([]*Link, error) {
+	query := `
+		SELECT ` + linkColumns + `
+		FROM ` + linkTables + `
+		JOIN user_favorites f ON f.link_id = l.id
+		WHERE f.user_id = $1 AND (l.visibility = 'public' OR l.created_by = $1)
+		ORDER BY f.position, f.created_at
+		LIMIT NULLIF($2, 0)`
+
+	if limit < 0 {
+		limit = 0
+	}
+	return r.queryLinks(ctx, query, userID, limit)
+}
+
+// CountFavorites returns how many links a user has starred, leaving out
+// the ones ListFavorites leaves out.
+func (r *LinkRepository) CountFavorites(ctx context.Context, userID int64) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM user_favorites f
+		JOIN links l ON l.id = f.link_id
+		WHERE f.user_id = $1 AND (l.visibility = 'public' OR l.created_by = $1)`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&count)
+	return count, err
+}
+
+// ReorderFavorites moves the given links to the front of a user's favorites
+// in the given order; the rest keep their relative order after them.
+func (r *LinkRepository) ReorderFavorites(ctx context.Context, userID int64, linkIDs []int64) error {
+	query := `
+		UPDATE user_favorites
+		SET position = COALESCE(array_position($2::bigint[], link_id::bigint), $3 + position)
+		WHERE user_id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, userID, pq.Array(linkIDs), len(linkIDs))
+	return err
+}
+
+// FavoriteLinkIDs returns the starred link IDs of every user, for ranking.
+func (r *LinkRepository) FavoriteLinkIDs(ctx context.Context) (map[int64][]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT user_id, link_id FROM user_favorites`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	favorites := make(map[int64][]int64)
+	for rows.Next() {
+		var userID, linkID int64
+		if err := rows.Scan(&userID, &linkID); err != nil {
+			return nil, err
+		}
+		favorites[userID] = append(favorites[userID], linkID)
+	}
+	return favorites, rows.Err()
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// LinkHealth is the result of the latest check of a link's destination.
+type LinkHealth struct {
+	LinkID              int64     `json:"-"`
+	DestinationURL      string    `json:"destinationUrl"` // the destination that was checked
+	StatusCode          int       `json:"statusCode,omitempty"`
+	Error               string    `json:"error,omitempty"`
+	LatencyMS           int64     `json:"latencyMs"`
+	Healthy             bool      `json:"healthy"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	CheckedAt           time.Time `json:"checkedAt"`
+}
+
+// ListDueForCheck returns up to limit active links whose destination was
+// never checked, was last checked before checkedBefore, or has changed since.
+// Destinations with parameter placeholders are templates, not pages, and are
+// never due.
+func (r *LinkRepository) ListDueForCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]*Link, error) {
+	query := `
+		SELECT l.id, l.alias, l.destination_url, l.created_by, l.is_active
+		FROM links l
+		LEFT JOIN link_health h ON h.link_id = l.id
+		WHERE l.is_active AND l.merged_into IS NULL
+			AND (l.expires_at IS NULL OR l.expires_at > NOW())
+			AND l.destination_url ~* '^https?://'
+			AND l.destination_url !~ '\{([0-9]+|\*)\}|%s'
+			AND (h.link_id IS NULL OR h.checked_at < $1 OR h.destination_url <> l.destination_url)
+		ORDER BY h.checked_at NULLS FIRST, l.id
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, checkedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []*Link
+	for rows.Next() {
+		link := &Link{}
+		if err := rows.Scan(&link.ID, &link.Alias, &link.DestinationURL, &link.CreatedBy, &link.IsActive); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// RecordHealth stores the result of a check, counting consecutive failures
+// into health.ConsecutiveFailures.
+func (r *LinkRepository) RecordHealth(ctx context.Context, health *LinkHealth) error {
+	query := `
+		INSERT INTO link_health (link_id, destination_url, status_code, error, latency_ms, healthy,
+			consecutive_failures, checked_at)
+		VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, ''), $5, $6, CASE WHEN $6 THEN 0 ELSE 1 END, $7)
+		ON CONFLICT (link_id) DO UPDATE
+		SET destination_url = EXCLUDED.destination_url,
+			status_code = EXCLUDED.status_code,
+			error = EXCLUDED.error,
+			latency_ms = EXCLUDED.latency_ms,
+			healthy = EXCLUDED.healthy,
+			consecutive_failures = CASE WHEN EXCLUDED.healthy THEN 0
+				ELSE link_health.consecutive_failures + 1 END,
+			checked_at = EXCLUDED.checked_at
+		RETURNING consecutive_failures`
+
+	return r.db.QueryRowContext(ctx, query,
+		health.LinkID, health.DestinationURL, health.StatusCode, health.Error,
+		health.LatencyMS, health.Healthy, health.CheckedAt,
+	).Scan(&health.ConsecutiveFailures)
+}
+
+// LoadHealth fills in the Health of every link that has been checked.
+func (r *LinkRepository) LoadHealth(ctx context.Context, links []*Link) error {
+	if len(links) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(links))
+	byID := make(map[int64]*Link, len(links))
+	for i, link := range links {
+		ids[i] = link.ID
+		byID[link.ID] = link
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT link_id, destination_url, COALESCE(status_code, 0), COALESCE(error, ''), latency_ms,
+			healthy, consecutive_failures, checked_at
+		FROM link_health
+		WHERE link_id = ANY($1)`,
+		pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		h := &LinkHealth{}
+		if err := rows.Scan(&h.LinkID, &h.DestinationURL, &h.StatusCode, &h.Error, &h.LatencyMS,
+			&h.Healthy, &h.ConsecutiveFailures, &h.CheckedAt); err != nil {
+			return err
+		}
+		byID[h.LinkID].Health = h
+	}
+	return rows.Err()
+}
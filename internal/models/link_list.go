@@ -0,0 +1,320 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	LinkStatusActive   = "active"
+	LinkStatusExpired  = "expired"
+	LinkStatusInactive = "inactive"
+	LinkStatusFlagged  = "flagged"
+	LinkStatusBroken   = "broken" // failed its latest destination check
+)
+
+// ListCursor is the keyset position of the last link of a page: the value of
+// the sort column, as formatted by the column, and the link ID as tie-breaker.
+type ListCursor struct {
+	Value string `json:"v"`
+	ID    int64  `json:"i"`
+}
+
+// sortColumn is a column links can be ordered by. expr never yields NULL so
+// that keyset comparisons work; cast turns a cursor value back into its type.
+type sortColumn struct {
+	expr  string
+	cast  string
+	value func(*Link) string
+}
+
+var linkSortColumns = map[string]sortColumn{
+	"alias":       {"l.alias", "text", func(l *Link) string { return l.Alias }},
+	"destination": {"l.destination_url", "text", func(l *Link) string { return l.DestinationURL }},
+	"owner":       {"COALESCE(u.email, '')", "text", func(l *Link) string { return l.OwnerEmail }},
+	"created":     {"l.created_at", "timestamptz", func(l *Link) string { return formatCursorTime(&l.CreatedAt) }},
+	"updated":     {"l.updated_at", "timestamptz", func(l *Link) string { return formatCursorTime(&l.UpdatedAt) }},
+	"expires": {"COALESCE(l.expires_at, 'infinity')", "timestamptz", func(l *Link) string {
+		if l.ExpiresAt == nil {
+			return "infinity"
+		}
+		return formatCursorTime(l.ExpiresAt)
+	}},
+	"accessed": {"COALESCE(s.last_accessed_at, '-infinity')", "timestamptz", func(l *Link) string {
+		if l.Stats == nil || l.Stats.LastAccessedAt == nil {
+			return "-infinity"
+		}
+		return formatCursorTime(l.Stats.LastAccessedAt)
+	}},
+	"clicks": {"COALESCE(s.total_count, 0)", "bigint", func(l *Link) string {
+		if l.Stats == nil {
+			return "0"
+		}
+		return strconv.Itoa(l.Stats.TotalCount)
+	}},
+	"active":     {"l.is_active", "boolean", func(l *Link) string { return strconv.FormatBool(l.IsActive) }},
+	"visibility": {"l.visibility", "text", func(l *Link) string { return l.Visibility }},
+}
+
+func formatCursorTime(t *time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseSort splits a sort option such as "clicks_desc" into its column and
+// direction, falling back to newest first.
+func parseSort(sortBy string) (sortColumn, bool) {
+	name, dir := sortBy, "desc"
+	if i := strings.LastIndex(sortBy, "_"); i >= 0 {
+		name, dir = sortBy[:i], sortBy[i+1:]
+	}
+
+	column, ok := linkSortColumns[name]
+	if !ok || (dir != "asc" && dir != "desc") {
+		return linkSortColumns["created"], true
+	}
+	return column, dir == "desc"
+}
+
+// CursorFor returns the cursor pointing just after link in a listing sorted
+// by opts.SortBy.
+func (opts ListOptions) CursorFor(link *Link) *ListCursor {
+	column, _ := parseSort(opts.SortBy)
+	return &ListCursor{Value: column.value(link), ID: link.ID}
+}
+
+// List returns the links matching opts. OwnerID restricts the listing to one
+// user's links; leave it zero only for admin listings across every user.
+// Pages are taken by opts.After or opts.Before when set, otherwise by
+// opts.Offset. Links come back in sort order either way.
+func (r *LinkRepository) List(ctx context.Context, opts ListOptions) ([]*Link, error) {
+	query := `
+		SELECT ` + linkColumns + `
+		FROM ` + linkTables + `
+		WHERE TRUE`
+
+	query, args := appendLinkFilters(query, nil, opts)
+
+	// Paging backward walks the sort order in reverse from the cursor and
+	// flips the page afterwards.
+	column, desc := parseSort(opts.SortBy)
+	cursor, backward := opts.After, false
+	if opts.Before != nil {
+		cursor, backward = opts.Before, true
+	}
+	order, cmp := "ASC", ">"
+	if desc != backward {
+		order, cmp = "DESC", "<"
+	}
+	if cursor != nil {
+		args = append(args, cursor.Value, cursor.ID)
+		query += fmt.Sprintf(` AND (%s, l.id) %s ($%d::%s, $%d)`,
+			column.expr, cmp, len(args)-1, column.cast, len(args))
+	}
+	query += fmt.Sprintf(` ORDER BY %s %s, l.id %s`, column.expr, order, order)
+
+	// Add pagination
+	if opts.Limit > 0 {
+		args = append(args, opts.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+	if opts.Offset > 0 && cursor == nil {
+		args = append(args, opts.Offset)
+		query += fmt.Sprintf(` OFFSET $%d`, len(args))
+	}
+
+	links, err := r.queryLinks(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if backward {
+		for i, j := 0, len(links)-1; i < j; i, j = i+1, j-1 {
+			links[i], links[j] = links[j], links[i]
+		}
+	}
+	return links, nil
+}
+
+// EachLink calls fn for every link matching opts, in sort order, reading
+// rows as it goes rather than loading them all. Tags are included. Returning
+// an error from fn stops the iteration and is returned.
+func (r *LinkRepository) EachLink(ctx context.Context, opts ListOptions, fn func(*Link) error) error {
+	query := `
+		SELECT ` + linkColumns + `,
+			ARRAY(
+				SELECT t.name FROM link_tags lt JOIN tags t ON t.id = lt.tag_id
+				WHERE lt.link_id = l.id ORDER BY t.name
+			)
+		FROM ` + linkTables + `
+		WHERE TRUE`
+
+	query, args := appendLinkFilters(query, nil, opts)
+	column, desc := parseSort(opts.SortBy)
+	order := "ASC"
+	if desc {
+		order = "DESC"
+	}
+	query += fmt.Sprintf(` ORDER BY %s %s, l.id %s`, column.expr, order, order)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tags []string
+		link, err := scanLink(rows, pq.Array(&tags))
+		if err != nil {
+			return err
+		}
+		link.Tags = tags
+		if link.Tags == nil {
+			link.Tags = []string{}
+		}
+		if err := fn(link); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Count counts the links List would return without pagination.
+func (r *LinkRepository) Count(ctx context.Context, opts ListOptions) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM ` + linkTables + `
+		WHERE TRUE`
+
+	query, args := appendLinkFilters(query, nil, opts)
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	return count, err
+}
+
+// EstimateCount is a cheap stand-in for Count on large listings: it returns
+// the planner's row estimate instead of counting.
+func (r *LinkRepository) EstimateCount(ctx context.Context, opts ListOptions) (int, error) {
+	query := `
+		EXPLAIN (FORMAT JSON) SELECT 1
+		FROM ` + linkTables + `
+		WHERE TRUE`
+
+	query, args := appendLinkFilters(query, nil, opts)
+
+	var raw []byte
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&raw); err != nil {
+		return 0, err
+	}
+
+	var plan []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(raw, &plan); err != nil || len(plan) == 0 {
+		return 0, fmt.Errorf("failed to read query plan: %v", err)
+	}
+	return int(plan[0].Plan.Rows), nil
+}
+
+// appendLinkFilters adds the WHERE conditions for opts to a query over
+// linkTables, numbering placeholders after the existing args.
+func appendLinkFilters(query string, args []interface{}, opts ListOptions) (string, []interface{}) {
+	if opts.OwnerID != 0 {
+		args = append(args, opts.OwnerID)
+		query += fmt.Sprintf(` AND l.created_by = $%d`, len(args))
+	}
+	if opts.OwnerEmail != "" {
+		args = append(args, opts.OwnerEmail)
+		query += fmt.Sprintf(` AND LOWER(u.email) = LOWER($%d)`, len(args))
+	}
+
+	if ns := strings.Trim(opts.Namespace, "/"); ns != "" {
+		args = append(args, escapeLike(ns)+"/%")
+		query += fmt.Sprintf(` AND l.alias LIKE $%d`, len(args))
+	}
+
+	if opts.Visibility != "" {
+		args = append(args, opts.Visibility)
+		query += fmt.Sprintf(` AND l.visibility = $%d`, len(args))
+	}
+
+	// Add search condition
+	if opts.Search != "" {
+		args = append(args, "%"+opts.Search+"%")
+		query += fmt.Sprintf(` AND (l.alias ILIKE $%d OR l.destination_url ILIKE $%d OR l.description ILIKE $%d)`,
+			len(args), len(args), len(args))
+	}
+
+	// Add domain filter
+	if opts.Domain != "" {
+		args = append(args, "%"+opts.Domain+"%")
+		query += fmt.Sprintf(` AND l.destination_url ILIKE $%d`, len(args))
+	}
+
+	if opts.DestPrefix != "" {
+		args = append(args, escapeLike(opts.DestPrefix)+"%")
+		query += fmt.Sprintf(` AND l.destination_url LIKE $%d`, len(args))
+	}
+
+	// Add status filter
+	switch opts.Status {
+	case LinkStatusActive:
+		query += ` AND l.is_active AND (l.expires_at IS NULL OR l.expires_at > NOW())`
+	case LinkStatusExpired:
+		query += ` AND l.expires_at <= NOW()`
+	case LinkStatusInactive:
+		query += ` AND NOT l.is_active`
+	case LinkStatusFlagged:
+		query += ` AND l.flagged_at IS NOT NULL`
+	case LinkStatusBroken:
+		query += ` AND EXISTS (SELECT 1 FROM link_health h WHERE h.link_id = l.id AND NOT h.healthy)`
+	}
+
+	// Add date range filters
+	for _, f := range []struct {
+		column string
+		op     string
+		t      *time.Time
+	}{
+		{"l.created_at", ">=", opts.CreatedAfter},
+		{"l.created_at", "<", opts.CreatedBefore},
+		{"s.last_accessed_at", ">=", opts.AccessedAfter},
+		{"s.last_accessed_at", "<", opts.AccessedBefore},
+	} {
+		if f.t != nil {
+			args = append(args, *f.t)
+			query += fmt.Sprintf(` AND %s %s $%d`, f.column, f.op, len(args))
+		}
+	}
+
+	if opts.ZeroClicks {
+		query += ` AND COALESCE(s.total_count, 0) = 0`
+	}
+
+	// Add tag filter
+	if tags := NormalizeTagList(opts.Tags); len(tags) > 0 {
+		args = append(args, pq.Array(tags))
+		tagQuery := fmt.Sprintf(`
+			SELECT COUNT(DISTINCT t.name)
+			FROM link_tags lt
+			JOIN tags t ON t.id = lt.tag_id
+			WHERE lt.link_id = l.id AND t.name = ANY($%d)`, len(args))
+
+		if opts.TagMatch == TagMatchAll {
+			args = append(args, len(tags))
+			query += fmt.Sprintf(` AND (%s) = $%d`, tagQuery, len(args))
+		} else {
+			query += fmt.Sprintf(` AND (%s) > 0`, tagQuery)
+		}
+	}
+
+	return query, args
+}
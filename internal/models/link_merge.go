@@ -0,0 +1,111 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// MergeLinks turns the links in mergeIDs into forwarding aliases of keepID.
+// Their click counts move to the kept link, and links already forwarding to
+// them forward to the kept link instead, so forwarding is never chained.
+// The audit entries are stored with the merge.
+func (r *LinkRepository) MergeLinks(ctx context.Context, keepID int64, mergeIDs []int64, audit ...*AuditLog) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE link_stats k
+		SET daily_count = k.daily_count + m.daily_count,
+			weekly_count = k.weekly_count + m.weekly_count,
+			total_count = k.total_count + m.total_count,
+			last_accessed_at = GREATEST(k.last_accessed_at, m.last_accessed_at)
+		FROM (
+			SELECT COALESCE(SUM(daily_count), 0) AS daily_count,
+				COALESCE(SUM(weekly_count), 0) AS weekly_count,
+				COALESCE(SUM(total_count), 0) AS total_count,
+				MAX(last_accessed_at) AS last_accessed_at
+			FROM link_stats
+			WHERE link_id = ANY($2)
+		) m
+		WHERE k.link_id = $1`,
+		keepID, pq.Array(mergeIDs))
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO link_stats (link_id, daily_count, weekly_count, total_count, last_accessed_at)
+			SELECT $1, COALESCE(SUM(daily_count), 0), COALESCE(SUM(weekly_count), 0),
+				COALESCE(SUM(total_count), 0), MAX(last_accessed_at)
+			FROM link_stats
+			WHERE link_id = ANY($2)`,
+			keepID, pq.Array(mergeIDs))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Forwarded clicks count towards the kept link from now on.
+	_, err = tx.ExecContext(ctx, `
+		UPDATE link_stats
+		SET daily_count = 0, weekly_count = 0, total_count = 0
+		WHERE link_id = ANY($1)`,
+		pq.Array(mergeIDs))
+	if err != nil {
+		return err
+	}
+
+	result, err = tx.ExecContext(ctx, `
+		UPDATE links
+		SET merged_into = $1, updated_at = NOW()
+		WHERE id = ANY($2)`,
+		keepID, pq.Array(mergeIDs))
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected < int64(len(mergeIDs)) {
+		return ErrNotFound
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE links
+		SET merged_into = $1, updated_at = NOW()
+		WHERE merged_into = ANY($2)`,
+		keepID, pq.Array(mergeIDs))
+	if err != nil {
+		return err
+	}
+	if err := recordChange(ctx, tx, LinkChangeUpdated, append([]int64{keepID}, mergeIDs...)...); err != nil {
+		return err
+	}
+	if err := insertAuditLogs(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.notifyChange(LinkChangeUpdated, append([]int64{keepID}, mergeIDs...)...)
+	return nil
+}
+
+// Forward returns the link that following link leads to: the link it was
+// merged into, or link itself.
+func (r *LinkRepository) Forward(ctx context.Context, link *Link) (*Link, error) {
+	if link.MergedInto == nil {
+		return link, nil
+	}
+	target, err := r.GetByID(ctx, *link.MergedInto)
+	if errors.Is(err, ErrNotFound) {
+		return link, nil
+	}
+	return target, err
+}
@@ -3,7 +3,6 @@ package models
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"log"
 
 	"github.com/devingoodsell/go-links-free/internal/db"
@@ -11,26 +10,141 @@ import (
 )
 
 type LinkRepository struct {
-	db *db.DB
+	db        *db.DB
+	listeners []func(LinkChange)
 }
 
 func NewLinkRepository(db *db.DB) *LinkRepository {
 	return &LinkRepository{db: db}
 }
 
+// linkColumns is the column list read by scanLink. Queries select it from
+// linkTables.
+const linkColumns = `
+	l.id, l.alias, l.destination_url, COALESCE(l.description, ''), l.created_by, COALESCE(u.email, ''), l.expires_at,
+	l.created_at, l.updated_at, l.is_active, l.visibility, l.flagged_at, COALESCE(l.flag_reason, ''), l.merged_into,
+	COALESCE(s.daily_count, 0), COALESCE(s.weekly_count, 0),
+	COALESCE(s.total_count, 0), s.last_accessed_at`
+
+const linkTables = `links l
+	LEFT JOIN link_stats s ON l.id = s.link_id
+	LEFT JOIN users u ON u.id = l.created_by`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanLink reads linkColumns from row, followed by any extra columns the
+// query selects into extra.
+func scanLink(row rowScanner, extra ...interface{}) (*Link, error) {
+	link := &Link{Stats: &LinkStats{}}
+	dest := []interface{}{
+		&link.ID, &link.Alias, &link.DestinationURL, &link.Description, &link.CreatedBy, &link.OwnerEmail,
+		&link.ExpiresAt, &link.CreatedAt, &link.UpdatedAt,
+		&link.IsActive, &link.Visibility, &link.FlaggedAt, &link.FlagReason, &link.MergedInto,
+		&link.Stats.DailyCount, &link.Stats.WeeklyCount, &link.Stats.TotalCount,
+		&link.Stats.LastAccessedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// scanLinks reads every row with scanLink and closes rows.
+func scanLinks(rows *sql.Rows) ([]*Link, error) {
+	defer rows.Close()
+
+	var links []*Link
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// queryLinks runs a query selecting linkColumns and loads the tags of every
+// link it returns.
+func (r *LinkRepository) queryLinks(ctx context.Context, query string, args ...interface{}) ([]*Link, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := scanLinks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadTags(ctx, links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// queryLink is queryLinks for a single row, returning ErrNotFound if there is none.
+func (r *LinkRepository) queryLink(ctx context.Context, query string, args ...interface{}) (*Link, error) {
+	link, err := scanLink(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadTags(ctx, []*Link{link}); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
 func (r *LinkRepository) Create(ctx context.Context, link *Link) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertLink(ctx, tx, link); err != nil {
+		return err
+	}
+	if err := recordChange(ctx, tx, LinkChangeCreated, link.ID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.notifyChange(LinkChangeCreated, link.ID)
+	return nil
+}
+
+// insertLink adds link with empty stats and its tags within tx, filling in
+// its ID and timestamps.
+func insertLink(ctx context.Context, tx *sql.Tx, link *Link) error {
 	query := `
-		INSERT INTO links (alias, destination_url, created_by, expires_at, is_active)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO links (alias, destination_url, description, created_by, expires_at, is_active, visibility)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at, updated_at`
 
-	err := r.db.QueryRowContext(
+	if link.Visibility == "" {
+		link.Visibility = VisibilityPublic
+	}
+
+	err := tx.QueryRowContext(
 		ctx, query,
 		link.Alias,
 		link.DestinationURL,
+		link.Description,
 		link.CreatedBy,
 		link.ExpiresAt,
 		link.IsActive,
+		link.Visibility,
 	).Scan(&link.ID, &link.CreatedAt, &link.UpdatedAt)
 
 	if err != nil {
@@ -46,36 +160,20 @@ func (r *LinkRepository) Create(ctx context.Context, link *Link) error {
 		INSERT INTO link_stats (link_id, daily_count, weekly_count, total_count)
 		VALUES ($1, 0, 0, 0)`
 
-	_, err = r.db.ExecContext(ctx, statsQuery, link.ID)
-	return err
+	if _, err := tx.ExecContext(ctx, statsQuery, link.ID); err != nil {
+		return err
+	}
+
+	return setLinkTags(ctx, tx, link.ID, link.Tags)
 }
 
 func (r *LinkRepository) GetByAlias(ctx context.Context, alias string) (*Link, error) {
 	query := `
-		SELECT l.id, l.alias, l.destination_url, l.created_by, l.expires_at,
-			   l.created_at, l.updated_at,
-			   COALESCE(s.daily_count, 0), COALESCE(s.weekly_count, 0),
-			   COALESCE(s.total_count, 0), s.last_accessed_at as "lastAccessedAt"
-		FROM links l
-		LEFT JOIN link_stats s ON l.id = s.link_id
+		SELECT ` + linkColumns + `
+		FROM ` + linkTables + `
 		WHERE l.alias = $1`
 
-	link := &Link{Stats: &LinkStats{}}
-	err := r.db.QueryRowContext(ctx, query, alias).Scan(
-		&link.ID, &link.Alias, &link.DestinationURL, &link.CreatedBy,
-		&link.ExpiresAt, &link.CreatedAt, &link.UpdatedAt,
-		&link.Stats.DailyCount, &link.Stats.WeeklyCount, &link.Stats.TotalCount,
-		&link.Stats.LastAccessedAt,
-	)
-
-	if err == sql.ErrNoRows {
-		return nil, ErrNotFound
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	return link, nil
+	return r.queryLink(ctx, query, alias)
 }
 
 func (r *LinkRepository) IncrementStats(ctx context.Context, linkID int64) error {
@@ -93,57 +191,126 @@ func (r *LinkRepository) IncrementStats(ctx context.Context, linkID int64) error
 
 func (r *LinkRepository) ListByUser(ctx context.Context, userID int64) ([]*Link, error) {
 	query := `
-		SELECT l.id, l.alias, l.destination_url, l.created_by, l.expires_at,
-			   l.created_at, l.updated_at,
-			   s.daily_count, s.weekly_count, s.total_count, s.last_accessed_at
-		FROM links l
-		LEFT JOIN link_stats s ON l.id = s.link_id
+		SELECT ` + linkColumns + `
+		FROM ` + linkTables + `
 		WHERE l.created_by = $1
 		ORDER BY l.created_at DESC`
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	return r.queryLinks(ctx, query, userID)
+}
+
+func (r *LinkRepository) Update(ctx context.Context, link *Link) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	var links []*Link
-	for rows.Next() {
-		link := &Link{Stats: &LinkStats{}}
-		err := rows.Scan(
-			&link.ID, &link.Alias, &link.DestinationURL, &link.CreatedBy,
-			&link.ExpiresAt, &link.CreatedAt, &link.UpdatedAt,
-			&link.Stats.DailyCount, &link.Stats.WeeklyCount, &link.Stats.TotalCount,
-			&link.Stats.LastAccessedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		links = append(links, link)
+	if err := updateLink(ctx, tx, link); err != nil {
+		return err
+	}
+	if err := recordChange(ctx, tx, LinkChangeUpdated, link.ID); err != nil {
+		return err
 	}
 
-	return links, nil
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.notifyChange(LinkChangeUpdated, link.ID)
+	return nil
 }
 
-func (r *LinkRepository) Update(ctx context.Context, link *Link) error {
+// updateLink saves link and its tags within tx. Only its owner's links are
+// updated; any other ID is ErrNotFound.
+func updateLink(ctx context.Context, tx *sql.Tx, link *Link) error {
 	query := `
 		UPDATE links 
-		SET destination_url = $1, expires_at = $2, updated_at = NOW()
-		WHERE id = $3 AND created_by = $4
+		SET alias = $1, destination_url = $2, description = $3, expires_at = $4, visibility = $7, updated_at = NOW(),
+			flagged_at = CASE WHEN destination_url = $2 THEN flagged_at END,
+			flag_reason = CASE WHEN destination_url = $2 THEN flag_reason END
+		WHERE id = $5 AND created_by = $6
 		RETURNING updated_at`
 
-	err := r.db.QueryRowContext(
+	if link.Visibility == "" {
+		link.Visibility = VisibilityPublic
+	}
+
+	err := tx.QueryRowContext(
 		ctx, query,
+		link.Alias,
 		link.DestinationURL,
+		link.Description,
 		link.ExpiresAt,
 		link.ID,
 		link.CreatedBy,
+		link.Visibility,
 	).Scan(&link.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return ErrNotFound
 	}
-	return err
+	if isPgDuplicateError(err) {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return err
+	}
+
+	return setLinkTags(ctx, tx, link.ID, link.Tags)
+}
+
+// SaveAll creates the links without an ID and updates the others, in one
+// transaction. If any link fails nothing is saved, and the index of that
+// link is returned along with its error; the index is -1 when the
+// transaction itself failed.
+func (r *LinkRepository) SaveAll(ctx context.Context, links []*Link) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	var created, updated []int64
+	isNew := make([]bool, len(links))
+	abandon := func(failed int, err error) (int, error) {
+		// The rolled back inserts never got their IDs
+		for i, link := range links {
+			if isNew[i] {
+				link.ID = 0
+			}
+		}
+		return failed, err
+	}
+	for i, link := range links {
+		if link.ID == 0 {
+			isNew[i] = true
+			err = insertLink(ctx, tx, link)
+			created = append(created, link.ID)
+		} else {
+			err = updateLink(ctx, tx, link)
+			updated = append(updated, link.ID)
+		}
+		if err != nil {
+			return abandon(i, err)
+		}
+	}
+	if err := recordChange(ctx, tx, LinkChangeCreated, created...); err != nil {
+		return abandon(-1, err)
+	}
+	if err := recordChange(ctx, tx, LinkChangeUpdated, updated...); err != nil {
+		return abandon(-1, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return abandon(-1, err)
+	}
+
+	if len(created) > 0 {
+		r.notifyChange(LinkChangeCreated, created...)
+	}
+	if len(updated) > 0 {
+		r.notifyChange(LinkChangeUpdated, updated...)
+	}
+	return -1, nil
 }
 
 func (r *LinkRepository) Delete(ctx context.Context, id int64, userID int64) error {
@@ -165,55 +332,78 @@ func (r *LinkRepository) Delete(ctx context.Context, id int64, userID int64) err
 	}
 
 	// Then delete the link
-	result, err := tx.ExecContext(ctx,
-		"DELETE FROM links WHERE id = $1 AND created_by = $2",
-		id, userID)
+	removed := &Link{ID: id}
+	err = tx.QueryRowContext(ctx,
+		"DELETE FROM links WHERE id = $1 AND created_by = $2 RETURNING alias, destination_url, created_by",
+		id, userID).Scan(&removed.Alias, &removed.DestinationURL, &removed.CreatedBy)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
 	if err != nil {
 		return err
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
+	if err := recordDeleted(ctx, tx, []*Link{removed}); err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return ErrNotFound
+	if err := tx.Commit(); err != nil {
+		return err
 	}
-
-	return tx.Commit()
+	r.notifyDeleted([]*Link{removed})
+	return nil
 }
 
-type ListResult struct {
-	Links      []*Link
-	TotalCount int
-	HasMore    bool
+// BulkDelete deletes links the user owns, all or none.
+func (r *LinkRepository) BulkDelete(ctx context.Context, userID int64, ids []int64) error {
+	result, err := r.BulkApply(ctx, BulkOptions{IDs: ids, ActorID: userID, Atomic: true},
+		BulkAction{Type: BulkActionDelete})
+	if err != nil {
+		return err
+	}
+	return result.Err()
 }
 
-func (r *LinkRepository) ListByUserWithPagination(ctx context.Context, userID int64, opts ListOptions) (*ListResult, error) {
-	// Get total count first
-	var totalCount int
-	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM links WHERE created_by = $1", userID).Scan(&totalCount)
+// BulkUpdateStatus activates or deactivates links the user owns, all or
+// none.
+func (r *LinkRepository) BulkUpdateStatus(ctx context.Context, userID int64, ids []int64, isActive bool) error {
+	action := BulkAction{Type: BulkActionDeactivate}
+	if isActive {
+		action.Type = BulkActionActivate
+	}
+	result, err := r.BulkApply(ctx, BulkOptions{IDs: ids, ActorID: userID, Atomic: true}, action)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	return result.Err()
+}
 
-	// If no limit is specified, use a reasonable default
-	if opts.Limit <= 0 {
-		opts.Limit = 20
+// Helper function to check for Postgres duplicate key error
+func isPgDuplicateError(err error) bool {
+	if pqErr, ok := err.(*pq.Error); ok {
+		return pqErr.Code == "23505" // unique_violation
 	}
+	return false
+}
 
+func (r *LinkRepository) GetByID(ctx context.Context, id int64) (*Link, error) {
 	query := `
-		SELECT l.id, l.alias, l.destination_url, l.created_by, l.expires_at,
-			   l.created_at, l.updated_at,
-			   s.daily_count, s.weekly_count, s.total_count, s.last_accessed_at
-		FROM links l
-		LEFT JOIN link_stats s ON l.id = s.link_id
-		WHERE l.created_by = $1
-		ORDER BY l.created_at DESC
-		LIMIT $2 OFFSET $3`
+		SELECT ` + linkColumns + `
+		FROM ` + linkTables + `
+		WHERE l.id = $1`
+
+	return r.queryLink(ctx, query, id)
+}
 
-	rows, err := r.db.QueryContext(ctx, query, userID, opts.Limit+1, opts.Offset)
+// ListUnflagged returns every link that has not been flagged yet, with just
+// enough fields to check its destination.
+func (r *LinkRepository) ListUnflagged(ctx context.Context) ([]*Link, error) {
+	query := `
+		SELECT id, alias, destination_url, created_by, is_active
+		FROM links
+		WHERE flagged_at IS NULL
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -221,245 +411,131 @@ func (r *LinkRepository) ListByUserWithPagination(ctx context.Context, userID in
 
 	var links []*Link
 	for rows.Next() {
-		link := &Link{Stats: &LinkStats{}}
-		err := rows.Scan(
-			&link.ID, &link.Alias, &link.DestinationURL, &link.CreatedBy,
-			&link.ExpiresAt, &link.CreatedAt, &link.UpdatedAt,
-			&link.Stats.DailyCount, &link.Stats.WeeklyCount, &link.Stats.TotalCount,
-			&link.Stats.LastAccessedAt,
-		)
-		if err != nil {
+		link := &Link{}
+		if err := rows.Scan(&link.ID, &link.Alias, &link.DestinationURL, &link.CreatedBy, &link.IsActive); err != nil {
 			return nil, err
 		}
 		links = append(links, link)
 	}
 
-	// Check if there are more results
-	hasMore := len(links) > opts.Limit
-	if hasMore {
-		links = links[:opts.Limit] // Remove the extra item we fetched
-	}
-
-	return &ListResult{
-		Links:      links,
-		TotalCount: totalCount,
-		HasMore:    hasMore,
-	}, nil
+	return links, rows.Err()
 }
 
-func (r *LinkRepository) ListByUserWithFilters(ctx context.Context, userID int64, opts ListOptions) ([]*Link, error) {
+// AdminUpdate saves the destination, expiry, active status and owner of a
+// link regardless of who owns it, along with its audit entries.
+func (r *LinkRepository) AdminUpdate(ctx context.Context, link *Link, audit ...*AuditLog) error {
 	query := `
-		SELECT l.id, l.alias, l.destination_url, l.created_by, l.expires_at,
-			   l.created_at, l.updated_at,
-			   s.daily_count, s.weekly_count, s.total_count, s.last_accessed_at
-		FROM links l
-		LEFT JOIN link_stats s ON l.id = s.link_id
-		WHERE l.created_by = $1`
-
-	args := []interface{}{userID}
-	argCount := 1
+		UPDATE links
+		SET destination_url = $1, expires_at = $2, is_active = $3, created_by = $4, updated_at = NOW(),
+			flagged_at = CASE WHEN destination_url = $1 THEN flagged_at END,
+			flag_reason = CASE WHEN destination_url = $1 THEN flag_reason END
+		WHERE id = $5
+		RETURNING updated_at`
 
-	// Add search condition
-	if opts.Search != "" {
-		argCount++
-		query += fmt.Sprintf(` AND (l.alias ILIKE $%d OR l.destination_url ILIKE $%d)`, argCount, argCount)
-		args = append(args, "%"+opts.Search+"%")
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	// Add domain filter
-	if opts.Domain != "" {
-		argCount++
-		query += fmt.Sprintf(` AND l.destination_url ILIKE $%d`, argCount)
-		args = append(args, "%"+opts.Domain+"%")
+	err = tx.QueryRowContext(ctx, query,
+		link.DestinationURL, link.ExpiresAt, link.IsActive, link.CreatedBy, link.ID,
+	).Scan(&link.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
 	}
-
-	// Add status filter
-	if opts.Status == "active" {
-		query += ` AND (l.expires_at IS NULL OR l.expires_at > NOW())`
-	} else if opts.Status == "expired" {
-		query += ` AND l.expires_at <= NOW()`
+	if err != nil {
+		return err
 	}
-
-	// Add sorting
-	switch opts.SortBy {
-	case "created_desc":
-		query += ` ORDER BY l.created_at DESC`
-	case "created_asc":
-		query += ` ORDER BY l.created_at ASC`
-	case "clicks_desc":
-		query += ` ORDER BY COALESCE(s.total_count, 0) DESC`
-	default:
-		query += ` ORDER BY l.created_at DESC`
+	if err := recordChange(ctx, tx, LinkChangeUpdated, link.ID); err != nil {
+		return err
 	}
-
-	// Add pagination
-	if opts.Limit > 0 {
-		argCount++
-		query += fmt.Sprintf(` LIMIT $%d`, argCount)
-		args = append(args, opts.Limit)
+	if err := insertAuditLogs(ctx, tx, audit); err != nil {
+		return err
 	}
-	if opts.Offset > 0 {
-		argCount++
-		query += fmt.Sprintf(` OFFSET $%d`, argCount)
-		args = append(args, opts.Offset)
+
+	if err := tx.Commit(); err != nil {
+		return err
 	}
+	r.notifyChange(LinkChangeUpdated, link.ID)
+	return nil
+}
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+// Flag marks a link as pointing somewhere unsafe and optionally deactivates it.
+// The audit entries are stored with the flag.
+func (r *LinkRepository) Flag(ctx context.Context, id int64, reason string, deactivate bool, audit ...*AuditLog) error {
+	query := `
+		UPDATE links
+		SET flagged_at = NOW(), flag_reason = $1,
+			is_active = CASE WHEN $2 THEN false ELSE is_active END,
+			updated_at = NOW()
+		WHERE id = $3`
+
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	var links []*Link
-	for rows.Next() {
-		link := &Link{Stats: &LinkStats{}}
-		err := rows.Scan(
-			&link.ID, &link.Alias, &link.DestinationURL, &link.CreatedBy,
-			&link.ExpiresAt, &link.CreatedAt, &link.UpdatedAt,
-			&link.Stats.DailyCount, &link.Stats.WeeklyCount, &link.Stats.TotalCount,
-			&link.Stats.LastAccessedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		links = append(links, link)
+	result, err := tx.ExecContext(ctx, query, reason, deactivate, id)
+	if err != nil {
+		return err
 	}
 
-	return links, nil
-}
-
-// Update these method signatures
-func (r *LinkRepository) BulkDelete(ctx context.Context, userID int64, ids []int64) error {
-	// First verify ownership of all links
-	for _, id := range ids {
-		link, err := r.GetByID(ctx, id)
-		if err != nil {
-			return err
-		}
-		if link.CreatedBy != userID {
-			return ErrUnauthorized
-		}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
 	}
-
-	// Then perform the bulk delete
-	query := `DELETE FROM links WHERE id = ANY($1)`
-	_, err := r.db.ExecContext(ctx, query, pq.Array(ids))
-	return err
-}
-
-func (r *LinkRepository) BulkUpdateStatus(ctx context.Context, userID int64, ids []int64, isActive bool) error {
-	// First verify ownership of all links
-	for _, id := range ids {
-		link, err := r.GetByID(ctx, id)
-		if err != nil {
-			return err
-		}
-		if link.CreatedBy != userID {
-			return ErrUnauthorized
-		}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	if err := recordChange(ctx, tx, LinkChangeUpdated, id); err != nil {
+		return err
+	}
+	if err := insertAuditLogs(ctx, tx, audit); err != nil {
+		return err
 	}
 
-	// Then perform the bulk update
-	query := `UPDATE links SET is_active = $1 WHERE id = ANY($2)`
-	_, err := r.db.ExecContext(ctx, query, isActive, pq.Array(ids))
-	return err
-}
-
-// Helper function to check for Postgres duplicate key error
-func isPgDuplicateError(err error) bool {
-	if pqErr, ok := err.(*pq.Error); ok {
-		return pqErr.Code == "23505" // unique_violation
+	if err := tx.Commit(); err != nil {
+		return err
 	}
-	return false
+	r.notifyChange(LinkChangeUpdated, id)
+	return nil
 }
 
-func (r *LinkRepository) GetByID(ctx context.Context, id int64) (*Link, error) {
+// ListActive returns every active, unexpired link with its stats but without
+// tags, for building in-memory indexes.
+func (r *LinkRepository) ListActive(ctx context.Context) ([]*Link, error) {
 	query := `
-		SELECT l.id, l.alias, l.destination_url, l.created_by, l.expires_at,
-			   l.created_at, l.updated_at,
-			   COALESCE(s.daily_count, 0), COALESCE(s.weekly_count, 0),
-			   COALESCE(s.total_count, 0), s.last_accessed_at as "lastAccessedAt"
-		FROM links l
-		LEFT JOIN link_stats s ON l.id = s.link_id
-		WHERE l.id = $1`
-
-	link := &Link{Stats: &LinkStats{}}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&link.ID, &link.Alias, &link.DestinationURL, &link.CreatedBy,
-		&link.ExpiresAt, &link.CreatedAt, &link.UpdatedAt,
-		&link.Stats.DailyCount, &link.Stats.WeeklyCount, &link.Stats.TotalCount,
-		&link.Stats.LastAccessedAt,
-	)
+		SELECT ` + linkColumns + `
+		FROM ` + linkTables + `
+		WHERE l.is_active AND (l.expires_at IS NULL OR l.expires_at > NOW())`
 
-	if err == sql.ErrNoRows {
-		return nil, ErrNotFound
-	}
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
-
-	return link, nil
+	return scanLinks(rows)
 }
 
-func (r *LinkRepository) ListForUser(ctx context.Context, userID int64, page, pageSize int) ([]Link, int64, error) {
-	offset := page * pageSize
-
-	log.Printf("Listing links for user %d, page %d, pageSize %d", userID, page, pageSize)
-
-	// Get total count
-	var total int64
-	err := r.db.QueryRowContext(ctx,
-		"SELECT COUNT(*) FROM links WHERE created_by = $1",
-		userID,
-	).Scan(&total)
-	if err != nil {
-		log.Printf("Error getting total count: %v", err)
-		return nil, 0, err
-	}
-
-	log.Printf("Found %d total links", total)
-
-	// Get paginated links
+// GetByAliases returns the links with the given aliases. Aliases that do not
+// exist are simply missing from the result.
+func (r *LinkRepository) GetByAliases(ctx context.Context, aliases []string) ([]*Link, error) {
 	query := `
-		SELECT id, alias, destination_url, created_by, expires_at, created_at, updated_at, is_active 
-		FROM links 
-		WHERE created_by = $1 
-		ORDER BY created_at DESC 
-		LIMIT $2 OFFSET $3`
-
-	log.Printf("Executing query: %s with userID=%d, pageSize=%d, offset=%d",
-		query, userID, pageSize, offset)
-
-	rows, err := r.db.QueryContext(ctx, query, userID, pageSize, offset)
-	if err != nil {
-		log.Printf("Error executing query: %v", err)
-		return nil, 0, err
-	}
-	defer rows.Close()
+		SELECT ` + linkColumns + `
+		FROM ` + linkTables + `
+		WHERE l.alias = ANY($1)`
 
-	var links []Link
-	for rows.Next() {
-		var link Link
-		err := rows.Scan(
-			&link.ID,
-			&link.Alias,
-			&link.DestinationURL,
-			&link.CreatedBy,
-			&link.ExpiresAt,
-			&link.CreatedAt,
-			&link.UpdatedAt,
-			&link.IsActive,
-		)
-		if err != nil {
-			return nil, 0, err
-		}
-		link.Stats = &LinkStats{} // Initialize empty stats
-		links = append(links, link)
-	}
+	return r.queryLinks(ctx, query, pq.Array(aliases))
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, 0, err
-	}
+// GetByIDs returns the links with the given IDs. IDs that do not exist are
+// simply missing from the result.
+func (r *LinkRepository) GetByIDs(ctx context.Context, ids []int64) ([]*Link, error) {
+	query := `
+		SELECT ` + linkColumns + `
+		FROM ` + linkTables + `
+		WHERE l.id = ANY($1)`
 
-	return links, total, nil
+	return r.queryLinks(ctx, query, pq.Array(ids))
 }
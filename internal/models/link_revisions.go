@@ -0,0 +1,109 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrStaleDestination means a link's destination changed between planning
+// a rewrite and applying it.
+var ErrStaleDestination = errors.New("destination changed since the rewrite was planned")
+
+// LinkRevision is one recorded change of a link's destination.
+type LinkRevision struct {
+	ID                int64     `json:"id"`
+	LinkID            int64     `json:"linkId"`
+	ChangedBy         *int64    `json:"changedBy,omitempty"`
+	OldDestinationURL string    `json:"oldDestinationUrl"`
+	NewDestinationURL string    `json:"newDestinationUrl"`
+	Reason            string    `json:"reason,omitempty"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// DestinationChange moves one link from Before to After.
+type DestinationChange struct {
+	LinkID int64
+	Before string
+	After  string
+}
+
+// RewriteDestinations applies every change in one transaction and records a
+// revision for each, along with the audit entries. It fails with
+// ErrStaleDestination, changing nothing, if any link no longer points at its
+// Before destination.
+func (r *LinkRepository) RewriteDestinations(ctx context.Context, changes []DestinationChange, actorID int64, reason string, audit ...*AuditLog) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ids := make([]int64, 0, len(changes))
+	for _, change := range changes {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE links
+			SET destination_url = $1, updated_at = NOW(), flagged_at = NULL, flag_reason = NULL
+			WHERE id = $2 AND destination_url = $3`,
+			change.After, change.LinkID, change.Before)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("link %d: %w", change.LinkID, ErrStaleDestination)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO link_revisions (link_id, changed_by, old_destination_url, new_destination_url, reason)
+			VALUES ($1, $2, $3, $4, $5)`,
+			change.LinkID, actorID, change.Before, change.After, reason)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, change.LinkID)
+	}
+	if err := recordChange(ctx, tx, LinkChangeUpdated, ids...); err != nil {
+		return err
+	}
+	if err := insertAuditLogs(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		r.notifyChange(LinkChangeUpdated, ids...)
+	}
+	return nil
+}
+
+// ListRevisions returns a link's destination history, newest first.
+func (r *LinkRepository) ListRevisions(ctx context.Context, linkID int64) ([]*LinkRevision, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, link_id, changed_by, old_destination_url, new_destination_url, reason, created_at
+		FROM link_revisions
+		WHERE link_id = $1
+		ORDER BY created_at DESC, id DESC`,
+		linkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*LinkRevision{}
+	for rows.Next() {
+		rev := &LinkRevision{}
+		if err := rows.Scan(&rev.ID, &rev.LinkID, &rev.ChangedBy, &rev.OldDestinationURL,
+			&rev.NewDestinationURL, &rev.Reason, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
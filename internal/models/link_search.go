@@ -0,0 +1,160 @@
+package models
+
+import (
+	"context"
+	"html"
+	"strings"
+)
+
+// Markers ts_headline wraps matches in. They are swapped for <mark> tags
+// after the rest of the snippet has been HTML-escaped.
+const (
+	highlightStart = "\x01"
+	highlightStop  = "\x02"
+)
+
+type SearchOptions struct {
+	Query  string
+	UserID int64 // the caller; their own inactive, expired and private links are included
+	Limit  int
+	After  *SearchPosition // page forward from this hit
+	Before *SearchPosition // page backward from this hit
+}
+
+// SearchPosition is the keyset position of a search hit, used to fetch the
+// page after it.
+type SearchPosition struct {
+	Score float64 `json:"s"`
+	ID    int64   `json:"i"`
+}
+
+// SearchHit is a link matching a search along with its relevance score and
+// HTML snippets in which matches are wrapped in <mark>.
+type SearchHit struct {
+	*Link
+	Score      float64           `json:"score"`
+	Favorite   bool              `json:"favorite"` // starred by the caller
+	Highlights map[string]string `json:"highlights"`
+}
+
+// SearchResult is a page of hits in rank order. HasMore reports whether
+// there are hits beyond the page in the direction it was fetched.
+type SearchResult struct {
+	Hits    []*SearchHit
+	HasMore bool
+}
+
+// Search ranks every link visible to the caller against a free-text query.
+// Relevance comes from the tsvector over alias, tags, description and
+// destination plus trigram similarity on the alias, and is blended with
+// click popularity and boosted for links the caller starred.
+func (r *LinkRepository) Search(ctx context.Context, opts SearchOptions) (*SearchResult, error) {
+	if opts.Limit <= 0 || opts.Limit > 100 {
+		opts.Limit = 20
+	}
+
+	var cursorScore interface{}
+	var cursorID int64
+	order, cmp := "DESC", "<"
+	if opts.After != nil {
+		cursorScore, cursorID = opts.After.Score, opts.After.ID
+	} else if opts.Before != nil {
+		cursorScore, cursorID = opts.Before.Score, opts.Before.ID
+		order, cmp = "ASC", ">"
+	}
+
+	query := `
+		SELECT * FROM (
+			SELECT ` + linkColumns + `,
+				(ts_rank_cd(l.search_vector, q.query) * 2
+					+ similarity(l.alias, $1)
+					+ 0.5 * word_similarity($1, l.destination_url))
+				* (1 + 0.1 * ln(1 + COALESCE(s.total_count, 0)))
+				* CASE WHEN f.link_id IS NULL THEN 1 ELSE 1.5 END AS score,
+				f.link_id IS NOT NULL AS favorite,
+				ts_headline('simple', l.alias, q.query, $6) AS alias_snippet,
+				ts_headline('english', COALESCE(l.description, ''), q.query, $6 || ', MaxFragments=2, MaxWords=20, MinWords=5') AS description_snippet,
+				ts_headline('simple', l.destination_url, q.query, $6) AS destination_snippet
+			FROM ` + linkTables + `
+			LEFT JOIN user_favorites f ON f.link_id = l.id AND f.user_id = $3
+			CROSS JOIN (
+				SELECT websearch_to_tsquery('simple', $1) || websearch_to_tsquery('english', $1) AS query
+			) q
+			WHERE (l.search_vector @@ q.query
+				OR l.alias % $1
+				OR l.alias ILIKE $2
+				OR l.destination_url ILIKE $2
+				OR l.description ILIKE $2)
+			  AND ((l.is_active AND (l.expires_at IS NULL OR l.expires_at > NOW()) AND l.visibility = 'public')
+				OR l.created_by = $3)
+		) ranked
+		WHERE $4::float8 IS NULL OR (score, id) ` + cmp + ` ($4::float8, $5)
+		ORDER BY score ` + order + `, id ` + order + `
+		LIMIT $7`
+
+	headlineOptions := "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true"
+	rows, err := r.db.QueryContext(ctx, query,
+		opts.Query,
+		"%"+escapeLike(opts.Query)+"%",
+		opts.UserID,
+		cursorScore,
+		cursorID,
+		headlineOptions,
+		opts.Limit+1,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []*SearchHit
+	for rows.Next() {
+		hit := &SearchHit{}
+		var aliasSnippet, descriptionSnippet, destinationSnippet string
+		link, err := scanLink(rows, &hit.Score, &hit.Favorite, &aliasSnippet, &descriptionSnippet, &destinationSnippet)
+		if err != nil {
+			return nil, err
+		}
+		hit.Link = link
+
+		hit.Highlights = map[string]string{
+			"alias":          renderHighlight(aliasSnippet),
+			"destinationUrl": renderHighlight(destinationSnippet),
+		}
+		if descriptionSnippet != "" {
+			hit.Highlights["description"] = renderHighlight(descriptionSnippet)
+		}
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	hasMore := len(hits) > opts.Limit
+	if hasMore {
+		hits = hits[:opts.Limit]
+	}
+	if opts.Before != nil {
+		for i, j := 0, len(hits)-1; i < j; i, j = i+1, j-1 {
+			hits[i], hits[j] = hits[j], hits[i]
+		}
+	}
+
+	links := make([]*Link, len(hits))
+	for i, hit := range hits {
+		links[i] = hit.Link
+	}
+	if err := r.loadTags(ctx, links); err != nil {
+		return nil, err
+	}
+
+	return &SearchResult{Hits: hits, HasMore: hasMore}, nil
+}
+
+// renderHighlight HTML-escapes a ts_headline snippet and turns the match
+// markers into <mark> tags.
+func renderHighlight(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, highlightStop, "</mark>")
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Reasons a link is considered stale.
+const (
+	StaleUnused        = "unused"         // no clicks for a while
+	StaleOwnerInactive = "owner_inactive" // the owner has not signed in for a while
+	StaleExpiring      = "expiring"       // expires soon
+)
+
+// StaleOptions set the thresholds of ListStale. Links nudged after
+// NudgedBefore, or snoozed by their owner, are left out.
+type StaleOptions struct {
+	UnusedSince        time.Time
+	OwnerInactiveSince time.Time
+	ExpiringBefore     time.Time
+	NudgedBefore       time.Time
+	Limit              int
+}
+
+// StaleLink is a link that should be brought to its owner's attention, with
+// the reasons why.
+type StaleLink struct {
+	Link    *Link
+	Reasons []string
+}
+
+// ListStale returns active links that have not been used since
+// UnusedSince, whose owner has not signed in since OwnerInactiveSince or
+// that expire before ExpiringBefore, ordered by owner. A link that was
+// never clicked counts as unused from its creation.
+func (r *LinkRepository) ListStale(ctx context.Context, opts StaleOptions) ([]*StaleLink, error) {
+	query := `
+		SELECT ` + linkColumns + `, stale.reasons
+		FROM ` + linkTables + `
+		LEFT JOIN link_nudges n ON n.link_id = l.id
+		CROSS JOIN LATERAL (SELECT ARRAY_REMOVE(ARRAY[
+			CASE WHEN COALESCE(s.last_accessed_at, l.created_at) < $1 THEN '` + StaleUnused + `' END,
+			CASE WHEN COALESCE(u.last_login_at, u.created_at) < $2 THEN '` + StaleOwnerInactive + `' END,
+			CASE WHEN l.expires_at < $3 THEN '` + StaleExpiring + `' END
+		], NULL) AS reasons) stale
+		WHERE l.is_active AND l.merged_into IS NULL AND l.created_by IS NOT NULL
+			AND (l.expires_at IS NULL OR l.expires_at > NOW())
+			AND (n.link_id IS NULL OR (n.nudged_at < $4 AND (n.snoozed_until IS NULL OR n.snoozed_until < NOW())))
+			AND CARDINALITY(stale.reasons) > 0
+		ORDER BY l.created_by, l.alias
+		LIMIT $5`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		opts.UnusedSince, opts.OwnerInactiveSince, opts.ExpiringBefore, opts.NudgedBefore, opts.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stale []*StaleLink
+	for rows.Next() {
+		var reasons []string
+		link, err := scanLink(rows, pq.Array(&reasons))
+		if err != nil {
+			return nil, err
+		}
+		stale = append(stale, &StaleLink{Link: link, Reasons: reasons})
+	}
+	return stale, rows.Err()
+}
+
+// RecordNudges notes that links were just put in a digest, so they are not
+// sent again until the next nudge is due.
+func (r *LinkRepository) RecordNudges(ctx context.Context, links []*StaleLink) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stale := range links {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO link_nudges (link_id, reasons, nudged_at)
+			VALUES ($1, $2, NOW())
+			ON CONFLICT (link_id) DO UPDATE
+			SET reasons = EXCLUDED.reasons, nudged_at = EXCLUDED.nudged_at`,
+			stale.Link.ID, pq.Array(stale.Reasons))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// KeepLink is an owner's answer that a stale link is still wanted: it is
+// left out of digests for the given time, and a link that expires gets an
+// expiry that much later. It returns the link's new expiry.
+func (r *LinkRepository) KeepLink(ctx context.Context, id int64, keepFor time.Duration) (*time.Time, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var expiresAt *time.Time
+	err = tx.QueryRowContext(ctx, `
+		UPDATE links
+		SET expires_at = CASE WHEN expires_at IS NOT NULL
+				THEN GREATEST(expires_at, NOW()) + $2 * INTERVAL '1 second' END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING expires_at`,
+		id, int64(keepFor/time.Second)).Scan(&expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO link_nudges (link_id, snoozed_until)
+		VALUES ($1, NOW() + $2 * INTERVAL '1 second')
+		ON CONFLICT (link_id) DO UPDATE SET snoozed_until = EXCLUDED.snoozed_until`,
+		id, int64(keepFor/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	if err := recordChange(ctx, tx, LinkChangeUpdated, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	r.notifyChange(LinkChangeUpdated, id)
+	return expiresAt, nil
+}
@@ -0,0 +1,218 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/db"
+)
+
+// Notification kinds. Users can turn each of them off.
+const (
+	NotifyLinkDigest      = "link_digest"      // unused links and expiry warnings
+	NotifyLinkTransferred = "link_transferred" // a link was given to the user
+	NotifyLinkBroken      = "link_broken"      // a link's destination keeps failing its checks
+	NotifyAdminEdit       = "admin_edit"       // an admin changed one of the user's links
+)
+
+// NotificationKinds lists every kind, in the order preferences are shown.
+var NotificationKinds = []string{NotifyLinkDigest, NotifyLinkTransferred, NotifyLinkBroken, NotifyAdminEdit}
+
+// ValidNotificationKind reports whether kind is a known notification kind.
+func ValidNotificationKind(kind string) bool {
+	for _, k := range NotificationKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Outbox statuses.
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxFailed  = "failed" // gave up after too many attempts
+)
+
+// Notification is a rendered message in the outbox.
+type Notification struct {
+	ID            int64      `json:"id"`
+	UserID        int64      `json:"userId"`
+	Kind          string     `json:"kind"`
+	Recipient     string     `json:"recipient"`
+	Subject       string     `json:"subject"`
+	Body          string     `json:"body"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt"`
+	LastError     string     `json:"lastError,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	SentAt        *time.Time `json:"sentAt,omitempty"`
+}
+
+// notificationColumns is the column list read by scanNotifications, from
+// notification_outbox o.
+const notificationColumns = `
+	o.id, COALESCE(o.user_id, 0), o.kind, o.recipient, o.subject, o.body, o.status,
+	o.attempts, o.next_attempt_at, COALESCE(o.last_error, ''), o.created_at, o.sent_at`
+
+type NotificationRepository struct {
+	db *db.DB
+}
+
+func NewNotificationRepository(db *db.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// GetPreferences returns whether the user wants each kind of notification.
+func (r *NotificationRepository) GetPreferences(ctx context.Context, userID int64) (map[string]bool, error) {
+	prefs := make(map[string]bool, len(NotificationKinds))
+	for _, kind := range NotificationKinds {
+		prefs[kind] = true
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT kind, enabled FROM notification_preferences WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind string
+		var enabled bool
+		if err := rows.Scan(&kind, &enabled); err != nil {
+			return nil, err
+		}
+		if _, ok := prefs[kind]; ok {
+			prefs[kind] = enabled
+		}
+	}
+	return prefs, rows.Err()
+}
+
+// SetPreferences stores the given preferences, leaving kinds not in prefs
+// as they were.
+func (r *NotificationRepository) SetPreferences(ctx context.Context, userID int64, prefs map[string]bool) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for kind, enabled := range prefs {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO notification_preferences (user_id, kind, enabled)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, kind) DO UPDATE SET enabled = EXCLUDED.enabled`,
+			userID, kind, enabled)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Wants reports whether the user wants notifications of kind.
+func (r *NotificationRepository) Wants(ctx context.Context, userID int64, kind string) (bool, error) {
+	var enabled bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT enabled FROM notification_preferences WHERE user_id = $1 AND kind = $2`,
+		userID, kind).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	return enabled, err
+}
+
+// Enqueue adds a notification to the outbox, to be sent as soon as possible.
+func (r *NotificationRepository) Enqueue(ctx context.Context, n *Notification) error {
+	query := `
+		INSERT INTO notification_outbox (user_id, kind, recipient, subject, body)
+		VALUES (NULLIF($1, 0), $2, $3, $4, $5)
+		RETURNING id, status, next_attempt_at, created_at`
+
+	return r.db.QueryRowContext(ctx, query, n.UserID, n.Kind, n.Recipient, n.Subject, n.Body).
+		Scan(&n.ID, &n.Status, &n.NextAttemptAt, &n.CreatedAt)
+}
+
+// ClaimDue returns up to limit pending notifications that are due and
+// holds them back from other callers for lease, so that several servers can
+// share one outbox without sending twice.
+func (r *NotificationRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*Notification, error) {
+	query := `
+		UPDATE notification_outbox o
+		SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		FROM (
+			SELECT id FROM notification_outbox
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) due
+		WHERE o.id = due.id
+		RETURNING ` + notificationColumns
+
+	rows, err := r.db.QueryContext(ctx, query, limit, int64(lease/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanNotifications(rows)
+}
+
+// MarkSent records that a notification was delivered.
+func (r *NotificationRepository) MarkSent(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE notification_outbox
+		SET status = 'sent', attempts = attempts + 1, sent_at = NOW(), last_error = NULL
+		WHERE id = $1`, id)
+	return err
+}
+
+// MarkFailed records a failed attempt. The notification is tried again at
+// retryAt, or given up on if retryAt is nil.
+func (r *NotificationRepository) MarkFailed(ctx context.Context, id int64, message string, retryAt *time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE notification_outbox
+		SET attempts = attempts + 1, last_error = $2,
+			status = CASE WHEN $3::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+			next_attempt_at = COALESCE($3, next_attempt_at)
+		WHERE id = $1`, id, message, retryAt)
+	return err
+}
+
+// ListForUser returns the latest notifications addressed to a user.
+func (r *NotificationRepository) ListForUser(ctx context.Context, userID int64, limit int) ([]*Notification, error) {
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM notification_outbox o
+		WHERE o.user_id = $1
+		ORDER BY o.created_at DESC, o.id DESC
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanNotifications(rows)
+}
+
+// scanNotifications reads notificationColumns from every row and closes rows.
+func scanNotifications(rows *sql.Rows) ([]*Notification, error) {
+	defer rows.Close()
+
+	var notifications []*Notification
+	for rows.Next() {
+		n := &Notification{}
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &n.Recipient, &n.Subject, &n.Body, &n.Status,
+			&n.Attempts, &n.NextAttemptAt, &n.LastError, &n.CreatedAt, &n.SentAt); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
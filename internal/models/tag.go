@@ -0,0 +1,165 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+
+	MaxTagsPerLink = 20
+	MaxTagLength   = 50
+)
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._/-]*$`)
+
+// TagCount is a tag with the number of links using it.
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// NormalizeTags lowercases, trims and de-duplicates tags, turning inner
+// spaces into dashes, and rejects tags that are too long or contain
+// unsupported characters.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := NormalizeTagList(tags)
+	if len(normalized) > MaxTagsPerLink {
+		return nil, fmt.Errorf("a link can have at most %d tags", MaxTagsPerLink)
+	}
+	for _, tag := range normalized {
+		if len(tag) > MaxTagLength {
+			return nil, fmt.Errorf("tag %q must be %d characters or less", tag, MaxTagLength)
+		}
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("tag %q may only contain letters, digits, '.', '_', '/' and '-'", tag)
+		}
+	}
+	return normalized, nil
+}
+
+// NormalizeTagList is the lenient half of NormalizeTags, used for filters.
+func NormalizeTagList(tags []string) []string {
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// setLinkTags replaces the tags of a link inside tx.
+func setLinkTags(ctx context.Context, tx *sql.Tx, linkID int64, tags []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM link_tags WHERE link_id = $1`, linkID); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO tags (name)
+		SELECT unnest($1::text[])
+		ON CONFLICT (name) DO NOTHING`,
+		pq.Array(tags))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO link_tags (link_id, tag_id)
+		SELECT $1, id FROM tags WHERE name = ANY($2)`,
+		linkID, pq.Array(tags))
+	return err
+}
+
+// loadTags fills in the Tags of every link with a single query.
+func (r *LinkRepository) loadTags(ctx context.Context, links []*Link) error {
+	if len(links) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(links))
+	byID := make(map[int64]*Link, len(links))
+	for i, link := range links {
+		link.Tags = []string{}
+		ids[i] = link.ID
+		byID[link.ID] = link
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT lt.link_id, t.name
+		FROM link_tags lt
+		JOIN tags t ON t.id = lt.tag_id
+		WHERE lt.link_id = ANY($1)
+		ORDER BY t.name`,
+		pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var linkID int64
+		var name string
+		if err := rows.Scan(&linkID, &name); err != nil {
+			return err
+		}
+		if link := byID[linkID]; link != nil {
+			link.Tags = append(link.Tags, name)
+		}
+	}
+
+	return rows.Err()
+}
+
+// ListTags returns tags starting with prefix, most used first. Only public
+// links and the user's own private links are counted, so tags used solely
+// on other users' private links are not listed.
+func (r *LinkRepository) ListTags(ctx context.Context, userID int64, prefix string, limit int) ([]TagCount, error) {
+	query := `
+		SELECT t.name, COUNT(lt.link_id)
+		FROM tags t
+		JOIN link_tags lt ON lt.tag_id = t.id
+		JOIN links l ON l.id = lt.link_id
+		WHERE t.name LIKE $1
+		AND (l.visibility = 'public' OR l.created_by = $3)
+		GROUP BY t.name
+		ORDER BY COUNT(lt.link_id) DESC, t.name
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, escapeLike(strings.ToLower(prefix))+"%", limit, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var tag TagCount
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
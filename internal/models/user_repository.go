@@ -3,7 +3,6 @@ package models
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -46,7 +45,9 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*User, e
 	query := `
 		SELECT id, email, password_hash, is_admin, created_at, last_login_at
 		FROM users
-		WHERE email = $1`
+		WHERE LOWER(email) = LOWER($1)
+		ORDER BY email = $1 DESC, id
+		LIMIT 1`
 
 	user := &User{}
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
@@ -59,7 +60,7 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*User, e
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	}
 	if err != nil {
 		log.Printf("Database error getting user: %v", err)
@@ -69,6 +70,32 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*User, e
 	return user, nil
 }
 
+func (r *UserRepository) GetByID(ctx context.Context, id int64) (*User, error) {
+	query := `
+		SELECT id, email, password_hash, is_admin, created_at, last_login_at
+		FROM users
+		WHERE id = $1`
+
+	user := &User{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.Email,
+		&user.PasswordHash,
+		&user.IsAdmin,
+		&user.CreatedAt,
+		&user.LastLoginAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
 func (r *UserRepository) VerifyPassword(user *User, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
 	return err == nil
@@ -106,7 +133,7 @@ func (r *UserRepository) SetAdminStatus(ctx context.Context, userID int64, isAdm
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("user not found")
+		return ErrUserNotFound
 	}
 
 	return nil
@@ -0,0 +1,341 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/db"
+	"github.com/lib/pq"
+)
+
+// Webhook event types.
+const (
+	WebhookLinkCreated   = "link.created"
+	WebhookLinkUpdated   = "link.updated"
+	WebhookLinkDeleted   = "link.deleted"
+	WebhookLinkExpired   = "link.expired"
+	WebhookLinkThreshold = "link.threshold_reached"
+)
+
+// WebhookEventTypes lists every event type an endpoint can subscribe to.
+var WebhookEventTypes = []string{
+	WebhookLinkCreated, WebhookLinkUpdated, WebhookLinkDeleted, WebhookLinkExpired, WebhookLinkThreshold,
+}
+
+// ValidWebhookEvent reports whether eventType is a known event type.
+func ValidWebhookEvent(eventType string) bool {
+	for _, t := range WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed" // gave up after too many attempts
+)
+
+// WebhookEndpoint receives the events it subscribes to, for every link if
+// AllLinks is set and otherwise for the links of the user who registered it.
+type WebhookEndpoint struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"` // only returned when the endpoint is created
+	Events    []string  `json:"events"`
+	AllLinks  bool      `json:"allLinks"`
+	CreatedBy int64     `json:"createdBy"`
+	IsActive  bool      `json:"isActive"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// WebhookDelivery is one event queued for, or delivered to, one endpoint.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	EndpointID     int64           `json:"endpointId"`
+	EventID        string          `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"nextAttemptAt"`
+	LastStatusCode int             `json:"lastStatusCode,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	LastAttemptAt  *time.Time      `json:"lastAttemptAt,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+
+	// Where to deliver it, filled in by ClaimDueDeliveries.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+type WebhookRepository struct {
+	db *db.DB
+}
+
+func NewWebhookRepository(db *db.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+const webhookEndpointColumns = `id, url, secret, events, all_links, created_by, is_active, created_at, updated_at`
+
+func scanWebhookEndpoint(row rowScanner) (*WebhookEndpoint, error) {
+	e := &WebhookEndpoint{}
+	err := row.Scan(&e.ID, &e.URL, &e.Secret, pq.Array(&e.Events), &e.AllLinks, &e.CreatedBy,
+		&e.IsActive, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *WebhookRepository) queryEndpoints(ctx context.Context, query string, args ...interface{}) ([]*WebhookEndpoint, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var endpoints []*WebhookEndpoint
+	for rows.Next() {
+		e, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, e)
+	}
+	return endpoints, rows.Err()
+}
+
+func (r *WebhookRepository) CreateEndpoint(ctx context.Context, e *WebhookEndpoint) error {
+	query := `
+		INSERT INTO webhook_endpoints (url, secret, events, all_links, created_by, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRowContext(ctx, query, e.URL, e.Secret, pq.Array(e.Events), e.AllLinks, e.CreatedBy, e.IsActive).
+		Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+}
+
+func (r *WebhookRepository) GetEndpoint(ctx context.Context, id int64) (*WebhookEndpoint, error) {
+	e, err := scanWebhookEndpoint(r.db.QueryRowContext(ctx,
+		`SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return e, err
+}
+
+// ListEndpoints returns the endpoints registered by a user, or by anyone if
+// userID is zero.
+func (r *WebhookRepository) ListEndpoints(ctx context.Context, userID int64) ([]*WebhookEndpoint, error) {
+	return r.queryEndpoints(ctx, `
+		SELECT `+webhookEndpointColumns+`
+		FROM webhook_endpoints
+		WHERE $1 = 0 OR created_by = $1
+		ORDER BY id`, userID)
+}
+
+// UpdateEndpoint stores the URL, events, scope and status of an endpoint.
+func (r *WebhookRepository) UpdateEndpoint(ctx context.Context, e *WebhookEndpoint) error {
+	query := `
+		UPDATE webhook_endpoints
+		SET url = $2, events = $3, all_links = $4, is_active = $5, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, e.ID, e.URL, pq.Array(e.Events), e.AllLinks, e.IsActive).
+		Scan(&e.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+// DeleteEndpoint removes an endpoint along with its deliveries.
+func (r *WebhookRepository) DeleteEndpoint(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// EndpointsFor returns the active endpoints subscribed to eventType for a
+// link owned by ownerID.
+func (r *WebhookRepository) EndpointsFor(ctx context.Context, ownerID int64, eventType string) ([]*WebhookEndpoint, error) {
+	return r.queryEndpoints(ctx, `
+		SELECT `+webhookEndpointColumns+`
+		FROM webhook_endpoints
+		WHERE is_active AND (all_links OR created_by = $1) AND $2 = ANY(events)
+		ORDER BY id`, ownerID, eventType)
+}
+
+// EndpointsForEvent returns the active endpoints subscribed to eventType,
+// whoever's links they cover.
+func (r *WebhookRepository) EndpointsForEvent(ctx context.Context, eventType string) ([]*WebhookEndpoint, error) {
+	return r.queryEndpoints(ctx, `
+		SELECT `+webhookEndpointColumns+`
+		FROM webhook_endpoints
+		WHERE is_active AND $1 = ANY(events)
+		ORDER BY id`, eventType)
+}
+
+// EnqueueChange queues the deliveries for a link change and removes the
+// change from the outbox, in one transaction. A change that is already gone,
+// having been processed elsewhere, queues nothing.
+func (r *WebhookRepository) EnqueueChange(ctx context.Context, changeID int64, deliveries []*WebhookDelivery) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM link_changes WHERE id = $1`, changeID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	if len(deliveries) > 0 {
+		endpointIDs := make([]int64, len(deliveries))
+		eventIDs := make([]string, len(deliveries))
+		eventTypes := make([]string, len(deliveries))
+		payloads := make([]string, len(deliveries))
+		for i, d := range deliveries {
+			endpointIDs[i], eventIDs[i], eventTypes[i], payloads[i] = d.EndpointID, d.EventID, d.EventType, string(d.Payload)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload)
+			SELECT endpoint_id, event_id, event_type, payload::jsonb
+			FROM UNNEST($1::int[], $2::text[], $3::text[], $4::text[]) AS d(endpoint_id, event_id, event_type, payload)`,
+			pq.Array(endpointIDs), pq.Array(eventIDs), pq.Array(eventTypes), pq.Array(payloads))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// EnqueueDeliveries queues one event for delivery to each of endpoints.
+func (r *WebhookRepository) EnqueueDeliveries(ctx context.Context, endpoints []*WebhookEndpoint, eventID, eventType string, payload []byte) error {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(endpoints))
+	for i, e := range endpoints {
+		ids[i] = e.ID
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload)
+		SELECT id, $2, $3, $4 FROM UNNEST($1::int[]) AS id`,
+		pq.Array(ids), eventID, eventType, payload)
+	return err
+}
+
+const webhookDeliveryColumns = `
+	d.id, d.endpoint_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at,
+	COALESCE(d.last_status_code, 0), COALESCE(d.last_error, ''), d.last_attempt_at, d.created_at, d.delivered_at`
+
+func scanWebhookDeliveries(rows *sql.Rows, extra ...func(*WebhookDelivery) []interface{}) ([]*WebhookDelivery, error) {
+	defer rows.Close()
+
+	var deliveries []*WebhookDelivery
+	for rows.Next() {
+		d := &WebhookDelivery{}
+		dest := []interface{}{
+			&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastStatusCode, &d.LastError, &d.LastAttemptAt, &d.CreatedAt, &d.DeliveredAt,
+		}
+		for _, fn := range extra {
+			dest = append(dest, fn(d)...)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries to active
+// endpoints that are due, with where to send them, and holds them back from
+// other callers for lease.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		FROM (
+			SELECT wd.id, e.url, e.secret
+			FROM webhook_deliveries wd
+			JOIN webhook_endpoints e ON e.id = wd.endpoint_id
+			WHERE wd.status = 'pending' AND wd.next_attempt_at <= NOW() AND e.is_active
+			ORDER BY wd.next_attempt_at, wd.id
+			LIMIT $1
+			FOR UPDATE OF wd SKIP LOCKED
+		) due
+		WHERE d.id = due.id
+		RETURNING ` + webhookDeliveryColumns + `, due.url, due.secret`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, int64(lease/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhookDeliveries(rows, func(d *WebhookDelivery) []interface{} {
+		return []interface{}{&d.URL, &d.Secret}
+	})
+}
+
+// MarkDelivered records a successful delivery.
+func (r *WebhookRepository) MarkDelivered(ctx context.Context, id int64, statusCode int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', attempts = attempts + 1, last_status_code = $2, last_error = NULL,
+			last_attempt_at = NOW(), delivered_at = NOW()
+		WHERE id = $1`, id, statusCode)
+	return err
+}
+
+// MarkDeliveryFailed records a failed attempt. The delivery is tried again
+// at retryAt, or given up on if retryAt is nil.
+func (r *WebhookRepository) MarkDeliveryFailed(ctx context.Context, id int64, statusCode int, message string, retryAt *time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1, last_status_code = NULLIF($2, 0), last_error = $3, last_attempt_at = NOW(),
+			status = CASE WHEN $4::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+			next_attempt_at = COALESCE($4, next_attempt_at)
+		WHERE id = $1`, id, statusCode, message, retryAt)
+	return err
+}
+
+// ListDeliveries returns the latest deliveries to an endpoint, optionally
+// only those with the given status.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, endpointID int64, status string, limit int) ([]*WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.endpoint_id = $1 AND ($2 = '' OR d.status = $2)
+		ORDER BY d.created_at DESC, d.id DESC
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, endpointID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhookDeliveries(rows)
+}
@@ -0,0 +1,14 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// LogNotifier writes messages to the server log instead of sending them.
+type LogNotifier struct{}
+
+func (LogNotifier) Send(ctx context.Context, msg *Message) error {
+	log.Printf("Notification to %s (%s): %s\n%s", msg.To, msg.Kind, msg.Subject, msg.Body)
+	return nil
+}
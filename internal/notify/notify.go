@@ -0,0 +1,60 @@
+// Package notify reaches users through a configurable channel: email over
+// SMTP, a generic webhook, the server log, or memory for tests. Messages are
+// rendered from templates and go through the outbox in
+// notification_outbox, which retries them until they are delivered.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Channels a Notifier can deliver through.
+const (
+	ChannelSMTP    = "smtp"
+	ChannelWebhook = "webhook"
+	ChannelLog     = "log"
+	ChannelMemory  = "memory"
+)
+
+// Message is a rendered notification addressed to one user.
+type Message struct {
+	UserID  int64  `json:"userId,omitempty"`
+	Kind    string `json:"kind"`
+	To      string `json:"to"` // email address
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Notifier delivers messages. Send returns an error if the message may not
+// have been delivered; the outbox will try again.
+type Notifier interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+type Config struct {
+	Channel      string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	WebhookURL   string
+}
+
+// New returns the notifier for cfg.Channel.
+func New(cfg Config) (Notifier, error) {
+	switch cfg.Channel {
+	case ChannelSMTP:
+		return NewSMTPNotifier(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom), nil
+	case ChannelWebhook:
+		return NewWebhookNotifier(cfg.WebhookURL, &http.Client{Timeout: 30 * time.Second}), nil
+	case ChannelLog, "":
+		return LogNotifier{}, nil
+	case ChannelMemory:
+		return NewMemoryNotifier(), nil
+	}
+	return nil, fmt.Errorf("unknown notification channel %q", cfg.Channel)
+}
@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+)
+
+// Service turns events into notifications: it checks that the recipient
+// wants them, renders them and puts them in the outbox.
+type Service struct {
+	repo      *models.NotificationRepository
+	userRepo  *models.UserRepository
+	linkRepo  *models.LinkRepository
+	templates *Templates
+}
+
+func NewService(repo *models.NotificationRepository, userRepo *models.UserRepository, linkRepo *models.LinkRepository, templates *Templates) *Service {
+	return &Service{
+		repo:      repo,
+		userRepo:  userRepo,
+		linkRepo:  linkRepo,
+		templates: templates,
+	}
+}
+
+// Notify queues a notification of kind for a user, unless they turned that
+// kind off. data is passed to the kind's templates.
+func (s *Service) Notify(ctx context.Context, userID int64, kind string, data interface{}) error {
+	wants, err := s.repo.Wants(ctx, userID, kind)
+	if err != nil || !wants {
+		return err
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	subject, body, err := s.templates.Render(kind, data)
+	if err != nil {
+		return err
+	}
+	return s.repo.Enqueue(ctx, &models.Notification{
+		UserID:    userID,
+		Kind:      kind,
+		Recipient: user.Email,
+		Subject:   subject,
+		Body:      body,
+	})
+}
+
+// auditDetails are the fields of audit entry details that notifications
+// show.
+type auditDetails struct {
+	Alias      string                            `json:"alias"`
+	Reason     string                            `json:"reason"`
+	BulkAction string                            `json:"bulkAction"`
+	Changes    map[string]map[string]interface{} `json:"changes"`
+}
+
+// AuditRecorded tells link owners about admin edits to their links and
+// about links transferred to them. Register it with
+// AuditLogRepository.AddListener.
+func (s *Service) AuditRecorded(ctx context.Context, entry *models.AuditLog) {
+	var kind string
+	switch entry.Action {
+	case models.AuditLinkAdminEdit:
+		kind = models.NotifyAdminEdit
+	case models.AuditLinkTransferred:
+		kind = models.NotifyLinkTransferred
+	default:
+		return
+	}
+	if entry.TargetUserID == nil || (entry.ActorID != nil && *entry.ActorID == *entry.TargetUserID) {
+		return
+	}
+
+	var details auditDetails
+	if entry.Details != nil {
+		if err := json.Unmarshal(entry.Details, &details); err != nil {
+			log.Printf("Error reading audit entry %d: %v", entry.ID, err)
+			return
+		}
+	}
+	data := map[string]interface{}{
+		"Alias":      details.Alias,
+		"Reason":     details.Reason,
+		"BulkAction": details.BulkAction,
+		"Changes":    details.Changes,
+	}
+	if entry.LinkID != nil {
+		if link, err := s.linkRepo.GetByID(ctx, *entry.LinkID); err == nil {
+			data["Alias"], data["DestinationURL"] = link.Alias, link.DestinationURL
+		}
+	}
+	if entry.ActorID != nil {
+		if actor, err := s.userRepo.GetByID(ctx, *entry.ActorID); err == nil {
+			data["Actor"] = actor.Email
+		}
+	}
+
+	if err := s.Notify(ctx, *entry.TargetUserID, kind, data); err != nil {
+		log.Printf("Error queueing %s notification for audit entry %d: %v", kind, entry.ID, err)
+	}
+}
+
+// LinkBroken alerts the owner of a link whose destination keeps failing its
+// checks.
+func (s *Service) LinkBroken(ctx context.Context, link *models.Link, health *models.LinkHealth) error {
+	return s.Notify(ctx, link.CreatedBy, models.NotifyLinkBroken, map[string]interface{}{
+		"Alias":          link.Alias,
+		"DestinationURL": health.DestinationURL,
+		"StatusCode":     health.StatusCode,
+		"Error":          health.Error,
+		"Failures":       health.ConsecutiveFailures,
+		"CheckedAt":      health.CheckedAt,
+	})
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/linkio"
+)
+
+// messageTemplates defines a subject and a body template for every
+// notification kind, named "<kind>.subject" and "<kind>.body".
+const messageTemplates = `
+{{define "link_digest.subject"}}{{len .Items}} of your go links need a look{{end}}
+{{define "link_digest.body"}}These go links look like they are no longer needed. Keep the
+ones you still use; archive or delete the rest.
+{{range $item := .Items}}
+go/{{.Alias}} -> {{.DestinationURL}}
+{{- range .Reasons}}
+{{- if eq . "unused"}}
+  {{if $item.LastAccessedAt}}Not used since {{date $item.LastAccessedAt}}{{else}}Never used{{end}}
+{{- else if eq . "owner_inactive"}}
+  You have not signed in for a while
+{{- else if eq . "expiring"}}
+  Expires on {{date $item.ExpiresAt}}
+{{- end}}
+{{- end}}
+  Keep:    {{.ExtendURL}}
+  Archive: {{.ArchiveURL}}
+  Delete:  {{.DeleteURL}}
+{{end}}
+These links work without signing in. Manage all your links at {{baseURL}}/
+{{end}}
+
+{{define "link_transferred.subject"}}go/{{.Alias}} is now yours{{end}}
+{{define "link_transferred.body"}}{{if .Actor}}{{.Actor}}{{else}}An admin{{end}} made you the owner of go/{{.Alias}}.
+
+{{shortURL .Alias}} -> {{.DestinationURL}}
+{{if .Reason}}
+Reason: {{.Reason}}
+{{end}}
+You can now edit it at {{baseURL}}/
+{{end}}
+
+{{define "link_broken.subject"}}go/{{.Alias}} looks broken{{end}}
+{{define "link_broken.body"}}The destination of go/{{.Alias}} failed its last {{.Failures}} checks.
+
+{{.DestinationURL}}
+{{if .StatusCode}}responded with status {{.StatusCode}}{{else}}could not be reached: {{.Error}}{{end}} on {{date .CheckedAt}}.
+
+If the page moved, update the link at {{baseURL}}/
+{{end}}
+
+{{define "admin_edit.subject"}}An admin changed go/{{.Alias}}{{end}}
+{{define "admin_edit.body"}}{{if .Actor}}{{.Actor}}{{else}}An admin{{end}} changed your link go/{{.Alias}}.
+{{- if .BulkAction}}
+  Bulk action: {{.BulkAction}}
+{{- end}}
+{{range $field, $change := .Changes}}
+  {{$field}}: {{value $change.from}} -> {{value $change.to}}
+{{- end}}
+{{if .Reason}}
+Reason: {{.Reason}}
+{{end}}
+See the history of your links at {{baseURL}}/
+{{end}}
+`
+
+// Templates renders notifications. Templates can link to the server with
+// baseURL and shortURL.
+type Templates struct {
+	set *template.Template
+}
+
+func NewTemplates(baseURL string) *Templates {
+	baseURL = strings.TrimRight(baseURL, "/")
+	funcs := template.FuncMap{
+		"baseURL":  func() string { return baseURL },
+		"shortURL": func(alias string) string { return linkio.ShortURL(baseURL, alias) },
+		"date":     formatDate,
+		"value":    formatValue,
+	}
+	return &Templates{set: template.Must(template.New("notifications").Funcs(funcs).Parse(messageTemplates))}
+}
+
+// Render returns the subject and body of a notification of kind.
+func (t *Templates) Render(kind string, data interface{}) (string, string, error) {
+	if t.set.Lookup(kind+".subject") == nil {
+		return "", "", fmt.Errorf("no template for notifications of kind %q", kind)
+	}
+
+	var subject, body bytes.Buffer
+	if err := t.set.ExecuteTemplate(&subject, kind+".subject", data); err != nil {
+		return "", "", err
+	}
+	if err := t.set.ExecuteTemplate(&body, kind+".body", data); err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(subject.String()), strings.TrimSpace(body.String()) + "\n", nil
+}
+
+func formatDate(v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format("January 2, 2006")
+	case *time.Time:
+		if t != nil {
+			return t.Format("January 2, 2006")
+		}
+	}
+	return "an unknown date"
+}
+
+// formatValue shows a changed field's value as it came out of the audit
+// log's JSON.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "(none)"
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return formatDate(t)
+		}
+		return v
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// maxGenerateAttempts bounds how many candidates Generate draws before
+// giving up on finding one the alias policy accepts.
+const maxGenerateAttempts = 20
+
+// AliasGenerator draws random aliases for links created without one. Each
+// character comes from crypto/rand, so generated aliases cannot be predicted
+// from earlier ones and enumerated.
+type AliasGenerator struct {
+	alphabet []rune
+	length   int
+	policy   *AliasPolicy
+}
+
+// NewAliasGenerator creates a generator of length-character aliases drawn
+// from alphabet. Candidates the alias policy rejects are skipped.
+func NewAliasGenerator(alphabet string, length int, policy *AliasPolicy) (*AliasGenerator, error) {
+	runes := []rune(alphabet)
+	seen := make(map[rune]bool, len(runes))
+	for _, r := range runes {
+		if seen[r] {
+			return nil, fmt.Errorf("generated alias alphabet repeats %q", r)
+		}
+		if r == '/' || strings.TrimSpace(string(r)) == "" {
+			return nil, fmt.Errorf("generated alias alphabet may not contain %q", r)
+		}
+		seen[r] = true
+	}
+	if len(runes) < 2 {
+		return nil, errors.New("generated alias alphabet needs at least 2 characters")
+	}
+	if length < 3 || length > 32 {
+		return nil, errors.New("generated alias length must be between 3 and 32")
+	}
+
+	return &AliasGenerator{alphabet: runes, length: length, policy: policy}, nil
+}
+
+// Generate returns a random alias the alias policy accepts. It does not
+// check that the alias is free; callers retry on ErrDuplicate.
+func (g *AliasGenerator) Generate() (string, error) {
+	max := big.NewInt(int64(len(g.alphabet)))
+	alias := make([]rune, g.length)
+
+	for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+		for i := range alias {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", fmt.Errorf("failed to read random bytes: %w", err)
+			}
+			alias[i] = g.alphabet[n.Int64()]
+		}
+
+		if g.policy == nil || g.policy.Check(string(alias)) == nil {
+			return string(alias), nil
+		}
+	}
+	return "", errors.New("could not generate an alias the alias policy accepts")
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+)
+
+// AliasPolicy decides which aliases users may claim. Reserved words and
+// blocked patterns come from the configuration and from alias_rules, which
+// admins manage at runtime.
+type AliasPolicy struct {
+	ruleRepo        *models.AliasRuleRepository
+	defaultReserved []string
+	defaultBlocked  []string
+
+	mu       sync.RWMutex
+	reserved map[string]bool
+	blocked  []*regexp.Regexp
+}
+
+func NewAliasPolicy(ruleRepo *models.AliasRuleRepository, reserved, blockedPatterns []string) (*AliasPolicy, error) {
+	for _, pattern := range blockedPatterns {
+		if _, err := compileAliasPattern(pattern); err != nil {
+			return nil, fmt.Errorf("invalid blocked alias pattern %q: %w", pattern, err)
+		}
+	}
+
+	p := &AliasPolicy{
+		ruleRepo:        ruleRepo,
+		defaultReserved: reserved,
+		defaultBlocked:  blockedPatterns,
+	}
+	p.rebuild(nil)
+	return p, nil
+}
+
+// Reload rebuilds the policy from the configured defaults and the rules
+// stored in the database.
+func (p *AliasPolicy) Reload(ctx context.Context) error {
+	rules, err := p.ruleRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	p.rebuild(rules)
+	return nil
+}
+
+func (p *AliasPolicy) rebuild(rules []*models.AliasRule) {
+	reserved := make(map[string]bool)
+	for _, word := range p.defaultReserved {
+		reserved[normalizeAlias(word)] = true
+	}
+
+	var blocked []*regexp.Regexp
+	for _, pattern := range p.defaultBlocked {
+		re, _ := compileAliasPattern(pattern)
+		blocked = append(blocked, re)
+	}
+
+	for _, rule := range rules {
+		switch rule.Kind {
+		case models.AliasRuleReserved:
+			reserved[normalizeAlias(rule.Pattern)] = true
+		case models.AliasRuleBlocked:
+			// Patterns are validated before they are stored, so a failure here
+			// means the row was edited by hand; skip it rather than fail.
+			if re, err := compileAliasPattern(rule.Pattern); err == nil {
+				blocked = append(blocked, re)
+			}
+		}
+	}
+
+	p.mu.Lock()
+	p.reserved = reserved
+	p.blocked = blocked
+	p.mu.Unlock()
+}
+
+// Check returns a *PolicyError if alias is reserved or matches a blocked
+// pattern. The first path segment is checked too, so "api/foo" is reserved
+// whenever "api" is.
+func (p *AliasPolicy) Check(alias string) error {
+	normalized := normalizeAlias(alias)
+	segment := strings.SplitN(normalized, "/", 2)[0]
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.reserved[normalized] || p.reserved[segment] {
+		return newPolicyError("alias", "alias_reserved", fmt.Sprintf("alias %q is reserved", alias))
+	}
+	for _, re := range p.blocked {
+		if re.MatchString(normalized) {
+			return newPolicyError("alias", "alias_blocked", fmt.Sprintf("alias %q is not allowed", alias))
+		}
+	}
+
+	return nil
+}
+
+// Defaults returns the reserved words and blocked patterns from the
+// configuration, which cannot be removed at runtime.
+func (p *AliasPolicy) Defaults() (reserved, blocked []string) {
+	return p.defaultReserved, p.defaultBlocked
+}
+
+func (p *AliasPolicy) ListRules(ctx context.Context) ([]*models.AliasRule, error) {
+	return p.ruleRepo.List(ctx)
+}
+
+func (p *AliasPolicy) AddRule(ctx context.Context, rule *models.AliasRule) error {
+	rule.Pattern = strings.TrimSpace(rule.Pattern)
+	if rule.Pattern == "" {
+		return newPolicyError("pattern", "pattern_required", "pattern is required")
+	}
+
+	switch rule.Kind {
+	case models.AliasRuleReserved:
+		rule.Pattern = normalizeAlias(rule.Pattern)
+	case models.AliasRuleBlocked:
+		if _, err := compileAliasPattern(rule.Pattern); err != nil {
+			return newPolicyError("pattern", "pattern_invalid", fmt.Sprintf("invalid pattern: %v", err))
+		}
+	default:
+		return newPolicyError("kind", "kind_invalid", "kind must be reserved or blocked")
+	}
+
+	if err := p.ruleRepo.Create(ctx, rule); err != nil {
+		return err
+	}
+	return p.Reload(ctx)
+}
+
+func (p *AliasPolicy) RemoveRule(ctx context.Context, id int64) error {
+	if err := p.ruleRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	return p.Reload(ctx)
+}
+
+func normalizeAlias(alias string) string {
+	return strings.ToLower(strings.TrimSpace(alias))
+}
+
+func compileAliasPattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
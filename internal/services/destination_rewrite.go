@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+)
+
+// Ways a rewrite matches destinations.
+const (
+	RewritePrefix = "prefix" // destinations starting with Match, replaced once
+	RewriteRegex  = "regex"  // Go regular expression; Replace may use $1 etc.
+)
+
+const maxRewritePatternLength = 1000
+
+var ErrRewriteInvalid = errors.New("some rewritten destinations are not allowed")
+
+// RewriteSpec is a find-and-replace over link destinations.
+type RewriteSpec struct {
+	Mode    string
+	Match   string
+	Replace string
+}
+
+// RewriteItem is one link a rewrite changes. Error explains why its new
+// destination would be refused.
+type RewriteItem struct {
+	LinkID  int64  `json:"id"`
+	Alias   string `json:"alias"`
+	OwnerID int64  `json:"-"`
+	Before  string `json:"before"`
+	After   string `json:"after"`
+	Error   string `json:"error,omitempty"`
+}
+
+// DestinationRewriter moves many links to new destinations at once, for
+// when a site changes domain. New destinations go through the URL policy
+// like any other edit.
+type DestinationRewriter struct {
+	linkRepo  *models.LinkRepository
+	urlPolicy *URLPolicy
+}
+
+func NewDestinationRewriter(linkRepo *models.LinkRepository, urlPolicy *URLPolicy) *DestinationRewriter {
+	return &DestinationRewriter{
+		linkRepo:  linkRepo,
+		urlPolicy: urlPolicy,
+	}
+}
+
+// Plan lists the links within scope that spec would change, with their
+// destinations before and after.
+func (rw *DestinationRewriter) Plan(ctx context.Context, spec RewriteSpec, scope models.ListOptions) ([]RewriteItem, error) {
+	rewrite, err := compileRewrite(spec)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Mode == RewritePrefix {
+		scope.DestPrefix = spec.Match
+	}
+	scope.SortBy = "alias_asc"
+
+	items := []RewriteItem{}
+	err = rw.linkRepo.EachLink(ctx, scope, func(link *models.Link) error {
+		after := rewrite(link.DestinationURL)
+		if after == link.DestinationURL {
+			return nil
+		}
+		if len(items) == models.MaxBulkLinks {
+			return models.ErrBulkTooLarge
+		}
+
+		item := RewriteItem{LinkID: link.ID, Alias: link.Alias, OwnerID: link.CreatedBy, Before: link.DestinationURL}
+		destination, err := rw.urlPolicy.Apply(ctx, link.Alias, after)
+		if err != nil {
+			item.After, item.Error = after, err.Error()
+		} else {
+			item.After = destination
+		}
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Apply makes the planned changes in one transaction, recording a revision
+// for each link and the audit entries given. Nothing changes if any new
+// destination was refused or any link moved since it was planned.
+func (rw *DestinationRewriter) Apply(ctx context.Context, items []RewriteItem, actorID int64, reason string, audit ...*models.AuditLog) error {
+	changes := make([]models.DestinationChange, 0, len(items))
+	for _, item := range items {
+		if item.Error != "" {
+			return ErrRewriteInvalid
+		}
+		changes = append(changes, models.DestinationChange{LinkID: item.LinkID, Before: item.Before, After: item.After})
+	}
+	return rw.linkRepo.RewriteDestinations(ctx, changes, actorID, reason, audit...)
+}
+
+func compileRewrite(spec RewriteSpec) (func(string) string, error) {
+	if spec.Match == "" {
+		return nil, errors.New("match is required")
+	}
+	if len(spec.Match) > maxRewritePatternLength || len(spec.Replace) > maxRewritePatternLength {
+		return nil, fmt.Errorf("match and replace must be %d characters or less", maxRewritePatternLength)
+	}
+
+	switch spec.Mode {
+	case RewritePrefix:
+		return func(destination string) string {
+			if !strings.HasPrefix(destination, spec.Match) {
+				return destination
+			}
+			return spec.Replace + destination[len(spec.Match):]
+		}, nil
+	case RewriteRegex:
+		re, err := regexp.Compile(spec.Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+		return func(destination string) string {
+			return re.ReplaceAllString(destination, spec.Replace)
+		}, nil
+	}
+	return nil, fmt.Errorf("mode must be %s or %s", RewritePrefix, RewriteRegex)
+}
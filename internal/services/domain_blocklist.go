@@ -0,0 +1,127 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DomainBlocklist holds the known-bad domains maintained by the security team
+// in a plain-text file: one domain per line, "#" starts a comment. Lines in
+// hosts-file format ("0.0.0.0 bad.example") are accepted too.
+type DomainBlocklist struct {
+	path string
+
+	mu      sync.RWMutex
+	domains map[string]bool
+	modTime time.Time
+	size    int64
+}
+
+// NewDomainBlocklist creates a blocklist backed by path. An empty path gives
+// a blocklist that never matches.
+func NewDomainBlocklist(path string) *DomainBlocklist {
+	return &DomainBlocklist{
+		path:    path,
+		domains: make(map[string]bool),
+	}
+}
+
+func (b *DomainBlocklist) Path() string {
+	return b.path
+}
+
+// Reload reads the file again if it changed since the last load and reports
+// whether the contents were replaced.
+func (b *DomainBlocklist) Reload() (bool, error) {
+	if b.path == "" {
+		return false, nil
+	}
+
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat blocklist: %w", err)
+	}
+
+	b.mu.RLock()
+	unchanged := info.ModTime().Equal(b.modTime) && info.Size() == b.size
+	b.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	domains, err := readDomainList(b.path)
+	if err != nil {
+		return false, err
+	}
+
+	b.mu.Lock()
+	b.domains = domains
+	b.modTime = info.ModTime()
+	b.size = info.Size()
+	b.mu.Unlock()
+
+	return true, nil
+}
+
+// Contains reports whether host or any of its parent domains is listed.
+func (b *DomainBlocklist) Contains(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for host != "" {
+		if b.domains[host] {
+			return true
+		}
+		i := strings.Index(host, ".")
+		if i < 0 {
+			break
+		}
+		host = host[i+1:]
+	}
+	return false
+}
+
+func (b *DomainBlocklist) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.domains)
+}
+
+func readDomainList(path string) (map[string]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blocklist: %w", err)
+	}
+	defer file.Close()
+
+	domains := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		domain := strings.ToLower(fields[len(fields)-1])
+		domain = strings.TrimPrefix(domain, "*.")
+		domain = strings.Trim(domain, ".")
+		if domain != "" {
+			domains[domain] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read blocklist: %w", err)
+	}
+
+	return domains, nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+)
+
+// trackingParams are query parameters that only record where a click came
+// from. Any parameter starting with "utm_" is one too.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_ga":     true,
+	"_gl":     true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+}
+
+// NormalizeDestination reduces a destination to what decides where it
+// leads, so links that differ only in scheme, host case, a trailing slash,
+// tracking parameters or query order compare equal.
+func NormalizeDestination(destination string) string {
+	u, err := url.Parse(strings.TrimSpace(destination))
+	if err != nil || u.Host == "" {
+		return strings.TrimRight(strings.TrimSpace(destination), "/")
+	}
+
+	query := u.Query()
+	for name := range query {
+		if trackingParams[strings.ToLower(name)] || strings.HasPrefix(strings.ToLower(name), "utm_") {
+			query.Del(name)
+		}
+	}
+
+	key := strings.ToLower(u.Host) + strings.TrimRight(u.EscapedPath(), "/")
+	if len(query) > 0 {
+		key += "?" + query.Encode()
+	}
+	if u.Fragment != "" {
+		key += "#" + u.Fragment
+	}
+	return key
+}
+
+// DuplicateGroup is a set of links leading to the same place, busiest
+// first.
+type DuplicateGroup struct {
+	Destination string         `json:"destination"` // normalized
+	Links       []*models.Link `json:"links"`
+}
+
+// FindDuplicates groups the links within scope by normalized destination
+// and returns the groups with more than one link, largest first. Links
+// already merged into another are left out.
+func FindDuplicates(ctx context.Context, linkRepo *models.LinkRepository, scope models.ListOptions) ([]DuplicateGroup, error) {
+	byKey := make(map[string][]*models.Link)
+	err := linkRepo.EachLink(ctx, scope, func(link *models.Link) error {
+		if link.MergedInto == nil {
+			key := NormalizeDestination(link.DestinationURL)
+			byKey[key] = append(byKey[key], link)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []DuplicateGroup{}
+	for key, links := range byKey {
+		if len(links) < 2 {
+			continue
+		}
+		sort.SliceStable(links, func(i, j int) bool {
+			return links[i].Stats.TotalCount > links[j].Stats.TotalCount
+		})
+		groups = append(groups, DuplicateGroup{Destination: key, Links: links})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].Links) != len(groups[j].Links) {
+			return len(groups[i].Links) > len(groups[j].Links)
+		}
+		return groups[i].Destination < groups[j].Destination
+	})
+	return groups, nil
+}
@@ -0,0 +1,17 @@
+package services
+
+// PolicyError describes why a link was rejected by one of the link policies.
+// Handlers return it to clients as a structured error.
+type PolicyError struct {
+	Code    string `json:"code"`
+	Field   string `json:"field"`
+	Message string `json:"error"`
+}
+
+func (e *PolicyError) Error() string {
+	return e.Message
+}
+
+func newPolicyError(field, code, message string) *PolicyError {
+	return &PolicyError{Code: code, Field: field, Message: message}
+}
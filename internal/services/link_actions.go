@@ -0,0 +1,112 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+)
+
+// Actions an owner can take on a link from a tokenized URL, without signing
+// in. Archiving deactivates the link, which the owner can undo later.
+const (
+	LinkActionExtend  = "extend"
+	LinkActionArchive = "archive"
+	LinkActionDelete  = "delete"
+)
+
+var (
+	ErrInvalidActionToken = errors.New("invalid link action token")
+	ErrActionTokenExpired = errors.New("link action token has expired")
+	// ErrActionTokenUsed means the link changed since the token was issued,
+	// either through this or another action or an edit by its owner.
+	ErrActionTokenUsed = errors.New("link changed since the link action token was issued")
+)
+
+// LinkActionClaims is what a link action token grants: one action on one
+// link, for the user who owned it when the token was issued and as long as
+// the link has not changed since.
+type LinkActionClaims struct {
+	LinkID    int64  `json:"l"`
+	OwnerID   int64  `json:"o"`
+	Action    string `json:"a"`
+	Version   int64  `json:"v"` // the link's updated_at in microseconds
+	ExpiresAt int64  `json:"e"` // unix seconds
+}
+
+// LinkActionTokens issues and verifies link action tokens, signed with
+// HMAC-SHA256 like page cursors.
+type LinkActionTokens struct {
+	key []byte
+	ttl time.Duration
+}
+
+func NewLinkActionTokens(secret string, ttl time.Duration) *LinkActionTokens {
+	return &LinkActionTokens{key: []byte(secret), ttl: ttl}
+}
+
+// Issue makes a token allowing the link's current owner to take action on
+// it until the token expires or the link changes.
+func (t *LinkActionTokens) Issue(link *models.Link, action string) string {
+	payload, _ := json.Marshal(LinkActionClaims{
+		LinkID:    link.ID,
+		OwnerID:   link.CreatedBy,
+		Action:    action,
+		Version:   link.UpdatedAt.UnixMicro(),
+		ExpiresAt: time.Now().Add(t.ttl).Unix(),
+	})
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(t.sign(encoded))
+}
+
+// Verify checks the signature and expiry of token and returns its claims.
+func (t *LinkActionTokens) Verify(token string) (*LinkActionClaims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidActionToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, t.sign(encoded)) {
+		return nil, ErrInvalidActionToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidActionToken
+	}
+	var claims LinkActionClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidActionToken
+	}
+	switch claims.Action {
+	case LinkActionExtend, LinkActionArchive, LinkActionDelete:
+	default:
+		return nil, ErrInvalidActionToken
+	}
+	if time.Now().Unix() > claims.ExpiresAt {
+		return nil, ErrActionTokenExpired
+	}
+	return &claims, nil
+}
+
+// Check reports whether the claims still hold for link: same owner, and
+// no change since the token was issued.
+func (c *LinkActionClaims) Check(link *models.Link) error {
+	if link.CreatedBy != c.OwnerID || link.UpdatedAt.UnixMicro() != c.Version {
+		return ErrActionTokenUsed
+	}
+	return nil
+}
+
+func (t *LinkActionTokens) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write([]byte("link-action:"))
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/linkio"
+	"github.com/devingoodsell/go-links-free/internal/models"
+)
+
+// Conflict strategies: what to do with a row whose alias is taken.
+const (
+	ImportSkip      = "skip"      // leave the existing link alone
+	ImportOverwrite = "overwrite" // replace it if the importer owns it
+	ImportFail      = "fail"      // import nothing if any row conflicts
+)
+
+// Per-row import outcomes.
+const (
+	ImportCreated       = "created"
+	ImportUpdated       = "updated"
+	ImportConflict      = "conflict"
+	ImportInvalid       = "invalid"
+	ImportInvalidURL    = "invalid_url"
+	ImportReservedAlias = "reserved_alias"
+	ImportError         = "error"
+)
+
+const maxImportDescriptionLength = 1000
+
+type ImportOptions struct {
+	OwnerID  int64
+	Strategy string
+	DryRun   bool
+
+	// MapOwners gives each link to the user whose email the file names as
+	// its owner. Links whose owner is missing or unknown go to
+	// FallbackOwnerID, or to OwnerID when that is unset. FallbackOwnerID
+	// is only used with MapOwners.
+	MapOwners       bool
+	FallbackOwnerID int64
+
+	// Override skips the alias policy, as admins may when creating links
+	// one by one. Callers only set it for admins.
+	Override bool
+}
+
+// ImportRowResult is the outcome of one row. For dry runs and aborted
+// imports it is the outcome the row would have had.
+type ImportRowResult struct {
+	Row     int    `json:"row"`
+	Alias   string `json:"alias"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	LinkID  int64  `json:"linkId,omitempty"`
+}
+
+type ImportResult struct {
+	DryRun  bool              `json:"dryRun"`
+	Applied bool              `json:"applied"` // false for dry runs and aborted imports
+	Counts  map[string]int    `json:"counts"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// plannedRow is a validated row and the link it will create or overwrite.
+type plannedRow struct {
+	result *ImportRowResult
+	link   *models.Link
+}
+
+// LinkImporter creates links from import records, applying the same alias
+// and destination policies as creating them one by one.
+type LinkImporter struct {
+	linkRepo    *models.LinkRepository
+	userRepo    *models.UserRepository
+	aliasPolicy *AliasPolicy
+	urlPolicy   *URLPolicy
+}
+
+func NewLinkImporter(linkRepo *models.LinkRepository, userRepo *models.UserRepository, aliasPolicy *AliasPolicy, urlPolicy *URLPolicy) *LinkImporter {
+	return &LinkImporter{
+		linkRepo:    linkRepo,
+		userRepo:    userRepo,
+		aliasPolicy: aliasPolicy,
+		urlPolicy:   urlPolicy,
+	}
+}
+
+// Import validates every record, then creates or overwrites links unless
+// this is a dry run or the fail strategy found a conflict.
+func (im *LinkImporter) Import(ctx context.Context, records []linkio.Record, opts ImportOptions) (*ImportResult, error) {
+	switch opts.Strategy {
+	case "":
+		opts.Strategy = ImportSkip
+	case ImportSkip, ImportOverwrite, ImportFail:
+	default:
+		return nil, fmt.Errorf("unknown conflict strategy %q", opts.Strategy)
+	}
+
+	result := &ImportResult{
+		DryRun: opts.DryRun,
+		Counts: make(map[string]int),
+		Rows:   make([]ImportRowResult, len(records)),
+	}
+
+	aliases := make([]string, 0, len(records))
+	for _, record := range records {
+		aliases = append(aliases, strings.TrimSpace(record.Alias))
+	}
+	existing, err := im.linkRepo.GetByAliases(ctx, aliases)
+	if err != nil {
+		return nil, err
+	}
+	existingByAlias := make(map[string]*models.Link, len(existing))
+	for _, link := range existing {
+		existingByAlias[link.Alias] = link
+	}
+
+	owners := newOwnerResolver(im.userRepo, opts)
+
+	var planned []plannedRow
+	seen := make(map[string]int)
+	conflicts := false
+	for i, record := range records {
+		row := &result.Rows[i]
+		row.Row, row.Alias = record.Row, strings.TrimSpace(record.Alias)
+
+		ownerID, note, err := owners.resolve(ctx, record.OwnerEmail)
+		if err != nil {
+			return nil, err
+		}
+		link, err := im.validate(ctx, record, ownerID, opts.Override)
+		if err != nil {
+			row.Status, row.Message = classifyImportError(err)
+			continue
+		}
+
+		if first, ok := seen[link.Alias]; ok {
+			row.Status, row.Message = ImportConflict, fmt.Sprintf("alias already used on row %d", first)
+			conflicts = true
+			continue
+		}
+		seen[link.Alias] = record.Row
+
+		if current := existingByAlias[link.Alias]; current != nil {
+			if opts.Strategy != ImportOverwrite || current.CreatedBy != ownerID {
+				row.Status, row.Message = ImportConflict, "alias already exists"
+				conflicts = true
+				continue
+			}
+			link.ID, link.Visibility = current.ID, current.Visibility
+			row.Status, row.LinkID = ImportUpdated, current.ID
+		} else {
+			row.Status = ImportCreated
+		}
+		row.Message = note
+		planned = append(planned, plannedRow{result: row, link: link})
+	}
+
+	switch {
+	case opts.DryRun || (conflicts && opts.Strategy == ImportFail):
+	case opts.Strategy == ImportFail:
+		applied, err := im.applyAll(ctx, planned)
+		if err != nil {
+			return nil, err
+		}
+		result.Applied = applied
+	default:
+		result.Applied = true
+		for _, p := range planned {
+			im.apply(ctx, p)
+		}
+	}
+
+	for _, row := range result.Rows {
+		result.Counts[row.Status]++
+	}
+	return result, nil
+}
+
+// ownerResolver maps owner emails from an import file to user IDs,
+// looking each email up once.
+type ownerResolver struct {
+	userRepo *models.UserRepository
+	opts     ImportOptions
+	fallback int64
+	ids      map[string]int64
+}
+
+func newOwnerResolver(userRepo *models.UserRepository, opts ImportOptions) *ownerResolver {
+	fallback := opts.FallbackOwnerID
+	if fallback == 0 {
+		fallback = opts.OwnerID
+	}
+	return &ownerResolver{userRepo: userRepo, opts: opts, fallback: fallback, ids: make(map[string]int64)}
+}
+
+// resolve returns the owner for a row, with a note for the row when the
+// fallback owner stands in for an unknown one.
+func (o *ownerResolver) resolve(ctx context.Context, email string) (int64, string, error) {
+	email = strings.TrimSpace(email)
+	if !o.opts.MapOwners {
+		return o.opts.OwnerID, "", nil
+	}
+	if email == "" {
+		return o.fallback, "", nil
+	}
+
+	id, ok := o.ids[email]
+	if !ok {
+		user, err := o.userRepo.GetByEmail(ctx, email)
+		switch {
+		case errors.Is(err, models.ErrNotFound):
+		case err != nil:
+			return 0, "", err
+		default:
+			id = user.ID
+		}
+		o.ids[email] = id
+	}
+	if id == 0 {
+		return o.fallback, fmt.Sprintf("owner %s not found; assigned to the fallback owner", email), nil
+	}
+	return id, "", nil
+}
+
+// validate turns a record into the link to save, or explains why it cannot
+// be imported. override skips the alias policy.
+func (im *LinkImporter) validate(ctx context.Context, record linkio.Record, ownerID int64, override bool) (*models.Link, error) {
+	alias := strings.TrimSpace(record.Alias)
+	switch {
+	case alias == "":
+		return nil, errors.New("alias is required")
+	case len(alias) > 100:
+		return nil, errors.New("alias must be 100 characters or less")
+	case strings.TrimSpace(record.DestinationURL) == "":
+		return nil, errors.New("destination URL is required")
+	case len(record.Description) > maxImportDescriptionLength:
+		return nil, errors.New("description must be 1000 characters or less")
+	case record.ExpiresAt != nil && record.ExpiresAt.Before(time.Now()):
+		return nil, errors.New("expiration time must be in the future")
+	}
+
+	if !override {
+		if err := im.aliasPolicy.Check(alias); err != nil {
+			return nil, err
+		}
+	}
+	destination, err := im.urlPolicy.Apply(ctx, alias, record.DestinationURL)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := models.NormalizeTags(record.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Link{
+		Alias:          alias,
+		DestinationURL: destination,
+		Description:    record.Description,
+		Tags:           tags,
+		ExpiresAt:      record.ExpiresAt,
+		CreatedBy:      ownerID,
+		IsActive:       true,
+	}, nil
+}
+
+func (im *LinkImporter) apply(ctx context.Context, p plannedRow) {
+	var err error
+	if p.link.ID != 0 {
+		err = im.linkRepo.Update(ctx, p.link)
+	} else {
+		err = im.linkRepo.Create(ctx, p.link)
+	}
+
+	if err != nil {
+		p.setError(err)
+		return
+	}
+	p.result.LinkID = p.link.ID
+}
+
+// applyAll saves every planned row in one transaction, so that a row that
+// fails only now, such as an alias taken since validation, leaves nothing
+// imported. The row at fault is marked and the rest keep the outcome they
+// would have had.
+func (im *LinkImporter) applyAll(ctx context.Context, planned []plannedRow) (bool, error) {
+	links := make([]*models.Link, len(planned))
+	for i, p := range planned {
+		links[i] = p.link
+	}
+
+	failed, err := im.linkRepo.SaveAll(ctx, links)
+	if failed >= 0 {
+		planned[failed].setError(err)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, p := range planned {
+		p.result.LinkID = p.link.ID
+	}
+	return true, nil
+}
+
+// setError records why saving the row failed.
+func (p plannedRow) setError(err error) {
+	if errors.Is(err, models.ErrDuplicate) {
+		p.result.Status, p.result.Message = ImportConflict, "alias already exists"
+		return
+	}
+	p.result.Status, p.result.Message = ImportError, err.Error()
+}
+
+// classifyImportError maps a validation error to a row status.
+func classifyImportError(err error) (string, string) {
+	var policyErr *PolicyError
+	if errors.As(err, &policyErr) {
+		if policyErr.Field == "alias" {
+			return ImportReservedAlias, policyErr.Message
+		}
+		return ImportInvalidURL, policyErr.Message
+	}
+	return ImportInvalid, err.Error()
+}
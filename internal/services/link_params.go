@@ -0,0 +1,52 @@
+package services
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// placeholderPattern matches the parameters a destination may contain:
+// {1}, {2}, ... for single arguments and {*} or %s for all of them.
+var placeholderPattern = regexp.MustCompile(`\{(\d+|\*)\}|%s`)
+
+// HasPlaceholders reports whether destination takes parameters.
+func HasPlaceholders(destination string) bool {
+	return placeholderPattern.MatchString(destination)
+}
+
+// ExpandDestination substitutes args into a parameterized destination, so
+// that "go jira 123" can open https://jira.example.com/browse/{1}. Missing
+// arguments expand to nothing. When the destination has no placeholders the
+// arguments are appended to its path instead, so "go docs api/v2" opens
+// <docs destination>/api/v2.
+func ExpandDestination(destination string, args []string) string {
+	if !HasPlaceholders(destination) {
+		if len(args) == 0 {
+			return destination
+		}
+		return appendPath(destination, args)
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(destination, func(placeholder string) string {
+		if placeholder == "%s" || placeholder == "{*}" {
+			return url.QueryEscape(strings.Join(args, " "))
+		}
+		n, _ := strconv.Atoi(placeholder[1 : len(placeholder)-1])
+		if n < 1 || n > len(args) {
+			return ""
+		}
+		return url.QueryEscape(args[n-1])
+	})
+}
+
+func appendPath(destination string, args []string) string {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return destination
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.Join(args, "/")
+	u.RawPath = ""
+	return u.String()
+}
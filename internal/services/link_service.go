@@ -41,7 +41,8 @@ func (s *LinkService) GetByAlias(ctx context.Context, alias string) (*models.Lin
 }
 
 func (s *LinkService) List(ctx context.Context, userID int64, opts models.ListOptions) ([]*models.Link, error) {
-	return s.linkRepo.ListByUserWithFilters(ctx, userID, opts)
+	opts.OwnerID = userID
+	return s.linkRepo.List(ctx, opts)
 }
 
 func (s *LinkService) Update(ctx context.Context, userID int64, alias string, destinationURL string, expiresAt *time.Time) (*models.Link, error) {
@@ -79,31 +80,9 @@ func (s *LinkService) IncrementStats(ctx context.Context, linkID int64) error {
 }
 
 func (s *LinkService) BulkDelete(ctx context.Context, userID int64, ids []int64) error {
-	// Verify all links exist and belong to the user
-	for _, id := range ids {
-		link, err := s.linkRepo.GetByID(ctx, id)
-		if err != nil {
-			return err
-		}
-		if link.CreatedBy != userID {
-			return models.ErrUnauthorized
-		}
-	}
-
 	return s.linkRepo.BulkDelete(ctx, userID, ids)
 }
 
 func (s *LinkService) BulkUpdateStatus(ctx context.Context, userID int64, ids []int64, isActive bool) error {
-	// Verify all links exist and belong to the user
-	for _, id := range ids {
-		link, err := s.linkRepo.GetByID(ctx, id)
-		if err != nil {
-			return err
-		}
-		if link.CreatedBy != userID {
-			return models.ErrUnauthorized
-		}
-	}
-
 	return s.linkRepo.BulkUpdateStatus(ctx, userID, ids, isActive)
 }
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+)
+
+const (
+	// maxRecentPerUser bounds how many recently used aliases are remembered
+	// for each user.
+	maxRecentPerUser = 50
+	// minFuzzyPrefix is the shortest prefix that also gets fuzzy matches;
+	// shorter ones would match nearly everything.
+	minFuzzyPrefix = 3
+	// favoriteBoost is added to the score of links the user starred.
+	favoriteBoost = 4
+)
+
+// ActiveLinkSource is the part of the link repository the suggest index
+// loads from.
+type ActiveLinkSource interface {
+	ListActive(ctx context.Context) ([]*models.Link, error)
+	FavoriteLinkIDs(ctx context.Context) (map[int64][]int64, error)
+}
+
+// Suggestion is an alias offered for a typed prefix.
+type Suggestion struct {
+	Alias          string  `json:"alias"`
+	DestinationURL string  `json:"destinationUrl"`
+	Score          float64 `json:"score"`
+	Match          string  `json:"match"` // "prefix" or "fuzzy"
+}
+
+type suggestEntry struct {
+	id          int64
+	key         string // lowercased alias, the sort key
+	alias       string
+	destination string
+	popularity  float64
+	ownerID     int64
+	private     bool // only suggested to its owner
+}
+
+// SuggestIndex answers alias autocomplete queries from memory. It holds every
+// active link sorted by alias and is rebuilt in the background whenever the
+// link repository reports a change, so lookups never touch the database.
+type SuggestIndex struct {
+	source   ActiveLinkSource
+	interval time.Duration
+
+	mu        sync.RWMutex
+	entries   []suggestEntry
+	favorites map[int64]map[int64]bool // user ID -> starred link IDs
+
+	recentMu sync.Mutex
+	recent   map[int64][]string // user ID -> lowercased aliases, most recent first
+
+	stale    chan struct{}
+	stopChan chan struct{}
+}
+
+// NewSuggestIndex creates an empty index. interval is how often it is rebuilt
+// even when no change was reported, to pick up expirations and stats.
+func NewSuggestIndex(source ActiveLinkSource, interval time.Duration) *SuggestIndex {
+	return &SuggestIndex{
+		source:   source,
+		interval: interval,
+		recent:   make(map[int64][]string),
+		stale:    make(chan struct{}, 1),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Refresh rebuilds the index from the source.
+func (s *SuggestIndex) Refresh(ctx context.Context) error {
+	links, err := s.source.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	favoriteIDs, err := s.source.FavoriteLinkIDs(ctx)
+	if err != nil {
+		return err
+	}
+	favorites := make(map[int64]map[int64]bool, len(favoriteIDs))
+	for userID, ids := range favoriteIDs {
+		favorites[userID] = make(map[int64]bool, len(ids))
+		for _, id := range ids {
+			favorites[userID][id] = true
+		}
+	}
+
+	entries := make([]suggestEntry, 0, len(links))
+	for _, link := range links {
+		var total, weekly int
+		if link.Stats != nil {
+			total, weekly = link.Stats.TotalCount, link.Stats.WeeklyCount
+		}
+		entries = append(entries, suggestEntry{
+			id:          link.ID,
+			key:         strings.ToLower(link.Alias),
+			alias:       link.Alias,
+			destination: link.DestinationURL,
+			popularity:  math.Log1p(float64(total)) + 0.5*math.Log1p(float64(weekly)),
+			ownerID:     link.CreatedBy,
+			private:     link.Visibility == models.VisibilityPrivate,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	s.mu.Lock()
+	s.entries = entries
+	s.favorites = favorites
+	s.mu.Unlock()
+	return nil
+}
+
+// MarkStale schedules a rebuild. It never blocks, so it can be registered as
+// a link repository change listener.
+func (s *SuggestIndex) MarkStale() {
+	select {
+	case s.stale <- struct{}{}:
+	default:
+	}
+}
+
+// Start rebuilds the index whenever it is marked stale and on every interval
+// tick.
+func (s *SuggestIndex) Start() {
+	ticker := time.NewTicker(s.interval)
+	go func() {
+		for {
+			select {
+			case <-s.stale:
+			case <-ticker.C:
+			case <-s.stopChan:
+				ticker.Stop()
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			if err := s.Refresh(ctx); err != nil {
+				log.Printf("Error refreshing suggest index: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+func (s *SuggestIndex) Stop() {
+	close(s.stopChan)
+}
+
+// RecordUse remembers that userID just followed alias, which boosts it in
+// that user's suggestions.
+func (s *SuggestIndex) RecordUse(userID int64, alias string) {
+	key := strings.ToLower(alias)
+
+	s.recentMu.Lock()
+	defer s.recentMu.Unlock()
+
+	recent := []string{key}
+	for _, k := range s.recent[userID] {
+		if k != key && len(recent) < maxRecentPerUser {
+			recent = append(recent, k)
+		}
+	}
+	s.recent[userID] = recent
+}
+
+// recentBoosts returns a boost per alias the user used recently, larger for
+// more recent use.
+func (s *SuggestIndex) recentBoosts(userID int64) map[string]float64 {
+	s.recentMu.Lock()
+	defer s.recentMu.Unlock()
+
+	boosts := make(map[string]float64, len(s.recent[userID]))
+	for i, key := range s.recent[userID] {
+		boosts[key] = 5 * (1 - float64(i)/maxRecentPerUser)
+	}
+	return boosts
+}
+
+// Suggest returns up to limit aliases for prefix, best first. Aliases starting
+// with prefix always rank above fuzzy matches that are within a small edit
+// distance; within each group popularity, the user's favorites and their
+// recent use decide.
+func (s *SuggestIndex) Suggest(userID int64, prefix string, limit int) []Suggestion {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	suggestions := []Suggestion{}
+	if prefix == "" || limit <= 0 {
+		return suggestions
+	}
+	boosts := s.recentBoosts(userID)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	starred := s.favorites[userID]
+	boost := func(e suggestEntry) float64 {
+		b := boosts[e.key]
+		if starred[e.id] {
+			b += favoriteBoost
+		}
+		return b
+	}
+
+	start := sort.Search(len(s.entries), func(i int) bool { return s.entries[i].key >= prefix })
+	matched := make(map[string]bool)
+	for i := start; i < len(s.entries) && strings.HasPrefix(s.entries[i].key, prefix); i++ {
+		e := s.entries[i]
+		if e.private && e.ownerID != userID {
+			continue
+		}
+		score := 10 + e.popularity + boost(e)
+		if e.key == prefix {
+			score += 5
+		}
+		matched[e.key] = true
+		suggestions = append(suggestions, Suggestion{Alias: e.alias, DestinationURL: e.destination, Score: score, Match: "prefix"})
+	}
+
+	if len(prefix) >= minFuzzyPrefix && len(suggestions) < limit {
+		maxDistance := 1
+		if len(prefix) >= 6 {
+			maxDistance = 2
+		}
+		for _, e := range s.entries {
+			if matched[e.key] || (e.private && e.ownerID != userID) {
+				continue
+			}
+			d := prefixDistance(prefix, e.key, maxDistance)
+			if d > maxDistance {
+				continue
+			}
+			score := e.popularity + boost(e) - float64(d)
+			suggestions = append(suggestions, Suggestion{Alias: e.alias, DestinationURL: e.destination, Score: score, Match: "fuzzy"})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Match != suggestions[j].Match {
+			return suggestions[i].Match == "prefix"
+		}
+		return suggestions[i].Score > suggestions[j].Score
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// Len returns the number of indexed links.
+func (s *SuggestIndex) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
+
+// prefixDistance is the smallest edit distance between prefix and any prefix
+// of key, giving up once it exceeds max.
+func prefixDistance(prefix, key string, max int) int {
+	a, b := []rune(prefix), []rune(key)
+	if len(b) > len(a)+max {
+		b = b[:len(a)+max]
+	}
+
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev, cur = cur, prev
+	}
+
+	best := prev[0]
+	for _, d := range prev {
+		best = min(best, d)
+	}
+	return best
+}
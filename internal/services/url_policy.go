@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+)
+
+// maxChainDepth bounds how many go links a destination may chain through
+// before it is treated as a loop.
+const maxChainDepth = 5
+
+// LinkLookup is the part of the link repository the URL policy needs to
+// follow chains of go links.
+type LinkLookup interface {
+	GetByAlias(ctx context.Context, alias string) (*models.Link, error)
+}
+
+type URLPolicyConfig struct {
+	AllowedSchemes []string
+	AllowedDomains []string // empty allows every domain not denied
+	DeniedDomains  []string
+	ForceHTTPS     bool
+	PublicURL      string   // base URL of this go server
+	SelfHosts      []string // other host names this go server answers on
+	Blocklist      *DomainBlocklist
+}
+
+// URLPolicy validates and normalizes link destinations.
+type URLPolicy struct {
+	links          LinkLookup
+	allowedSchemes map[string]bool
+	allowedDomains []string
+	deniedDomains  []string
+	forceHTTPS     bool
+	selfHosts      map[string]bool
+	blocklist      *DomainBlocklist
+}
+
+func NewURLPolicy(links LinkLookup, cfg URLPolicyConfig) *URLPolicy {
+	p := &URLPolicy{
+		links:          links,
+		allowedSchemes: make(map[string]bool),
+		allowedDomains: normalizeDomains(cfg.AllowedDomains),
+		deniedDomains:  normalizeDomains(cfg.DeniedDomains),
+		forceHTTPS:     cfg.ForceHTTPS,
+		selfHosts:      make(map[string]bool),
+		blocklist:      cfg.Blocklist,
+	}
+
+	for _, scheme := range cfg.AllowedSchemes {
+		p.allowedSchemes[strings.ToLower(scheme)] = true
+	}
+	for _, host := range cfg.SelfHosts {
+		p.selfHosts[strings.ToLower(host)] = true
+	}
+	if u, err := url.Parse(cfg.PublicURL); err == nil && u.Host != "" {
+		p.selfHosts[strings.ToLower(u.Host)] = true
+	}
+
+	return p
+}
+
+// Apply checks destination for the link with the given alias and returns the
+// destination to store, which may have been upgraded to https. Violations are
+// returned as *PolicyError.
+func (p *URLPolicy) Apply(ctx context.Context, alias, destination string) (string, error) {
+	destination = strings.TrimSpace(destination)
+	u, err := url.Parse(destination)
+	if err != nil || u.Scheme == "" {
+		return "", destinationError("url_invalid", "destination must be an absolute URL")
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if !p.allowedSchemes[u.Scheme] {
+		return "", destinationError("scheme_not_allowed", fmt.Sprintf("URL scheme %q is not allowed", u.Scheme))
+	}
+	if u.Host == "" {
+		return "", destinationError("url_invalid", "destination must include a host")
+	}
+	if p.forceHTTPS && u.Scheme == "http" {
+		u.Scheme = "https"
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if matchesDomain(host, p.deniedDomains) {
+		return "", destinationError("domain_denied", fmt.Sprintf("links to %s are not allowed", host))
+	}
+	if p.blocklist != nil && p.blocklist.Contains(host) {
+		return "", destinationError("domain_blocklisted", fmt.Sprintf("%s is on the malicious domain blocklist", host))
+	}
+	if len(p.allowedDomains) > 0 && !matchesDomain(host, p.allowedDomains) {
+		return "", destinationError("domain_not_allowed", fmt.Sprintf("%s is not an allowed domain", host))
+	}
+
+	if err := p.checkChain(ctx, alias, u); err != nil {
+		return "", err
+	}
+
+	// Keep everything after the scheme as written: re-encoding would escape
+	// the {1} and {*} placeholders of parameterized links.
+	return u.Scheme + destination[strings.Index(destination, ":"):], nil
+}
+
+// checkChain follows destinations that point back at this go server and
+// rejects the ones that lead back to alias or chain too deeply.
+func (p *URLPolicy) checkChain(ctx context.Context, alias string, u *url.URL) error {
+	visited := map[string]bool{strings.ToLower(alias): true}
+
+	for depth := 0; ; depth++ {
+		target, ok := p.selfAlias(u)
+		if !ok {
+			return nil
+		}
+		if visited[target] {
+			return destinationError("redirect_loop", fmt.Sprintf("destination loops back to go/%s", target))
+		}
+		if depth >= maxChainDepth {
+			return destinationError("redirect_chain_too_long", "destination chains through too many go links")
+		}
+		visited[target] = true
+
+		if p.links == nil {
+			return nil
+		}
+		link, err := p.links.GetByAlias(ctx, target)
+		if errors.Is(err, models.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		next, err := url.Parse(link.DestinationURL)
+		if err != nil {
+			return nil
+		}
+		u = next
+	}
+}
+
+// selfAlias returns the alias a URL on this go server refers to, if any.
+func (p *URLPolicy) selfAlias(u *url.URL) (string, bool) {
+	if !p.selfHosts[strings.ToLower(u.Host)] && !p.selfHosts[strings.ToLower(u.Hostname())] {
+		return "", false
+	}
+
+	path := strings.TrimPrefix(u.Path, "/")
+	path = strings.TrimPrefix(path, "go/")
+	alias := strings.ToLower(strings.SplitN(path, "/", 2)[0])
+	return alias, alias != ""
+}
+
+func destinationError(code, message string) *PolicyError {
+	return newPolicyError("destinationUrl", code, message)
+}
+
+func normalizeDomains(domains []string) []string {
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain != "" {
+			normalized = append(normalized, domain)
+		}
+	}
+	return normalized
+}
+
+// matchesDomain reports whether host is one of domains or a subdomain of one.
+func matchesDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
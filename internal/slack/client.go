@@ -0,0 +1,121 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultAPIURL is where the Slack Web API is served.
+const DefaultAPIURL = "https://slack.com/api"
+
+// Response types of a reply. Ephemeral replies are shown only to the person
+// who ran the command.
+const (
+	ResponseEphemeral = "ephemeral"
+	ResponseInChannel = "in_channel"
+)
+
+// Response is a reply to a command, posted to its response URL.
+type Response struct {
+	ResponseType string `json:"response_type,omitempty"`
+	Text         string `json:"text"`
+}
+
+// ErrNoEmail is returned when Slack does not share a user's email, which
+// needs the users:read.email scope.
+var ErrNoEmail = errors.New("Slack did not return an email for the user")
+
+// Client calls the Slack Web API with a bot token and posts command replies.
+type Client struct {
+	token  string
+	apiURL string
+	http   *http.Client
+}
+
+// NewClient returns a client for the Web API at apiURL, DefaultAPIURL when
+// empty. Tests point it at a fake.
+func NewClient(token, apiURL string, httpClient *http.Client) *Client {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	return &Client{
+		token:  token,
+		apiURL: strings.TrimRight(apiURL, "/"),
+		http:   httpClient,
+	}
+}
+
+// UserEmail returns the email address of the Slack user with the given ID.
+func (c *Client) UserEmail(ctx context.Context, userID string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.apiURL+"/users.info?user="+url.QueryEscape(userID), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("users.info responded %s", resp.Status)
+	}
+
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+		User  struct {
+			Profile struct {
+				Email string `json:"email"`
+			} `json:"profile"`
+		} `json:"user"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return "", err
+	}
+	if !result.OK {
+		return "", fmt.Errorf("users.info failed: %s", result.Error)
+	}
+	if result.User.Profile.Email == "" {
+		return "", ErrNoEmail
+	}
+	return result.User.Profile.Email, nil
+}
+
+// Respond posts a reply to the response URL of a command.
+func (c *Client) Respond(ctx context.Context, responseURL string, response *Response) error {
+	payload, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("response URL responded %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Package slack verifies and parses the slash commands Slack sends to the
+// go server, and talks back to Slack: it looks up the people who run
+// commands and posts replies to the response URL of each command.
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request headers Slack signs every request with. The signature is "v0="
+// followed by the hex HMAC-SHA256 of "v0:<timestamp>:<body>", keyed with the
+// app's signing secret.
+const (
+	HeaderTimestamp = "X-Slack-Request-Timestamp"
+	HeaderSignature = "X-Slack-Signature"
+)
+
+// MaxRequestAge is how old a signed request may be before it is refused as
+// a possible replay.
+const MaxRequestAge = 5 * time.Minute
+
+var (
+	ErrInvalidSignature = errors.New("invalid Slack signature")
+	ErrStaleRequest     = errors.New("Slack request is too old")
+)
+
+// Sign returns the signature header value for a body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + strconv.FormatInt(timestamp, 10) + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that body was signed by Slack with secret less than
+// MaxRequestAge before now.
+func Verify(secret string, header http.Header, body []byte, now time.Time) error {
+	if secret == "" {
+		return ErrInvalidSignature
+	}
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(timestamp, 0)); age > MaxRequestAge || age < -MaxRequestAge {
+		return ErrStaleRequest
+	}
+	if !hmac.Equal([]byte(header.Get(HeaderSignature)), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Command is a slash command as Slack posts it.
+type Command struct {
+	Command     string
+	Text        string
+	UserID      string
+	UserName    string
+	TeamID      string
+	ChannelID   string
+	ResponseURL string
+}
+
+// ParseCommand parses the form body of a slash command request.
+func ParseCommand(body []byte) (*Command, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	cmd := &Command{
+		Command:     values.Get("command"),
+		Text:        strings.TrimSpace(values.Get("text")),
+		UserID:      values.Get("user_id"),
+		UserName:    values.Get("user_name"),
+		TeamID:      values.Get("team_id"),
+		ChannelID:   values.Get("channel_id"),
+		ResponseURL: values.Get("response_url"),
+	}
+	if cmd.UserID == "" || cmd.ResponseURL == "" {
+		return nil, errors.New("slash command needs user_id and response_url")
+	}
+	return cmd, nil
+}
+
+// Unwrap returns the text of a command argument without the angle brackets
+// Slack puts around links, and without the label of "<url|label>".
+func Unwrap(arg string) string {
+	if strings.HasPrefix(arg, "<") && strings.HasSuffix(arg, ">") {
+		arg = arg[1 : len(arg)-1]
+		if i := strings.IndexByte(arg, '|'); i >= 0 {
+			arg = arg[:i]
+		}
+	}
+	return arg
+}
+
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Escape makes text safe to put in a message, where Slack treats &, < and >
+// as markup.
+func Escape(text string) string {
+	return escaper.Replace(text)
+}
@@ -0,0 +1,234 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/linkio"
+	"github.com/devingoodsell/go-links-free/internal/models"
+)
+
+// Event is the JSON body of a delivery.
+type Event struct {
+	ID        string       `json:"id"`
+	Type      string       `json:"type"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Link      *LinkPayload `json:"link"`
+	Threshold int          `json:"threshold,omitempty"` // link.threshold_reached
+}
+
+// LinkPayload is the link an event is about. Deleted links only carry their
+// ID, alias, destination and owner ID.
+type LinkPayload struct {
+	ID             int64      `json:"id"`
+	Alias          string     `json:"alias"`
+	ShortURL       string     `json:"shortUrl"`
+	DestinationURL string     `json:"destinationUrl"`
+	Description    string     `json:"description,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	OwnerID        int64      `json:"ownerId"`
+	OwnerEmail     string     `json:"ownerEmail,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	IsActive       bool       `json:"isActive"`
+	Visibility     string     `json:"visibility,omitempty"`
+	Clicks         int        `json:"clicks"`
+}
+
+// Dispatcher turns link events into deliveries for every endpoint
+// subscribed to them.
+type Dispatcher struct {
+	repo     *models.WebhookRepository
+	linkRepo *models.LinkRepository
+	baseURL  string
+}
+
+func NewDispatcher(repo *models.WebhookRepository, linkRepo *models.LinkRepository, baseURL string) *Dispatcher {
+	return &Dispatcher{
+		repo:     repo,
+		linkRepo: linkRepo,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+	}
+}
+
+var changeEvents = map[string]string{
+	models.LinkChangeCreated: models.WebhookLinkCreated,
+	models.LinkChangeUpdated: models.WebhookLinkUpdated,
+	models.LinkChangeDeleted: models.WebhookLinkDeleted,
+}
+
+// ProcessChanges queues events for up to limit pending changes from the
+// link change outbox, oldest first, and returns how many it processed. The
+// endpoints subscribed to each event type and the changed links are loaded
+// once for the whole batch. Events describe links as they are when the
+// change is processed; links deleted since are left to their own change.
+func (d *Dispatcher) ProcessChanges(ctx context.Context, limit int) (int, error) {
+	changes, err := d.linkRepo.PendingChanges(ctx, limit)
+	if err != nil || len(changes) == 0 {
+		return 0, err
+	}
+
+	subscribed := make(map[string][]*models.WebhookEndpoint)
+	var ids []int64
+	for _, change := range changes {
+		eventType := changeEvents[change.Type]
+		if _, ok := subscribed[eventType]; !ok {
+			if subscribed[eventType], err = d.repo.EndpointsForEvent(ctx, eventType); err != nil {
+				return 0, err
+			}
+		}
+		if change.Type != models.LinkChangeDeleted && len(subscribed[eventType]) > 0 {
+			ids = append(ids, change.LinkIDs...)
+		}
+	}
+
+	current := make(map[int64]*models.Link)
+	if len(ids) > 0 {
+		links, err := d.linkRepo.GetByIDs(ctx, ids)
+		if err != nil {
+			return 0, err
+		}
+		for _, link := range links {
+			current[link.ID] = link
+		}
+	}
+
+	for i, change := range changes {
+		eventType := changeEvents[change.Type]
+		links := change.Removed
+		if change.Type != models.LinkChangeDeleted {
+			links = nil
+			for _, id := range change.LinkIDs {
+				if link := current[id]; link != nil {
+					links = append(links, link)
+				}
+			}
+		}
+
+		var deliveries []*models.WebhookDelivery
+		for _, link := range links {
+			endpoints := endpointsFor(subscribed[eventType], link.CreatedBy)
+			if len(endpoints) == 0 {
+				continue
+			}
+			id, payload, err := d.newEvent(eventType, link, 0)
+			if err != nil {
+				return i, err
+			}
+			for _, e := range endpoints {
+				deliveries = append(deliveries, &models.WebhookDelivery{EndpointID: e.ID, EventID: id, EventType: eventType, Payload: payload})
+			}
+		}
+		if err := d.repo.EnqueueChange(ctx, change.ID, deliveries); err != nil {
+			return i, err
+		}
+	}
+	return len(changes), nil
+}
+
+// endpointsFor picks the endpoints that cover the links of ownerID.
+func endpointsFor(endpoints []*models.WebhookEndpoint, ownerID int64) []*models.WebhookEndpoint {
+	var covered []*models.WebhookEndpoint
+	for _, e := range endpoints {
+		if e.AllLinks || e.CreatedBy == ownerID {
+			covered = append(covered, e)
+		}
+	}
+	return covered
+}
+
+// Emit queues an event about link for every endpoint subscribed to it.
+func (d *Dispatcher) Emit(ctx context.Context, eventType string, link *models.Link, threshold int) error {
+	endpoints, err := d.repo.EndpointsFor(ctx, link.CreatedBy, eventType)
+	if err != nil || len(endpoints) == 0 {
+		return err
+	}
+
+	id, payload, err := d.newEvent(eventType, link, threshold)
+	if err != nil {
+		return err
+	}
+	return d.repo.EnqueueDeliveries(ctx, endpoints, id, eventType, payload)
+}
+
+// newEvent builds an event about link and returns its ID and JSON body.
+func (d *Dispatcher) newEvent(eventType string, link *models.Link, threshold int) (string, []byte, error) {
+	id, err := newEventID()
+	if err != nil {
+		return "", nil, err
+	}
+	payload, err := json.Marshal(Event{
+		ID:        id,
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Link:      d.linkPayload(link),
+		Threshold: threshold,
+	})
+	return id, payload, err
+}
+
+// EmitScheduled queues link.expired events for links that expired in the
+// lookback window and link.threshold_reached events for links used in it
+// whose clicks reached one of thresholds. Looking back a limited time keeps
+// old expiries and click counts from flooding endpoints when webhooks are
+// first set up. It returns how many events were emitted.
+func (d *Dispatcher) EmitScheduled(ctx context.Context, thresholds []int, lookback time.Duration, limit int) (int, error) {
+	since := time.Now().Add(-lookback)
+	emitted := 0
+
+	expired, err := d.linkRepo.ListNewlyExpired(ctx, since, limit)
+	if err != nil {
+		return emitted, err
+	}
+	for _, link := range expired {
+		if err := d.Emit(ctx, models.WebhookLinkExpired, link, 0); err != nil {
+			return emitted, err
+		}
+		key := strconv.FormatInt(link.ExpiresAt.Unix(), 10)
+		if err := d.linkRepo.MarkEventEmitted(ctx, link.ID, models.WebhookLinkExpired, key); err != nil {
+			return emitted, err
+		}
+		emitted++
+	}
+
+	if len(thresholds) == 0 {
+		return emitted, nil
+	}
+	crossings, err := d.linkRepo.ListThresholdsReached(ctx, thresholds, since, limit)
+	if err != nil {
+		return emitted, err
+	}
+	for _, c := range crossings {
+		if err := d.Emit(ctx, models.WebhookLinkThreshold, c.Link, c.Threshold); err != nil {
+			return emitted, err
+		}
+		key := strconv.Itoa(c.Threshold)
+		if err := d.linkRepo.MarkEventEmitted(ctx, c.Link.ID, models.WebhookLinkThreshold, key); err != nil {
+			return emitted, err
+		}
+		emitted++
+	}
+	return emitted, nil
+}
+
+func (d *Dispatcher) linkPayload(link *models.Link) *LinkPayload {
+	payload := &LinkPayload{
+		ID:             link.ID,
+		Alias:          link.Alias,
+		ShortURL:       linkio.ShortURL(d.baseURL, link.Alias),
+		DestinationURL: link.DestinationURL,
+		Description:    link.Description,
+		Tags:           link.Tags,
+		OwnerID:        link.CreatedBy,
+		OwnerEmail:     link.OwnerEmail,
+		ExpiresAt:      link.ExpiresAt,
+		IsActive:       link.IsActive,
+		Visibility:     link.Visibility,
+	}
+	if link.Stats != nil {
+		payload.Clicks = link.Stats.TotalCount
+	}
+	return payload
+}
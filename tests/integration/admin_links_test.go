@@ -0,0 +1,121 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devingoodsell/go-links-free/internal/handlers"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateLinkAdmin(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+	auditRepo := models.NewAuditLogRepository(testDB)
+
+	admin := &models.User{Email: "admin@example.com", IsAdmin: true}
+	owner := &models.User{Email: "owner@example.com"}
+	heir := &models.User{Email: "heir@example.com"}
+	for _, user := range []*models.User{admin, owner, heir} {
+		require.NoError(t, userRepo.Create(ctx, user, "password123"))
+	}
+
+	link := &models.Link{Alias: "docs", DestinationURL: "https://example.com/docs", CreatedBy: owner.ID, IsActive: true}
+	require.NoError(t, linkRepo.Create(ctx, link))
+
+	aliasPolicy, err := services.NewAliasPolicy(nil, nil, nil)
+	require.NoError(t, err)
+	urlPolicy := services.NewURLPolicy(linkRepo, services.URLPolicyConfig{AllowedSchemes: []string{"http", "https"}})
+	handler := handlers.NewAdminHandler(models.NewAnalyticsRepository(testDB), linkRepo, userRepo,
+		aliasPolicy, urlPolicy, auditRepo, handlers.NewCursorCodec("test-secret"))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(asUser(admin.ID, true))
+	router.PUT("/api/admin/links/:alias", handler.UpdateLinkAdmin)
+
+	update := func(t *testing.T, req map[string]interface{}) (int, map[string]interface{}) {
+		payload, err := json.Marshal(req)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/admin/links/docs", bytes.NewReader(payload)))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+
+	t.Run("Requires A Reason", func(t *testing.T) {
+		for _, req := range []map[string]interface{}{
+			{"isActive": false},
+			{"isActive": false, "reason": "   "},
+		} {
+			code, body := update(t, req)
+			assert.Equal(t, http.StatusBadRequest, code)
+			assert.Equal(t, "a reason is required for admin edits", body["error"])
+		}
+
+		current, err := linkRepo.GetByID(ctx, link.ID)
+		require.NoError(t, err)
+		assert.True(t, current.IsActive)
+	})
+
+	t.Run("Rejects Malformed Requests", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/admin/links/docs", bytes.NewBufferString(`{"isActive": "no"`)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error": "invalid request"}`, w.Body.String())
+	})
+
+	t.Run("Rejects Unknown Owners", func(t *testing.T) {
+		_, err := userRepo.GetByEmail(ctx, "nobody@example.com")
+		assert.True(t, errors.Is(err, models.ErrUserNotFound))
+
+		code, body := update(t, map[string]interface{}{"ownerEmail": "nobody@example.com", "reason": "handover"})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "no user with email nobody@example.com", body["error"])
+
+		current, err := linkRepo.GetByID(ctx, link.ID)
+		require.NoError(t, err)
+		assert.Equal(t, owner.ID, current.CreatedBy)
+	})
+
+	t.Run("Transfers And Audits For Both Owners", func(t *testing.T) {
+		code, body := update(t, map[string]interface{}{"ownerEmail": "heir@example.com", "isActive": false, "reason": "owner left"})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "heir@example.com", body["ownerEmail"])
+
+		current, err := linkRepo.GetByID(ctx, link.ID)
+		require.NoError(t, err)
+		assert.Equal(t, heir.ID, current.CreatedBy)
+		assert.False(t, current.IsActive)
+
+		entries, err := auditRepo.List(ctx, models.AuditLogFilter{TargetUserID: owner.ID, LinkID: link.ID, Action: models.AuditLinkAdminEdit})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, admin.ID, *entries[0].ActorID)
+
+		var details struct {
+			Reason  string                            `json:"reason"`
+			Changes map[string]map[string]interface{} `json:"changes"`
+		}
+		require.NoError(t, json.Unmarshal(entries[0].Details, &details))
+		assert.Equal(t, "owner left", details.Reason)
+		assert.Equal(t, map[string]interface{}{"from": "owner@example.com", "to": "heir@example.com"}, details.Changes["owner"])
+		assert.Equal(t, map[string]interface{}{"from": true, "to": false}, details.Changes["isActive"])
+
+		entries, err = auditRepo.List(ctx, models.AuditLogFilter{TargetUserID: heir.ID, LinkID: link.ID, Action: models.AuditLinkTransferred})
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+}
@@ -0,0 +1,55 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliasGenerator(t *testing.T) {
+	t.Run("Alphabet And Length", func(t *testing.T) {
+		gen, err := services.NewAliasGenerator("bcd234", 8, nil)
+		require.NoError(t, err)
+
+		seen := make(map[string]bool)
+		for i := 0; i < 100; i++ {
+			alias, err := gen.Generate()
+			require.NoError(t, err)
+			assert.Len(t, alias, 8)
+			assert.Empty(t, strings.Trim(alias, "bcd234"))
+			seen[alias] = true
+		}
+		assert.Greater(t, len(seen), 95)
+	})
+
+	t.Run("Skips Policy Violations", func(t *testing.T) {
+		policy, err := services.NewAliasPolicy(nil, nil, []string{"^b"})
+		require.NoError(t, err)
+		gen, err := services.NewAliasGenerator("bc", 3, policy)
+		require.NoError(t, err)
+
+		alias, err := gen.Generate()
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(alias, "c"), alias)
+	})
+
+	t.Run("Invalid Config", func(t *testing.T) {
+		for _, tt := range []struct {
+			alphabet string
+			length   int
+		}{
+			{"a", 6},
+			{"abca", 6},
+			{"ab/", 6},
+			{"ab c", 6},
+			{"abc", 2},
+			{"abc", 33},
+		} {
+			_, err := services.NewAliasGenerator(tt.alphabet, tt.length, nil)
+			assert.Error(t, err, "alphabet %q length %d", tt.alphabet, tt.length)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliasPolicy(t *testing.T) {
+	policy, err := services.NewAliasPolicy(nil, []string{"admin", "api"}, []string{`^bad`, `word$`})
+	require.NoError(t, err)
+
+	tests := []struct {
+		alias string
+		code  string
+	}{
+		{"docs", ""},
+		{"Admin", "alias_reserved"},
+		{"api/users", "alias_reserved"},
+		{"apidocs", ""},
+		{"badname", "alias_blocked"},
+		{"swearWORD", "alias_blocked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			err := policy.Check(tt.alias)
+			if tt.code == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var policyErr *services.PolicyError
+			require.ErrorAs(t, err, &policyErr)
+			assert.Equal(t, tt.code, policyErr.Code)
+			assert.Equal(t, "alias", policyErr.Field)
+		})
+	}
+
+	t.Run("Invalid Pattern", func(t *testing.T) {
+		_, err := services.NewAliasPolicy(nil, nil, []string{"("})
+		assert.Error(t, err)
+	})
+}
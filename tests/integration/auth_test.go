@@ -7,6 +7,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/devingoodsell/go-links-free/internal/handlers"
+	"github.com/devingoodsell/go-links-free/internal/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -81,6 +83,27 @@ func TestAuthFlow(t *testing.T) {
 	})
 }
 
+func TestLogoutExpiresSessionCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/auth/logout", handlers.NewAuthHandler(nil, nil, nil).Logout)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil)
+	req.AddCookie(&http.Cookie{Name: middleware.SessionCookie, Value: "token"})
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, middleware.SessionCookie, cookies[0].Name)
+	assert.Empty(t, cookies[0].Value)
+	assert.True(t, cookies[0].MaxAge < 0)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.True(t, cookies[0].Secure)
+}
+
 // Helper function to get a valid JWT token for protected endpoint tests
 func getTestUserToken(t *testing.T, router *gin.Engine) string {
 	testUser := map[string]string{
@@ -0,0 +1,105 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/jobs"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocklistScan(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+	auditRepo := models.NewAuditLogRepository(testDB)
+
+	owner := &models.User{Email: "owner@example.com"}
+	require.NoError(t, userRepo.Create(ctx, owner, "password123"))
+
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("malware.test\n"), 0o644))
+	blocklist := services.NewDomainBlocklist(path)
+	_, err := blocklist.Reload()
+	require.NoError(t, err)
+
+	newLink := func(alias, destination string) *models.Link {
+		link := &models.Link{Alias: alias, DestinationURL: destination, CreatedBy: owner.ID, IsActive: true}
+		require.NoError(t, linkRepo.Create(ctx, link))
+		return link
+	}
+	bad := newLink("bad", "https://cdn.malware.test/payload")
+	good := newLink("good", "https://example.com/docs")
+
+	t.Run("Flags Matching Links", func(t *testing.T) {
+		job := jobs.NewBlocklistScanJob(blocklist, linkRepo, auditRepo, false, time.Hour)
+		flagged, err := job.Scan(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, flagged)
+
+		link, err := linkRepo.GetByID(ctx, bad.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, link.FlaggedAt)
+		assert.True(t, link.IsActive)
+
+		link, err = linkRepo.GetByID(ctx, good.ID)
+		require.NoError(t, err)
+		assert.Nil(t, link.FlaggedAt)
+	})
+
+	t.Run("Records The Audit Entry", func(t *testing.T) {
+		entries, err := auditRepo.List(ctx, models.AuditLogFilter{TargetUserID: owner.ID, LinkID: bad.ID, Action: models.AuditLinkFlagged})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Nil(t, entries[0].ActorID)
+
+		var details map[string]interface{}
+		require.NoError(t, json.Unmarshal(entries[0].Details, &details))
+		assert.Equal(t, "bad", details["alias"])
+		assert.Equal(t, "cdn.malware.test", details["domain"])
+		assert.Equal(t, false, details["deactivated"])
+	})
+
+	t.Run("Skips Flagged Links", func(t *testing.T) {
+		job := jobs.NewBlocklistScanJob(blocklist, linkRepo, auditRepo, true, time.Hour)
+		flagged, err := job.Scan(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, flagged)
+
+		link, err := linkRepo.GetByID(ctx, bad.ID)
+		require.NoError(t, err)
+		assert.True(t, link.IsActive)
+
+		entries, err := auditRepo.List(ctx, models.AuditLogFilter{LinkID: bad.ID})
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("Deactivates In Deactivate Mode", func(t *testing.T) {
+		worse := newLink("worse", "https://malware.test/")
+		job := jobs.NewBlocklistScanJob(blocklist, linkRepo, auditRepo, true, time.Hour)
+		flagged, err := job.Scan(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, flagged)
+
+		link, err := linkRepo.GetByID(ctx, worse.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, link.FlaggedAt)
+		assert.False(t, link.IsActive)
+
+		entries, err := auditRepo.List(ctx, models.AuditLogFilter{LinkID: worse.ID, Action: models.AuditLinkFlagged})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		var details map[string]interface{}
+		require.NoError(t, json.Unmarshal(entries[0].Details, &details))
+		assert.Equal(t, true, details["deactivated"])
+	})
+}
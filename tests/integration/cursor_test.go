@@ -0,0 +1,50 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/devingoodsell/go-links-free/internal/handlers"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec := handlers.NewCursorCodec("secret")
+	pos := &models.ListCursor{Value: "2024-03-01T17:30:00Z", ID: 42}
+	token := codec.Encode("links", "created_desc", true, pos)
+
+	t.Run("Round Trip", func(t *testing.T) {
+		decoded := &models.ListCursor{}
+		backward, err := codec.Decode(token, "links", "created_desc", decoded)
+		require.NoError(t, err)
+		assert.True(t, backward)
+		assert.Equal(t, pos, decoded)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		forged := codec.Encode("links", "created_desc", true, &models.ListCursor{Value: "x", ID: 1})
+		payload, _, _ := strings.Cut(forged, ".")
+		_, signature, _ := strings.Cut(token, ".")
+		_, err := codec.Decode(payload+"."+signature, "links", "created_desc", &models.ListCursor{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Other Key", func(t *testing.T) {
+		_, err := handlers.NewCursorCodec("other").Decode(token, "links", "created_desc", &models.ListCursor{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Other Listing Or Sort", func(t *testing.T) {
+		_, err := codec.Decode(token, "admin_links", "created_desc", &models.ListCursor{})
+		assert.Error(t, err)
+		_, err = codec.Decode(token, "links", "alias_asc", &models.ListCursor{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Garbage", func(t *testing.T) {
+		_, err := codec.Decode("not-a-cursor", "links", "created_desc", &models.ListCursor{})
+		assert.Error(t, err)
+	})
+}
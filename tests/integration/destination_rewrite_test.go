@@ -0,0 +1,81 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDestinationRewrite(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+	policy := services.NewURLPolicy(linkRepo, services.URLPolicyConfig{
+		AllowedSchemes: []string{"http", "https"},
+		DeniedDomains:  []string{"evil.com"},
+	})
+	rewriter := services.NewDestinationRewriter(linkRepo, policy)
+
+	owner := &models.User{Email: "rewrite@example.com"}
+	require.NoError(t, userRepo.Create(ctx, owner, "password123"))
+	for alias, destination := range map[string]string{
+		"wiki":  "https://wiki.old.example.com/display/ENG",
+		"space": "https://wiki.old.example.com/display/OPS?x=1",
+		"other": "https://docs.example.com",
+	} {
+		require.NoError(t, linkRepo.Create(ctx, &models.Link{
+			Alias: alias, DestinationURL: destination, CreatedBy: owner.ID, IsActive: true,
+		}))
+	}
+	scope := models.ListOptions{OwnerID: owner.ID}
+
+	t.Run("Prefix Preview", func(t *testing.T) {
+		items, err := rewriter.Plan(ctx, services.RewriteSpec{
+			Mode: services.RewritePrefix, Match: "https://wiki.old.example.com/", Replace: "https://wiki.example.com/",
+		}, scope)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, "space", items[0].Alias)
+		assert.Equal(t, "https://wiki.example.com/display/OPS?x=1", items[0].After)
+		assert.Empty(t, items[0].Error)
+	})
+
+	t.Run("Refused Destinations Block Apply", func(t *testing.T) {
+		items, err := rewriter.Plan(ctx, services.RewriteSpec{
+			Mode: services.RewriteRegex, Match: `^https://wiki\.old\.example\.com`, Replace: "https://evil.com",
+		}, scope)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.NotEmpty(t, items[0].Error)
+		assert.True(t, errors.Is(rewriter.Apply(ctx, items, owner.ID, ""), services.ErrRewriteInvalid))
+	})
+
+	t.Run("Regex Apply Records Revisions", func(t *testing.T) {
+		items, err := rewriter.Plan(ctx, services.RewriteSpec{
+			Mode: services.RewriteRegex, Match: `^https://wiki\.old\.example\.com/display/(\w+)`, Replace: "https://wiki.example.com/spaces/$1",
+		}, scope)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.NoError(t, rewriter.Apply(ctx, items, owner.ID, "wiki moved"))
+
+		link, err := linkRepo.GetByAlias(ctx, "wiki")
+		require.NoError(t, err)
+		assert.Equal(t, "https://wiki.example.com/spaces/ENG", link.DestinationURL)
+
+		revisions, err := linkRepo.ListRevisions(ctx, link.ID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 1)
+		assert.Equal(t, "https://wiki.old.example.com/display/ENG", revisions[0].OldDestinationURL)
+		assert.Equal(t, "wiki moved", revisions[0].Reason)
+
+		// Applying the stale plan again changes nothing.
+		err = rewriter.Apply(ctx, items, owner.ID, "")
+		assert.True(t, errors.Is(err, models.ErrStaleDestination))
+	})
+}
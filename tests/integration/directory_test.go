@@ -0,0 +1,69 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devingoodsell/go-links-free/internal/handlers"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectory(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+
+	alice := &models.User{Email: "alice@example.com"}
+	bob := &models.User{Email: "bob@example.com"}
+	require.NoError(t, userRepo.Create(ctx, alice, "password123"))
+	require.NoError(t, userRepo.Create(ctx, bob, "password123"))
+
+	require.NoError(t, linkRepo.Create(ctx, &models.Link{Alias: "handbook", DestinationURL: "https://example.com/handbook", Description: "Tips & <tricks>", CreatedBy: alice.ID, IsActive: true, Tags: []string{"onboarding"}}))
+	require.NoError(t, linkRepo.Create(ctx, &models.Link{Alias: "salaries", DestinationURL: "https://example.com/salaries", CreatedBy: bob.ID, IsActive: true, Visibility: models.VisibilityPrivate}))
+
+	handler := handlers.NewDirectoryHandler(linkRepo, handlers.NewCursorCodec("test-secret"), "https://go.example.com")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(asUser(bob.ID, false))
+	router.GET("/api/directory", handler.List)
+	router.GET("/directory", handler.Page)
+
+	t.Run("Lists Public Links Of Every User", func(t *testing.T) {
+		code, body := getJSON(t, router, "/api/directory")
+		require.Equal(t, http.StatusOK, code)
+
+		items := body["items"].([]interface{})
+		require.Len(t, items, 1)
+		entry := items[0].(map[string]interface{})
+		assert.Equal(t, "handbook", entry["alias"])
+		assert.Equal(t, "alice@example.com", entry["ownerEmail"])
+		assert.Equal(t, []interface{}{"onboarding"}, entry["tags"])
+	})
+
+	t.Run("Filters By Owner", func(t *testing.T) {
+		_, body := getJSON(t, router, "/api/directory?owner=bob@example.com")
+		assert.Empty(t, body["items"])
+
+		_, body = getJSON(t, router, "/api/directory?owner=alice@example.com")
+		assert.Len(t, body["items"], 1)
+	})
+
+	t.Run("Renders The Page", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/directory", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+		page := w.Body.String()
+		assert.Contains(t, page, `<a href="https://go.example.com/go/handbook">go/handbook</a>`)
+		assert.Contains(t, page, "Tips &amp; &lt;tricks&gt;")
+		assert.Contains(t, page, "alice@example.com")
+		assert.NotContains(t, page, "salaries")
+	})
+}
@@ -0,0 +1,150 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devingoodsell/go-links-free/internal/handlers"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDestination(t *testing.T) {
+	same := [][]string{
+		{"https://docs.example.com/guide/", "http://docs.example.com/guide"},
+		{"https://Docs.Example.com/guide", "https://docs.example.com/guide/"},
+		{"https://example.com/a?utm_source=slack&id=3", "https://example.com/a?id=3"},
+		{"https://example.com/a?b=2&a=1&fbclid=xyz", "https://example.com/a/?a=1&b=2"},
+		{"https://example.com/", "https://example.com"},
+	}
+	for _, pair := range same {
+		assert.Equal(t, services.NormalizeDestination(pair[0]), services.NormalizeDestination(pair[1]), pair)
+	}
+
+	different := [][]string{
+		{"https://example.com/a", "https://example.com/b"},
+		{"https://example.com/a?id=3", "https://example.com/a?id=4"},
+		{"https://example.com/Guide", "https://example.com/guide"},
+		{"https://example.com/#one", "https://example.com/#two"},
+	}
+	for _, pair := range different {
+		assert.NotEqual(t, services.NormalizeDestination(pair[0]), services.NormalizeDestination(pair[1]), pair)
+	}
+}
+
+func TestMergeLinks(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+
+	owner := &models.User{Email: "owner@example.com"}
+	require.NoError(t, userRepo.Create(ctx, owner, "password123"))
+
+	newLink := func(alias string, clicks int) *models.Link {
+		link := &models.Link{Alias: alias, DestinationURL: "https://docs.example.com/guide", CreatedBy: owner.ID, IsActive: true}
+		require.NoError(t, linkRepo.Create(ctx, link))
+		for i := 0; i < clicks; i++ {
+			require.NoError(t, linkRepo.IncrementStats(ctx, link.ID))
+		}
+		return link
+	}
+	keep, dup, older := newLink("guide", 2), newLink("guide-dup", 3), newLink("guide-old", 1)
+
+	t.Run("Moves Stats And Forwards", func(t *testing.T) {
+		require.NoError(t, linkRepo.MergeLinks(ctx, dup.ID, []int64{older.ID}))
+		require.NoError(t, linkRepo.MergeLinks(ctx, keep.ID, []int64{dup.ID}))
+
+		kept, err := linkRepo.GetByID(ctx, keep.ID)
+		require.NoError(t, err)
+		assert.Nil(t, kept.MergedInto)
+		assert.Equal(t, 6, kept.Stats.TotalCount)
+
+		for _, id := range []int64{dup.ID, older.ID} {
+			merged, err := linkRepo.GetByID(ctx, id)
+			require.NoError(t, err)
+			require.NotNil(t, merged.MergedInto)
+			// Links forwarding to a merged link are re-pointed, never chained
+			assert.Equal(t, keep.ID, *merged.MergedInto)
+			assert.Equal(t, 0, merged.Stats.TotalCount)
+
+			target, err := linkRepo.Forward(ctx, merged)
+			require.NoError(t, err)
+			assert.Equal(t, keep.ID, target.ID)
+		}
+	})
+
+	t.Run("Unknown Links Roll Back", func(t *testing.T) {
+		other := newLink("guide-new", 4)
+		err := linkRepo.MergeLinks(ctx, keep.ID, []int64{other.ID, 999999})
+		assert.True(t, errors.Is(err, models.ErrNotFound))
+
+		link, err := linkRepo.GetByID(ctx, other.ID)
+		require.NoError(t, err)
+		assert.Nil(t, link.MergedInto)
+		assert.Equal(t, 4, link.Stats.TotalCount)
+	})
+
+	t.Run("Forwarders Do Not Stand In For Unknown Links", func(t *testing.T) {
+		target, forwarder := newLink("guide-target", 0), newLink("guide-forwarder", 0)
+		require.NoError(t, linkRepo.MergeLinks(ctx, target.ID, []int64{forwarder.ID}))
+
+		err := linkRepo.MergeLinks(ctx, keep.ID, []int64{target.ID, 999999})
+		assert.True(t, errors.Is(err, models.ErrNotFound))
+
+		link, err := linkRepo.GetByID(ctx, target.ID)
+		require.NoError(t, err)
+		assert.Nil(t, link.MergedInto)
+	})
+
+	t.Run("Admin Merges Audit Every Other Owner", func(t *testing.T) {
+		admin := &models.User{Email: "admin@example.com", IsAdmin: true}
+		require.NoError(t, userRepo.Create(ctx, admin, "password123"))
+		auditRepo := models.NewAuditLogRepository(testDB)
+		theirs := newLink("guide-kept", 0)
+		mine := &models.Link{Alias: "guide-mine", DestinationURL: "https://docs.example.com/guide", CreatedBy: admin.ID, IsActive: true}
+		require.NoError(t, linkRepo.Create(ctx, mine))
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(asUser(admin.ID, true))
+		router.POST("/api/links/merge", handlers.NewDuplicateHandler(linkRepo, auditRepo).Merge)
+
+		w := httptest.NewRecorder()
+		body := bytes.NewBufferString(`{"keep": "guide-kept", "merge": ["guide-mine"], "reason": "duplicates"}`)
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/links/merge", body))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		entries, err := auditRepo.List(ctx, models.AuditLogFilter{TargetUserID: owner.ID, LinkID: theirs.ID})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, models.AuditLinkAdminEdit, entries[0].Action)
+		assert.Contains(t, string(entries[0].Details), "guide-mine")
+
+		entries, err = auditRepo.List(ctx, models.AuditLogFilter{TargetUserID: admin.ID})
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("Redirect Follows Forwarding", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.GET("/go/:alias", handlers.NewLinkHandler(linkRepo, nil, nil, nil, nil, nil).Redirect)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/go/guide-old", nil))
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, keep.DestinationURL, w.Header().Get("Location"))
+
+		kept, err := linkRepo.GetByID(ctx, keep.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 7, kept.Stats.TotalCount)
+	})
+}
@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/handlers"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFavoritesHidePrivateLinks(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+
+	owner := &models.User{Email: "owner@example.com"}
+	other := &models.User{Email: "other@example.com"}
+	require.NoError(t, userRepo.Create(ctx, owner, "password123"))
+	require.NoError(t, userRepo.Create(ctx, other, "password123"))
+
+	secret := &models.Link{Alias: "secret", DestinationURL: "https://example.com/secret", CreatedBy: owner.ID, IsActive: true, Visibility: models.VisibilityPrivate}
+	shared := &models.Link{Alias: "shared", DestinationURL: "https://example.com/shared", CreatedBy: owner.ID, IsActive: true}
+	require.NoError(t, linkRepo.Create(ctx, secret))
+	require.NoError(t, linkRepo.Create(ctx, shared))
+
+	handler := handlers.NewFavoriteHandler(linkRepo, services.NewSuggestIndex(linkRepo, time.Hour))
+	gin.SetMode(gin.TestMode)
+	routerFor := func(userID int64) *gin.Engine {
+		router := gin.New()
+		router.Use(asUser(userID, false))
+		router.GET("/api/favorites", handler.List)
+		router.PUT("/api/favorites", handler.Reorder)
+		router.PUT("/api/favorites/:alias", handler.Star)
+		return router
+	}
+	star := func(router *gin.Engine, alias string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/favorites/"+alias, nil))
+		return w.Code
+	}
+
+	t.Run("Only The Owner Stars A Private Link", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, star(routerFor(other.ID), "secret"))
+		assert.Equal(t, http.StatusOK, star(routerFor(owner.ID), "secret"))
+
+		_, body := getJSON(t, routerFor(owner.ID), "/api/favorites")
+		assert.Len(t, body["items"], 1)
+	})
+
+	t.Run("Links Made Private Drop Out", func(t *testing.T) {
+		router := routerFor(other.ID)
+		require.Equal(t, http.StatusOK, star(router, "shared"))
+		_, body := getJSON(t, router, "/api/favorites")
+		assert.Len(t, body["items"], 1)
+
+		shared.Visibility = models.VisibilityPrivate
+		require.NoError(t, linkRepo.Update(ctx, shared))
+		_, body = getJSON(t, router, "/api/favorites")
+		assert.Empty(t, body["items"])
+
+		count, err := linkRepo.CountFavorites(ctx, other.ID)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("Private Links Cannot Be Reordered By Others", func(t *testing.T) {
+		reorder := func(alias string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			body := bytes.NewBufferString(`{"aliases": ["` + alias + `"]}`)
+			routerFor(other.ID).ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/favorites", body))
+			return w
+		}
+
+		missing, secret := reorder("missing"), reorder("secret")
+		assert.Equal(t, http.StatusBadRequest, secret.Code)
+		assert.JSONEq(t, `{"error": "unknown alias secret"}`, secret.Body.String())
+		assert.Equal(t, missing.Code, secret.Code)
+	})
+}
@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/handlers"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeywordSearch(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+
+	owner := &models.User{Email: "owner@example.com"}
+	require.NoError(t, userRepo.Create(ctx, owner, "password123"))
+
+	past := time.Now().Add(-time.Hour)
+	jira := &models.Link{Alias: "jira", DestinationURL: "https://jira.example.com/browse/{1}", CreatedBy: owner.ID, IsActive: true}
+	paused := &models.Link{Alias: "paused", DestinationURL: "https://example.com/paused", CreatedBy: owner.ID}
+	gone := &models.Link{Alias: "gone", DestinationURL: "https://example.com/gone", CreatedBy: owner.ID, IsActive: true, ExpiresAt: &past}
+	for _, link := range []*models.Link{jira, paused, gone} {
+		require.NoError(t, linkRepo.Create(ctx, link))
+	}
+
+	handler := handlers.NewKeywordSearchHandler(linkRepo, services.NewSuggestIndex(linkRepo, time.Hour), "https://go.example.com")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(asUser(owner.ID, false))
+	router.GET("/search", handler.Search)
+
+	search := func(q string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/search?q="+url.QueryEscape(q), nil))
+		return w
+	}
+
+	t.Run("Alias Redirects With Arguments", func(t *testing.T) {
+		w := search("jira ABC-123")
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, "https://jira.example.com/browse/ABC-123", w.Header().Get("Location"))
+
+		link, err := linkRepo.GetByID(ctx, jira.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, link.Stats.TotalCount)
+	})
+
+	t.Run("Strips The go/ Prefix", func(t *testing.T) {
+		w := search("go/jira ABC-7")
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, "https://jira.example.com/browse/ABC-7", w.Header().Get("Location"))
+	})
+
+	t.Run("Miss Renders Results", func(t *testing.T) {
+		w := search("nowhere")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, w.Body.String(), "No go link named <strong>nowhere</strong>")
+	})
+
+	t.Run("Multi Word Miss Is A Search", func(t *testing.T) {
+		w := search("nowhere to be found")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "No links match your search.")
+	})
+
+	t.Run("Inactive And Expired Links Fall Through", func(t *testing.T) {
+		for _, alias := range []string{"paused", "gone"} {
+			w := search(alias)
+			assert.Equal(t, http.StatusNotFound, w.Code, alias)
+			assert.Empty(t, w.Header().Get("Location"), alias)
+			assert.Contains(t, w.Body.String(), "No go link named <strong>"+alias+"</strong>", alias)
+		}
+	})
+}
+
+func TestKeywordSearchDescriptors(t *testing.T) {
+	index := services.NewSuggestIndex(fakeActiveLinks{
+		activeLink("docs", 10),
+		activeLink("docs-api", 500),
+		activeLink("oncall", 0),
+	}, time.Hour)
+	require.NoError(t, index.Refresh(context.Background()))
+
+	handler := handlers.NewKeywordSearchHandler(nil, index, "https://go.example.com")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/opensearch.xml", handler.OpenSearchDescription)
+	router.GET("/search/suggest", handler.Suggestions)
+
+	t.Run("OpenSearch Description", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/opensearch.xml", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/opensearchdescription+xml")
+		assert.Contains(t, w.Body.String(), `template="https://go.example.com/search?q={searchTerms}"`)
+		assert.Contains(t, w.Body.String(), `template="https://go.example.com/search/suggest?q={searchTerms}"`)
+	})
+
+	t.Run("Suggestions", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/search/suggest?q=doc", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var body []interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body, 3)
+		assert.Equal(t, "doc", body[0])
+		assert.Equal(t, []interface{}{"docs-api", "docs"}, body[1])
+		assert.Equal(t, []interface{}{"https://example.com/docs-api", "https://example.com/docs"}, body[2])
+	})
+}
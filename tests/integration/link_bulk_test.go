@@ -0,0 +1,108 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkApply(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+
+	owner := &models.User{Email: "owner@example.com"}
+	other := &models.User{Email: "other@example.com"}
+	require.NoError(t, userRepo.Create(ctx, owner, "password123"))
+	require.NoError(t, userRepo.Create(ctx, other, "password123"))
+
+	newLink := func(alias string, ownerID int64) *models.Link {
+		link := &models.Link{Alias: alias, DestinationURL: "https://example.com/" + alias, CreatedBy: ownerID, IsActive: true}
+		require.NoError(t, linkRepo.Create(ctx, link))
+		return link
+	}
+	mine1, mine2 := newLink("mine-1", owner.ID), newLink("mine-2", owner.ID)
+	theirs := newLink("theirs", other.ID)
+
+	t.Run("Atomic Batch Is All Or Nothing", func(t *testing.T) {
+		result, err := linkRepo.BulkApply(ctx, models.BulkOptions{
+			IDs:     []int64{mine1.ID, theirs.ID, 999999},
+			ActorID: owner.ID,
+			Atomic:  true,
+		}, models.BulkAction{Type: models.BulkActionDeactivate})
+		require.NoError(t, err)
+		assert.False(t, result.Applied)
+		require.Len(t, result.Items, 3)
+		assert.Equal(t, models.BulkSkipped, result.Items[0].Status)
+		assert.Equal(t, models.BulkForbidden, result.Items[1].Status)
+		assert.Equal(t, models.BulkNotFound, result.Items[2].Status)
+		assert.True(t, errors.Is(result.Err(), models.ErrUnauthorized))
+
+		link, err := linkRepo.GetByID(ctx, mine1.ID)
+		require.NoError(t, err)
+		assert.True(t, link.IsActive)
+	})
+
+	t.Run("Best Effort Applies What It Can", func(t *testing.T) {
+		result, err := linkRepo.BulkApply(ctx, models.BulkOptions{
+			IDs:     []int64{mine1.ID, theirs.ID},
+			ActorID: owner.ID,
+		}, models.BulkAction{Type: models.BulkActionAddTag, Tag: "team"})
+		require.NoError(t, err)
+		assert.True(t, result.Applied)
+		assert.Equal(t, 1, result.Counts[models.BulkOK])
+		assert.Equal(t, 1, result.Counts[models.BulkForbidden])
+	})
+
+	t.Run("Only Admins Activate Flagged Links", func(t *testing.T) {
+		require.NoError(t, linkRepo.Flag(ctx, mine1.ID, "blocklisted", true))
+
+		for _, atomic := range []bool{true, false} {
+			result, err := linkRepo.BulkApply(ctx, models.BulkOptions{
+				IDs:     []int64{mine1.ID},
+				ActorID: owner.ID,
+				Atomic:  atomic,
+			}, models.BulkAction{Type: models.BulkActionActivate})
+			require.NoError(t, err)
+			assert.Equal(t, models.BulkForbidden, result.Items[0].Status)
+			assert.True(t, errors.Is(result.Err(), models.ErrUnauthorized))
+		}
+		link, err := linkRepo.GetByID(ctx, mine1.ID)
+		require.NoError(t, err)
+		assert.False(t, link.IsActive)
+
+		result, err := linkRepo.BulkApply(ctx, models.BulkOptions{
+			IDs:     []int64{mine1.ID},
+			ActorID: other.ID,
+			Admin:   true,
+			Atomic:  true,
+		}, models.BulkAction{Type: models.BulkActionActivate})
+		require.NoError(t, err)
+		assert.Equal(t, models.BulkOK, result.Items[0].Status)
+		assert.Equal(t, owner.ID, result.Items[0].OwnerID)
+		link, err = linkRepo.GetByID(ctx, mine1.ID)
+		require.NoError(t, err)
+		assert.True(t, link.IsActive)
+	})
+
+	t.Run("Filter Selects Only The Actor's Links", func(t *testing.T) {
+		result, err := linkRepo.BulkApply(ctx, models.BulkOptions{
+			Filter:  &models.ListOptions{},
+			ActorID: owner.ID,
+			Atomic:  true,
+		}, models.BulkAction{Type: models.BulkActionDelete})
+		require.NoError(t, err)
+		assert.True(t, result.Applied)
+		assert.Equal(t, 2, result.Counts[models.BulkOK])
+
+		_, err = linkRepo.GetByID(ctx, mine2.ID)
+		assert.True(t, errors.Is(err, models.ErrNotFound))
+		_, err = linkRepo.GetByID(ctx, theirs.ID)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,118 @@
+package integration
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/jobs"
+	"github.com/devingoodsell/go-links-free/internal/safehttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkHealthCheck(t *testing.T) {
+	var mu sync.Mutex
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/ok":
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/private":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	job := jobs.NewLinkHealthJob(nil, server.Client(), jobs.LinkHealthConfig{Timeout: 50 * time.Millisecond})
+	ctx := context.Background()
+
+	t.Run("healthy", func(t *testing.T) {
+		health := job.Check(ctx, server.URL+"/ok")
+		assert.True(t, health.Healthy)
+		assert.Equal(t, http.StatusOK, health.StatusCode)
+		assert.Empty(t, health.Error)
+		assert.False(t, health.CheckedAt.IsZero())
+	})
+
+	t.Run("falls back to GET", func(t *testing.T) {
+		health := job.Check(ctx, server.URL+"/no-head")
+		assert.True(t, health.Healthy)
+		assert.Equal(t, http.StatusOK, health.StatusCode)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Contains(t, methods, "HEAD /no-head")
+		assert.Contains(t, methods, "GET /no-head")
+	})
+
+	t.Run("sign-in walls are healthy", func(t *testing.T) {
+		health := job.Check(ctx, server.URL+"/private")
+		assert.True(t, health.Healthy)
+		assert.Equal(t, http.StatusUnauthorized, health.StatusCode)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		health := job.Check(ctx, server.URL+"/gone")
+		assert.False(t, health.Healthy)
+		assert.Equal(t, http.StatusNotFound, health.StatusCode)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		health := job.Check(ctx, server.URL+"/slow")
+		assert.False(t, health.Healthy)
+		assert.Zero(t, health.StatusCode)
+		assert.Equal(t, "timed out", health.Error)
+	})
+}
+
+func TestLinkHealthCheckInternalAddresses(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	// The httptest server listens on loopback, like an internal service
+	job := jobs.NewLinkHealthJob(nil, safehttp.NewClient(time.Second), jobs.LinkHealthConfig{})
+	health := job.Check(context.Background(), server.URL+"/admin")
+	assert.False(t, health.Healthy)
+	assert.Zero(t, health.StatusCode)
+	assert.Equal(t, "address not allowed", health.Error)
+	assert.False(t, requested)
+
+	for _, ip := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"100.64.0.1", "0.0.0.0", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1", "64:ff9b::a9fe:a9fe"} {
+		assert.False(t, safehttp.Allowed(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{"93.184.216.34", "8.8.8.8", "2606:4700:4700::1111"} {
+		assert.True(t, safehttp.Allowed(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestLinkHealthCheckHostInterval(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	job := jobs.NewLinkHealthJob(nil, server.Client(), jobs.LinkHealthConfig{HostInterval: 50 * time.Millisecond})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.True(t, job.Check(context.Background(), server.URL).Healthy)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
@@ -0,0 +1,158 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/handlers"
+	"github.com/devingoodsell/go-links-free/internal/linkio"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkImport(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+
+	owner := &models.User{Email: "owner@example.com"}
+	require.NoError(t, userRepo.Create(ctx, owner, "password123"))
+	taken := &models.Link{Alias: "taken", DestinationURL: "https://example.com/taken", CreatedBy: owner.ID, IsActive: true}
+	require.NoError(t, linkRepo.Create(ctx, taken))
+
+	aliasPolicy, err := services.NewAliasPolicy(nil, nil, nil)
+	require.NoError(t, err)
+	urlPolicy := services.NewURLPolicy(linkRepo, services.URLPolicyConfig{AllowedSchemes: []string{"http", "https"}})
+	importer := services.NewLinkImporter(linkRepo, userRepo, aliasPolicy, urlPolicy)
+
+	t.Run("Save All Is All Or Nothing", func(t *testing.T) {
+		fresh := &models.Link{Alias: "fresh", DestinationURL: "https://example.com/fresh", CreatedBy: owner.ID, IsActive: true}
+		clash := &models.Link{Alias: "taken", DestinationURL: "https://example.com/clash", CreatedBy: owner.ID, IsActive: true}
+
+		failed, err := linkRepo.SaveAll(ctx, []*models.Link{fresh, clash})
+		assert.Equal(t, 1, failed)
+		assert.True(t, errors.Is(err, models.ErrDuplicate))
+		assert.Zero(t, fresh.ID)
+
+		_, err = linkRepo.GetByAlias(ctx, "fresh")
+		assert.True(t, errors.Is(err, models.ErrNotFound))
+	})
+
+	t.Run("Fail Strategy Imports Nothing On Conflict", func(t *testing.T) {
+		result, err := importer.Import(ctx, []linkio.Record{
+			{Row: 1, Alias: "one", DestinationURL: "https://example.com/one"},
+			{Row: 2, Alias: "taken", DestinationURL: "https://example.com/two"},
+		}, services.ImportOptions{OwnerID: owner.ID, Strategy: services.ImportFail})
+		require.NoError(t, err)
+		assert.False(t, result.Applied)
+		assert.Equal(t, services.ImportConflict, result.Rows[1].Status)
+
+		_, err = linkRepo.GetByAlias(ctx, "one")
+		assert.True(t, errors.Is(err, models.ErrNotFound))
+	})
+
+	t.Run("Fail Strategy Imports Everything Otherwise", func(t *testing.T) {
+		result, err := importer.Import(ctx, []linkio.Record{
+			{Row: 1, Alias: "one", DestinationURL: "https://example.com/one"},
+			{Row: 2, Alias: "two", DestinationURL: "https://example.com/two"},
+		}, services.ImportOptions{OwnerID: owner.ID, Strategy: services.ImportFail})
+		require.NoError(t, err)
+		assert.True(t, result.Applied)
+		assert.Equal(t, 2, result.Counts[services.ImportCreated])
+		for _, row := range result.Rows {
+			assert.NotZero(t, row.LinkID)
+		}
+	})
+
+	t.Run("Past Expiry Is Invalid", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		result, err := importer.Import(ctx, []linkio.Record{
+			{Row: 1, Alias: "old", DestinationURL: "https://example.com/old", ExpiresAt: &past},
+		}, services.ImportOptions{OwnerID: owner.ID, DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, services.ImportInvalid, result.Rows[0].Status)
+		assert.Equal(t, "expiration time must be in the future", result.Rows[0].Message)
+	})
+
+	t.Run("Maps Owners With A Fallback", func(t *testing.T) {
+		heir := &models.User{Email: "heir@example.com"}
+		require.NoError(t, userRepo.Create(ctx, heir, "password123"))
+
+		result, err := importer.Import(ctx, []linkio.Record{
+			{Row: 1, Alias: "mapped", DestinationURL: "https://example.com/mapped", OwnerEmail: "owner@example.com"},
+			{Row: 2, Alias: "orphan", DestinationURL: "https://example.com/orphan", OwnerEmail: "gone@example.com"},
+		}, services.ImportOptions{OwnerID: owner.ID, MapOwners: true, FallbackOwnerID: heir.ID})
+		require.NoError(t, err)
+		require.True(t, result.Applied)
+		assert.Contains(t, result.Rows[1].Message, "owner gone@example.com not found")
+
+		mapped, err := linkRepo.GetByAlias(ctx, "mapped")
+		require.NoError(t, err)
+		assert.Equal(t, owner.ID, mapped.CreatedBy)
+		orphan, err := linkRepo.GetByAlias(ctx, "orphan")
+		require.NoError(t, err)
+		assert.Equal(t, heir.ID, orphan.CreatedBy)
+	})
+
+	t.Run("Fallback Owner Requires Map Owners", func(t *testing.T) {
+		handler := handlers.NewImportExportHandler(linkRepo, userRepo, importer, "https://go.example.com")
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(asUser(owner.ID, true))
+		router.POST("/api/links/import", handler.Import)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/links/import?format=csv&fallback_owner=heir@example.com",
+			strings.NewReader("alias,destination_url\nstray,https://example.com/stray\n"))
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "fallback_owner requires map_owners=true")
+
+		_, err := linkRepo.GetByAlias(ctx, "stray")
+		assert.True(t, errors.Is(err, models.ErrNotFound))
+	})
+
+	t.Run("Only Admins Override The Alias Policy", func(t *testing.T) {
+		strict, err := services.NewAliasPolicy(nil, []string{"admin"}, nil)
+		require.NoError(t, err)
+		handler := handlers.NewImportExportHandler(linkRepo, userRepo,
+			services.NewLinkImporter(linkRepo, userRepo, strict, urlPolicy), "https://go.example.com")
+		gin.SetMode(gin.TestMode)
+
+		importAs := func(isAdmin bool) string {
+			router := gin.New()
+			router.Use(asUser(owner.ID, isAdmin))
+			router.POST("/api/links/import", handler.Import)
+			req := httptest.NewRequest(http.MethodPost, "/api/links/import?format=csv&override=true",
+				strings.NewReader("alias,destination_url\nadmin,https://example.com/admin\n"))
+			req.Header.Set("Content-Type", "text/csv")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var result services.ImportResult
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+			require.Len(t, result.Rows, 1)
+			return result.Rows[0].Status
+		}
+
+		assert.Equal(t, services.ImportReservedAlias, importAs(false))
+		_, err = linkRepo.GetByAlias(ctx, "admin")
+		assert.True(t, errors.Is(err, models.ErrNotFound))
+
+		assert.Equal(t, services.ImportCreated, importAs(true))
+		_, err = linkRepo.GetByAlias(ctx, "admin")
+		assert.NoError(t, err)
+	})
+}
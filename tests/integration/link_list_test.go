@@ -0,0 +1,90 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/handlers"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCursor(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 500, time.FixedZone("EST", -5*3600))
+	link := &models.Link{
+		ID:         42,
+		Alias:      "docs",
+		OwnerEmail: "owner@example.com",
+		CreatedAt:  created,
+		Stats:      &models.LinkStats{TotalCount: 7},
+	}
+
+	tests := []struct {
+		sortBy   string
+		expected string
+	}{
+		{"", "2024-03-01T17:30:00.0000005Z"},
+		{"alias_asc", "docs"},
+		{"owner_desc", "owner@example.com"},
+		{"clicks_desc", "7"},
+		{"expires_asc", "infinity"},
+		{"accessed_desc", "-infinity"},
+		{"bogus_desc", "2024-03-01T17:30:00.0000005Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			cursor := models.ListOptions{SortBy: tt.sortBy}.CursorFor(link)
+			assert.Equal(t, tt.expected, cursor.Value)
+			assert.Equal(t, int64(42), cursor.ID)
+		})
+	}
+}
+
+func TestLinkListTotals(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+
+	owner := &models.User{Email: "owner@example.com"}
+	require.NoError(t, userRepo.Create(ctx, owner, "password123"))
+	for i := 0; i < 3; i++ {
+		link := &models.Link{Alias: fmt.Sprintf("link-%d", i), DestinationURL: "https://example.com", CreatedBy: owner.ID, IsActive: true}
+		require.NoError(t, linkRepo.Create(ctx, link))
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/links", asUser(owner.ID, false),
+		handlers.NewLinkHandler(linkRepo, nil, nil, nil, handlers.NewCursorCodec("secret"), nil).List)
+
+	t.Run("Whole Listing Is Counted Exactly", func(t *testing.T) {
+		code, body := getJSON(t, router, "/api/links")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 3.0, body["totalCount"])
+		assert.NotContains(t, body, "totalIsApproximate")
+	})
+
+	t.Run("Estimated By Default", func(t *testing.T) {
+		_, body := getJSON(t, router, "/api/links?pageSize=2")
+		assert.Contains(t, body, "totalCount")
+		assert.Equal(t, true, body["totalIsApproximate"])
+	})
+
+	t.Run("Exact On Request", func(t *testing.T) {
+		_, body := getJSON(t, router, "/api/links?pageSize=2&total=exact")
+		assert.Equal(t, 3.0, body["totalCount"])
+		assert.NotContains(t, body, "totalIsApproximate")
+	})
+
+	t.Run("None", func(t *testing.T) {
+		_, body := getJSON(t, router, "/api/links?pageSize=2&total=none")
+		assert.NotContains(t, body, "totalCount")
+	})
+}
@@ -0,0 +1,31 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandDestination(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+		args        []string
+		expected    string
+	}{
+		{"No Arguments", "https://docs.example.com/home", nil, "https://docs.example.com/home"},
+		{"Positional", "https://jira.example.com/browse/{1}", []string{"ABC-123"}, "https://jira.example.com/browse/ABC-123"},
+		{"All Arguments", "https://www.google.com/search?q={*}", []string{"go", "links"}, "https://www.google.com/search?q=go+links"},
+		{"Percent S", "https://example.com/?q=%s", []string{"a&b"}, "https://example.com/?q=a%26b"},
+		{"Missing Argument", "https://example.com/{1}/{2}", []string{"x"}, "https://example.com/x/"},
+		{"Appended To Path", "https://docs.example.com/", []string{"api", "v2"}, "https://docs.example.com/api/v2"},
+		{"Escaped Path Segment", "https://docs.example.com", []string{"a b"}, "https://docs.example.com/a%20b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, services.ExpandDestination(tt.destination, tt.args))
+		})
+	}
+}
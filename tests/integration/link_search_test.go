@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkSearch(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+
+	owner := &models.User{Email: "owner@example.com"}
+	other := &models.User{Email: "other@example.com"}
+	require.NoError(t, userRepo.Create(ctx, owner, "password123"))
+	require.NoError(t, userRepo.Create(ctx, other, "password123"))
+
+	wiki := &models.Link{Alias: "wiki", DestinationURL: "https://wiki.example.com", Description: "Team wiki", CreatedBy: owner.ID, IsActive: true}
+	handbook := &models.Link{Alias: "handbook", DestinationURL: "https://example.com/handbook", Description: "Tom & Jerry wiki pages", CreatedBy: owner.ID, IsActive: true}
+	drafts := &models.Link{Alias: "drafts", DestinationURL: "https://example.com/drafts", Description: "Unpublished wiki drafts", CreatedBy: owner.ID, IsActive: true, Visibility: models.VisibilityPrivate}
+	retired := &models.Link{Alias: "old-wiki", DestinationURL: "https://old.example.com", CreatedBy: owner.ID}
+	for _, link := range []*models.Link{wiki, handbook, drafts, retired} {
+		require.NoError(t, linkRepo.Create(ctx, link))
+	}
+
+	hitIDs := func(result *models.SearchResult) []int64 {
+		ids := make([]int64, len(result.Hits))
+		for i, hit := range result.Hits {
+			ids[i] = hit.ID
+		}
+		return ids
+	}
+
+	t.Run("Finds Links", func(t *testing.T) {
+		result, err := linkRepo.Search(ctx, models.SearchOptions{Query: "wiki", UserID: owner.ID})
+		require.NoError(t, err)
+		require.Len(t, result.Hits, 4)
+
+		hit := result.Hits[0]
+		assert.Equal(t, wiki.ID, hit.ID)
+		assert.Equal(t, "owner@example.com", hit.OwnerEmail)
+		assert.Nil(t, hit.MergedInto)
+		assert.Greater(t, hit.Score, 0.0)
+		assert.Contains(t, hit.Highlights["alias"], "<mark>wiki</mark>")
+	})
+
+	t.Run("Ranks Alias Matches First", func(t *testing.T) {
+		result, err := linkRepo.Search(ctx, models.SearchOptions{Query: "wiki", UserID: other.ID})
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Hits)
+		assert.Equal(t, wiki.ID, result.Hits[0].ID)
+		for i := 1; i < len(result.Hits); i++ {
+			assert.GreaterOrEqual(t, result.Hits[i-1].Score, result.Hits[i].Score)
+		}
+	})
+
+	t.Run("Hides Other Users' Private And Inactive Links", func(t *testing.T) {
+		result, err := linkRepo.Search(ctx, models.SearchOptions{Query: "wiki", UserID: other.ID})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int64{wiki.ID, handbook.ID}, hitIDs(result))
+
+		result, err = linkRepo.Search(ctx, models.SearchOptions{Query: "wiki", UserID: owner.ID})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int64{wiki.ID, handbook.ID, drafts.ID, retired.ID}, hitIDs(result))
+	})
+
+	t.Run("Escapes Highlights", func(t *testing.T) {
+		result, err := linkRepo.Search(ctx, models.SearchOptions{Query: "jerry", UserID: other.ID})
+		require.NoError(t, err)
+		require.Len(t, result.Hits, 1)
+
+		description := result.Hits[0].Highlights["description"]
+		assert.Contains(t, description, "&amp;")
+		assert.Contains(t, description, "<mark>Jerry</mark>")
+		assert.NotContains(t, description, " & ")
+	})
+
+	t.Run("Pages With Cursors", func(t *testing.T) {
+		all, err := linkRepo.Search(ctx, models.SearchOptions{Query: "wiki", UserID: owner.ID})
+		require.NoError(t, err)
+		require.Len(t, all.Hits, 4)
+
+		first, err := linkRepo.Search(ctx, models.SearchOptions{Query: "wiki", UserID: owner.ID, Limit: 2})
+		require.NoError(t, err)
+		assert.True(t, first.HasMore)
+		assert.Equal(t, hitIDs(all)[:2], hitIDs(first))
+
+		last := first.Hits[1]
+		second, err := linkRepo.Search(ctx, models.SearchOptions{Query: "wiki", UserID: owner.ID, Limit: 2,
+			After: &models.SearchPosition{Score: last.Score, ID: last.ID}})
+		require.NoError(t, err)
+		assert.False(t, second.HasMore)
+		assert.Equal(t, hitIDs(all)[2:], hitIDs(second))
+
+		head := second.Hits[0]
+		back, err := linkRepo.Search(ctx, models.SearchOptions{Query: "wiki", UserID: owner.ID, Limit: 2,
+			Before: &models.SearchPosition{Score: head.Score, ID: head.ID}})
+		require.NoError(t, err)
+		assert.False(t, back.HasMore)
+		assert.Equal(t, hitIDs(first), hitIDs(back))
+	})
+}
@@ -0,0 +1,205 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/devingoodsell/go-links-free/internal/linkio"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVImport(t *testing.T) {
+	importer, err := linkio.LookupImporter("csv")
+	require.NoError(t, err)
+
+	input := "\ufeffAlias,URL,Tags,Expires_At\n" +
+		"docs,https://docs.example.com,eng;wiki,\n" +
+		"jira,https://jira.example.com,,2030-01-02T03:04:05Z\n"
+	records, err := importer.Parse(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, 2, records[0].Row)
+	assert.Equal(t, "docs", records[0].Alias)
+	assert.Equal(t, "https://docs.example.com", records[0].DestinationURL)
+	assert.Equal(t, []string{"eng", "wiki"}, records[0].Tags)
+	assert.Nil(t, records[0].ExpiresAt)
+	require.NotNil(t, records[1].ExpiresAt)
+	assert.Equal(t, 2030, records[1].ExpiresAt.Year())
+
+	_, err = importer.Parse(strings.NewReader("name,url\nx,https://x.example.com\n"))
+	assert.Error(t, err)
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	links := []*models.Link{
+		{Alias: "docs", DestinationURL: "https://docs.example.com", Tags: []string{"eng", "wiki"}},
+		{Alias: "jira", DestinationURL: "https://jira.example.com", OwnerEmail: "a@example.com"},
+	}
+
+	for _, format := range []string{"csv", "json"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, _, extension, err := linkio.NewWriter(format, &buf, linkio.ExportOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, format, extension)
+			for _, link := range links {
+				require.NoError(t, writer.Write(link))
+			}
+			require.NoError(t, writer.Close())
+
+			importer, err := linkio.LookupImporter(format)
+			require.NoError(t, err)
+			records, err := importer.Parse(&buf)
+			require.NoError(t, err)
+			require.Len(t, records, 2)
+			assert.Equal(t, "docs", records[0].Alias)
+			assert.Equal(t, []string{"eng", "wiki"}, records[0].Tags)
+			assert.Equal(t, "a@example.com", records[1].OwnerEmail)
+		})
+	}
+
+	_, _, _, err := linkio.NewWriter("xml", &bytes.Buffer{}, linkio.ExportOptions{})
+	assert.ErrorIs(t, err, linkio.ErrUnknownFormat)
+}
+
+func TestCSVExportEscapesFormulas(t *testing.T) {
+	link := &models.Link{
+		Alias:          "budget",
+		DestinationURL: "https://example.com/budget",
+		Description:    `=HYPERLINK("https://evil.example.com","click")`,
+		Tags:           []string{"+finance", "q3"},
+		OwnerEmail:     "@owner@example.com",
+	}
+
+	var buf bytes.Buffer
+	writer, _, _, err := linkio.NewWriter("csv", &buf, linkio.ExportOptions{})
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(link))
+	require.NoError(t, writer.Close())
+
+	rows, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, []string{"budget", "https://example.com/budget", `'=HYPERLINK("https://evil.example.com","click")`,
+		"'+finance,q3", "", "'@owner@example.com"}, rows[1])
+
+	for _, cell := range []string{"-1", "\tcmd", "\rcmd"} {
+		buf.Reset()
+		writer, _, _, err := linkio.NewWriter("csv", &buf, linkio.ExportOptions{})
+		require.NoError(t, err)
+		require.NoError(t, writer.Write(&models.Link{Alias: "x", DestinationURL: "https://example.com", Description: cell}))
+		require.NoError(t, writer.Close())
+		rows, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, "'"+cell, rows[1][2])
+	}
+
+	t.Run("Imports Unchanged", func(t *testing.T) {
+		buf.Reset()
+		writer, _, _, err := linkio.NewWriter("csv", &buf, linkio.ExportOptions{})
+		require.NoError(t, err)
+		require.NoError(t, writer.Write(link))
+		require.NoError(t, writer.Close())
+
+		importer, err := linkio.LookupImporter("csv")
+		require.NoError(t, err)
+		records, err := importer.Parse(&buf)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, link.Description, records[0].Description)
+		assert.Equal(t, link.Tags, records[0].Tags)
+		assert.Equal(t, link.OwnerEmail, records[0].OwnerEmail)
+	})
+}
+
+func TestYOURLSSQLImport(t *testing.T) {
+	importer, err := linkio.LookupImporter("yourls-sql")
+	require.NoError(t, err)
+
+	dump := "-- MySQL dump\n" +
+		"INSERT INTO `yourls_options` VALUES (1,'version','1.9');\n" +
+		"INSERT INTO `yourls_url` (`keyword`, `url`, `title`, `timestamp`, `ip`, `clicks`) VALUES " +
+		"('docs','https://docs.example.com/?a=1&b=2','It\\'s the docs','2024-01-01 00:00:00','127.0.0.1',4)," +
+		"('wiki','https://wiki.example.com',NULL,'2024-01-01 00:00:00','127.0.0.1',0);\n"
+	records, err := importer.Parse(strings.NewReader(dump))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "docs", records[0].Alias)
+	assert.Equal(t, "https://docs.example.com/?a=1&b=2", records[0].DestinationURL)
+	assert.Equal(t, "It's the docs", records[0].Description)
+	assert.Equal(t, "", records[1].Description)
+
+	_, err = importer.Parse(strings.NewReader("INSERT INTO `yourls_url` VALUES ('docs','https://x"))
+	assert.Error(t, err)
+}
+
+func TestBookmarksImport(t *testing.T) {
+	importer, err := linkio.LookupImporter("bookmarks")
+	require.NoError(t, err)
+
+	input := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<TITLE>Bookmarks</TITLE>
+<DL><p>
+    <DT><H3 PERSONAL_TOOLBAR_FOLDER="true">Bookmarks bar</H3>
+    <DL><p>
+        <DT><H3>Team Wiki</H3>
+        <DL><p>
+            <DT><A HREF="https://wiki.example.com/onboarding" SHORTCUTURL="onboard">Onboarding Guide</A>
+            <DD>Start here
+        </DL><p>
+        <DT><A HREF="https://jira.example.com">Jira Board!</A>
+        <DT><A HREF="javascript:void(0)">Bookmarklet</A>
+    </DL><p>
+</DL><p>`
+	records, err := importer.Parse(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "onboard", records[0].Alias)
+	assert.Equal(t, []string{"team-wiki"}, records[0].Tags)
+	assert.Equal(t, "Onboarding Guide - Start here", records[0].Description)
+	assert.Equal(t, "jira-board", records[1].Alias)
+	assert.Empty(t, records[1].Tags)
+}
+
+func TestShareableExports(t *testing.T) {
+	link := &models.Link{
+		Alias:          "eng/oncall",
+		DestinationURL: "https://pager.example.com/schedules/42",
+		Description:    "Who is on call | today",
+		Tags:           []string{"eng"},
+	}
+	opts := linkio.ExportOptions{BaseURL: "https://go.example.com/"}
+	assert.Equal(t, "https://go.example.com/go/eng/oncall", opts.ShortURL(link.Alias))
+
+	var buf bytes.Buffer
+	writer, contentType, _, err := linkio.NewWriter("markdown", &buf, opts)
+	require.NoError(t, err)
+	assert.Contains(t, contentType, "text/markdown")
+	require.NoError(t, writer.Write(link))
+	require.NoError(t, writer.Close())
+	assert.Contains(t, buf.String(), "| [go/eng/oncall](https://go.example.com/go/eng/oncall) | Who is on call \\| today | `eng` |")
+	assert.NotContains(t, buf.String(), "pager.example.com")
+
+	buf.Reset()
+	writer, _, _, err = linkio.NewWriter("bookmarks", &buf, opts)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(link))
+	require.NoError(t, writer.Close())
+	assert.NotContains(t, buf.String(), "pager.example.com")
+
+	// Exported bookmarks import back with the go link as destination.
+	importer, err := linkio.LookupImporter("bookmarks")
+	require.NoError(t, err)
+	records, err := importer.Parse(&buf)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "eng/oncall", records[0].Alias)
+	assert.Equal(t, "https://go.example.com/go/eng/oncall", records[0].DestinationURL)
+	assert.Equal(t, []string{"go-links", "eng"}, records[0].Tags)
+}
@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/jobs"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationTemplates(t *testing.T) {
+	templates := notify.NewTemplates("https://go.example.com/")
+
+	lastUsed := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	subject, body, err := templates.Render(models.NotifyLinkDigest, &jobs.StaleDigest{
+		OwnerEmail: "a@example.com",
+		Items: []jobs.StaleDigestItem{{
+			Alias:          "wiki",
+			DestinationURL: "https://wiki.example.com",
+			Reasons:        []string{models.StaleUnused, models.StaleExpiring},
+			LastAccessedAt: &lastUsed,
+			ExpiresAt:      &lastUsed,
+			ExtendURL:      "https://go.example.com/link-actions/keep",
+			ArchiveURL:     "https://go.example.com/link-actions/archive",
+			DeleteURL:      "https://go.example.com/link-actions/delete",
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "1 of your go links need a look", subject)
+	assert.Contains(t, body, "go/wiki -> https://wiki.example.com")
+	assert.Contains(t, body, "Not used since March 1, 2024")
+	assert.Contains(t, body, "Expires on March 1, 2024")
+	assert.Contains(t, body, "https://go.example.com/link-actions/archive")
+
+	subject, body, err = templates.Render(models.NotifyAdminEdit, map[string]interface{}{
+		"Alias":  "docs",
+		"Actor":  "admin@example.com",
+		"Reason": "moved to the new wiki",
+		"Changes": map[string]map[string]interface{}{
+			"destinationUrl": {"from": "https://old.example.com", "to": "https://new.example.com"},
+			"expiresAt":      {"from": nil, "to": "2030-01-02T03:04:05Z"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "An admin changed go/docs", subject)
+	assert.Contains(t, body, "admin@example.com changed your link go/docs.")
+	assert.Contains(t, body, "destinationUrl: https://old.example.com -> https://new.example.com")
+	assert.Contains(t, body, "expiresAt: (none) -> January 2, 2030")
+	assert.Contains(t, body, "Reason: moved to the new wiki")
+
+	subject, body, err = templates.Render(models.NotifyLinkTransferred, map[string]interface{}{
+		"Alias": "team/roadmap", "DestinationURL": "https://roadmap.example.com",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "go/team/roadmap is now yours", subject)
+	assert.Contains(t, body, "An admin made you the owner of go/team/roadmap.")
+	assert.Contains(t, body, "https://go.example.com/go/team/roadmap -> https://roadmap.example.com")
+
+	_, body, err = templates.Render(models.NotifyLinkBroken, map[string]interface{}{
+		"Alias": "jira", "DestinationURL": "https://jira.example.com", "StatusCode": 404,
+		"Failures": 2, "CheckedAt": lastUsed,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, body, "failed its last 2 checks")
+	assert.Contains(t, body, "responded with status 404 on March 1, 2024")
+
+	_, _, err = templates.Render("unknown", nil)
+	assert.Error(t, err)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received notify.Message
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		if fail {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	notifier := notify.NewWebhookNotifier(server.URL, server.Client())
+	msg := &notify.Message{UserID: 3, Kind: models.NotifyLinkBroken, To: "a@example.com", Subject: "s", Body: "b"}
+	require.NoError(t, notifier.Send(context.Background(), msg))
+	assert.Equal(t, *msg, received)
+
+	fail = true
+	assert.Error(t, notifier.Send(context.Background(), msg))
+}
+
+func TestMemoryNotifier(t *testing.T) {
+	notifier, err := notify.New(notify.Config{Channel: notify.ChannelMemory})
+	require.NoError(t, err)
+	memory := notifier.(*notify.MemoryNotifier)
+
+	require.NoError(t, memory.Send(context.Background(), &notify.Message{To: "a@example.com", Subject: "one"}))
+	require.NoError(t, memory.Send(context.Background(), &notify.Message{To: "b@example.com", Subject: "two"}))
+	messages := memory.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, "one", messages[0].Subject)
+	assert.Equal(t, "b@example.com", messages[1].To)
+
+	memory.Reset()
+	assert.Empty(t, memory.Messages())
+
+	_, err = notify.New(notify.Config{Channel: "pigeon"})
+	assert.Error(t, err)
+}
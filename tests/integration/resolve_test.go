@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/handlers"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+
+	owner := &models.User{Email: "owner@example.com"}
+	require.NoError(t, userRepo.Create(ctx, owner, "password123"))
+
+	past := time.Now().Add(-time.Hour)
+	active := &models.Link{Alias: "docs", DestinationURL: "https://example.com/docs", CreatedBy: owner.ID, IsActive: true}
+	inactive := &models.Link{Alias: "paused", DestinationURL: "https://example.com/paused", CreatedBy: owner.ID}
+	expired := &models.Link{Alias: "gone", DestinationURL: "https://example.com/gone", CreatedBy: owner.ID, IsActive: true, ExpiresAt: &past}
+	for _, link := range []*models.Link{active, inactive, expired} {
+		require.NoError(t, linkRepo.Create(ctx, link))
+	}
+
+	handler := handlers.NewResolveHandler(linkRepo, services.NewSuggestIndex(linkRepo, time.Hour))
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(asUser(owner.ID, false))
+	router.GET("/api/resolve/:alias", handler.Resolve)
+	router.POST("/api/resolve", handler.BatchResolve)
+
+	batch := func(t *testing.T, req map[string]interface{}) (int, map[string]interface{}) {
+		payload, err := json.Marshal(req)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/resolve", bytes.NewReader(payload)))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+	totalCount := func(t *testing.T, link *models.Link) int {
+		fresh, err := linkRepo.GetByID(ctx, link.ID)
+		require.NoError(t, err)
+		return fresh.Stats.TotalCount
+	}
+
+	t.Run("Maps Statuses", func(t *testing.T) {
+		code, body := getJSON(t, router, "/api/resolve/docs?arg=api")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "active", body["status"])
+		assert.Equal(t, "https://example.com/docs/api", body["resolvedUrl"])
+		assert.Equal(t, "owner@example.com", body["ownerEmail"])
+
+		code, body = getJSON(t, router, "/api/resolve/paused")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "inactive", body["status"])
+
+		code, body = getJSON(t, router, "/api/resolve/gone")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "expired", body["status"])
+
+		code, body = getJSON(t, router, "/api/resolve/missing")
+		assert.Equal(t, http.StatusNotFound, code)
+		assert.Equal(t, "not_found", body["status"])
+	})
+
+	t.Run("Batch Keeps Request Order", func(t *testing.T) {
+		code, body := batch(t, map[string]interface{}{"aliases": []string{"missing", "docs api", "paused", "  "}})
+		require.Equal(t, http.StatusOK, code)
+
+		items := body["items"].([]interface{})
+		require.Len(t, items, 4)
+		var statuses []string
+		for _, item := range items {
+			statuses = append(statuses, item.(map[string]interface{})["status"].(string))
+		}
+		assert.Equal(t, []string{"not_found", "active", "inactive", "not_found"}, statuses)
+		assert.Equal(t, "https://example.com/docs/api", items[1].(map[string]interface{})["resolvedUrl"])
+	})
+
+	t.Run("Only Counted Active Lookups Record Clicks", func(t *testing.T) {
+		before := totalCount(t, active)
+
+		getJSON(t, router, "/api/resolve/docs")
+		batch(t, map[string]interface{}{"aliases": []string{"docs"}})
+		assert.Equal(t, before, totalCount(t, active))
+
+		getJSON(t, router, "/api/resolve/docs?count=true")
+		batch(t, map[string]interface{}{"aliases": []string{"docs"}, "count": true})
+		assert.Equal(t, before+2, totalCount(t, active))
+
+		getJSON(t, router, "/api/resolve/paused?count=true")
+		getJSON(t, router, "/api/resolve/gone?count=true")
+		assert.Zero(t, totalCount(t, inactive))
+		assert.Zero(t, totalCount(t, expired))
+	})
+
+	t.Run("Caps Batch Size", func(t *testing.T) {
+		aliases := make([]string, 51)
+		for i := range aliases {
+			aliases[i] = fmt.Sprintf("alias-%d", i)
+		}
+		code, body := batch(t, map[string]interface{}{"aliases": aliases})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "at most 50 aliases can be resolved at once", body["error"])
+
+		code, _ = batch(t, map[string]interface{}{"aliases": aliases[:50]})
+		assert.Equal(t, http.StatusOK, code)
+	})
+}
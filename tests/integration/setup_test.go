@@ -104,6 +104,7 @@ func setupTestRouter() *gin.Engine {
 func clearTestDatabase(db *sql.DB) error {
 	tables := []string{
 		"request_log_aggregates",
+		"link_changes",
 		"request_logs",
 		"link_stats",
 		"links",
@@ -151,3 +152,21 @@ func resetTestDB(t *testing.T) {
 	err := clearTestDatabase(testDB.DB)
 	require.NoError(t, err, "Failed to clear test database")
 }
+
+// asUser stands in for the auth middleware in front of the handlers of
+// the server, signing every request in as userID.
+func asUser(userID int64, isAdmin bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("user", &auth.Claims{UserID: userID, IsAdmin: isAdmin})
+		c.Next()
+	}
+}
+
+// getJSON serves a GET request for path and decodes the JSON response.
+func getJSON(t *testing.T, router http.Handler, path string) (int, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+	return w.Code, body
+}
@@ -0,0 +1,227 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/handlers"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/devingoodsell/go-links-free/internal/slack"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const slackTestSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+// recordedSlackCommand is a /go command as Slack posts it, with the text
+// and response URL left to fill in.
+const recordedSlackCommand = "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow" +
+	"&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner" +
+	"&command=%%2Fgo&text=%s&api_app_id=A1TPGBM5X&is_enterprise_install=false" +
+	"&response_url=%s&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+
+func recordedCommand(text, responseURL string) []byte {
+	return []byte(fmt.Sprintf(recordedSlackCommand, url.QueryEscape(text), url.QueryEscape(responseURL)))
+}
+
+func signedSlackRequest(body []byte, secret string, timestamp time.Time) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/slack/commands", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(slack.HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(slack.HeaderSignature, slack.Sign(secret, timestamp.Unix(), body))
+	return req
+}
+
+func TestSlackVerify(t *testing.T) {
+	body := recordedCommand("foo", "https://hooks.slack.com/commands/T1DC2JH3J/397700885554/96rGlfmibIGlgcZRskXaIFfN")
+	now := time.Unix(1531420618, 0)
+	header := signedSlackRequest(body, slackTestSecret, now).Header
+
+	assert.NoError(t, slack.Verify(slackTestSecret, header, body, now))
+	assert.NoError(t, slack.Verify(slackTestSecret, header, body, now.Add(4*time.Minute)))
+	assert.ErrorIs(t, slack.Verify(slackTestSecret, header, body, now.Add(6*time.Minute)), slack.ErrStaleRequest)
+	assert.ErrorIs(t, slack.Verify("other-secret", header, body, now), slack.ErrInvalidSignature)
+	assert.ErrorIs(t, slack.Verify("", header, body, now), slack.ErrInvalidSignature)
+
+	tampered := []byte(strings.Replace(string(body), "text=foo", "text=bar", 1))
+	assert.ErrorIs(t, slack.Verify(slackTestSecret, header, tampered, now), slack.ErrInvalidSignature)
+
+	cmd, err := slack.ParseCommand(body)
+	require.NoError(t, err)
+	assert.Equal(t, "/go", cmd.Command)
+	assert.Equal(t, "foo", cmd.Text)
+	assert.Equal(t, "U2CERLKJA", cmd.UserID)
+	assert.Equal(t, "https://hooks.slack.com/commands/T1DC2JH3J/397700885554/96rGlfmibIGlgcZRskXaIFfN", cmd.ResponseURL)
+
+	assert.Equal(t, "https://example.com", slack.Unwrap("<https://example.com>"))
+	assert.Equal(t, "https://example.com", slack.Unwrap("<https://example.com|example.com>"))
+	assert.Equal(t, "https://example.com", slack.Unwrap("https://example.com"))
+	assert.Equal(t, "a &lt;b&gt; &amp; c", slack.Escape("a <b> & c"))
+}
+
+// fakeSlack serves users.info for the Web API and collects what is posted
+// to its response URL.
+type fakeSlack struct {
+	*httptest.Server
+	emails  map[string]string
+	replies chan slack.Response
+}
+
+func newFakeSlack(t *testing.T, emails map[string]string) *fakeSlack {
+	f := &fakeSlack{emails: emails, replies: make(chan slack.Response, 10)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/users.info":
+			assert.Equal(t, "Bearer xoxb-test", r.Header.Get("Authorization"))
+			email, ok := f.emails[r.URL.Query().Get("user")]
+			if !ok {
+				json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error": "user_not_found"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ok":   true,
+				"user": map[string]interface{}{"profile": map[string]string{"email": email}},
+			})
+		case "/respond":
+			var reply slack.Response
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&reply))
+			f.replies <- reply
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeSlack) client() *slack.Client {
+	return slack.NewClient("xoxb-test", f.URL+"/api", f.Client())
+}
+
+func (f *fakeSlack) reply(t *testing.T) slack.Response {
+	select {
+	case reply := <-f.replies:
+		return reply
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reply was posted to the response URL")
+		return slack.Response{}
+	}
+}
+
+func TestSlackClient(t *testing.T) {
+	fake := newFakeSlack(t, map[string]string{"U2CERLKJA": "roadrunner@example.com", "U0NOEMAIL": ""})
+	client := fake.client()
+	ctx := context.Background()
+
+	email, err := client.UserEmail(ctx, "U2CERLKJA")
+	require.NoError(t, err)
+	assert.Equal(t, "roadrunner@example.com", email)
+
+	_, err = client.UserEmail(ctx, "U0NOEMAIL")
+	assert.ErrorIs(t, err, slack.ErrNoEmail)
+
+	_, err = client.UserEmail(ctx, "U0UNKNOWN")
+	assert.ErrorContains(t, err, "user_not_found")
+
+	require.NoError(t, client.Respond(ctx, fake.URL+"/respond", &slack.Response{ResponseType: slack.ResponseEphemeral, Text: "hi"}))
+	assert.Equal(t, slack.Response{ResponseType: slack.ResponseEphemeral, Text: "hi"}, fake.reply(t))
+
+	assert.Error(t, client.Respond(ctx, fake.URL+"/missing", &slack.Response{Text: "hi"}))
+}
+
+func TestSlackCommands(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+
+	owner := &models.User{Email: "roadrunner@example.com"}
+	require.NoError(t, userRepo.Create(ctx, owner, "password123"))
+
+	aliasPolicy, err := services.NewAliasPolicy(nil, []string{"admin"}, nil)
+	require.NoError(t, err)
+	urlPolicy := services.NewURLPolicy(linkRepo, services.URLPolicyConfig{AllowedSchemes: []string{"http", "https"}})
+
+	fake := newFakeSlack(t, map[string]string{"U2CERLKJA": "roadrunner@example.com", "U0STRANGER": "coyote@example.com", "U0SHOUTY": "RoadRunner@Example.com"})
+	handler := handlers.NewSlackHandler(linkRepo, userRepo, aliasPolicy, urlPolicy, fake.client(), slackTestSecret, "https://go.example.com")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/slack/commands", handler.Command)
+
+	command := func(t *testing.T, text string) slack.Response {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, signedSlackRequest(recordedCommand(text, fake.URL+"/respond"), slackTestSecret, time.Now()))
+		require.Equal(t, http.StatusOK, w.Code)
+		return fake.reply(t)
+	}
+
+	t.Run("Rejects unsigned and stale requests", func(t *testing.T) {
+		body := recordedCommand("foo", fake.URL+"/respond")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, signedSlackRequest(body, "wrong-secret", time.Now()))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, signedSlackRequest(body, slackTestSecret, time.Now().Add(-10*time.Minute)))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		reply := command(t, "create docs <https://docs.example.com|docs.example.com>")
+		assert.Equal(t, slack.ResponseEphemeral, reply.ResponseType)
+		assert.Equal(t, "Created go/docs → https://docs.example.com", reply.Text)
+
+		link, err := linkRepo.GetByAlias(ctx, "docs")
+		require.NoError(t, err)
+		assert.Equal(t, owner.ID, link.CreatedBy)
+
+		assert.Contains(t, command(t, "create docs https://other.example.com").Text, "go/docs already exists")
+		assert.Contains(t, command(t, "create admin https://example.com").Text, "Could not create go/admin")
+		assert.Contains(t, command(t, "create bad not-a-url").Text, "Could not create go/bad")
+		assert.Contains(t, command(t, "create docs").Text, "Usage")
+		assert.Contains(t, command(t, "create "+strings.Repeat("a", 101)+" https://example.com").Text, "alias must be 100 characters or less")
+	})
+
+	t.Run("Lookup", func(t *testing.T) {
+		assert.Equal(t, "go/docs → https://docs.example.com", command(t, "docs").Text)
+		assert.Equal(t, "go/docs → https://docs.example.com/api", command(t, "docs api").Text)
+		assert.Contains(t, command(t, "nope").Text, "go/nope does not exist")
+	})
+
+	t.Run("Who", func(t *testing.T) {
+		reply := command(t, "who docs")
+		assert.Contains(t, reply.Text, "go/docs is owned by roadrunner@example.com")
+		assert.Contains(t, reply.Text, "Clicks: 0 today, 0 this week, 0 in total.")
+	})
+
+	t.Run("Unknown users", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := []byte(strings.Replace(string(recordedCommand("docs", fake.URL+"/respond")), "U2CERLKJA", "U0STRANGER", 1))
+		router.ServeHTTP(w, signedSlackRequest(body, slackTestSecret, time.Now()))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "No go links account uses coyote@example.com. Sign in at https://go.example.com first.", fake.reply(t).Text)
+	})
+
+	t.Run("Matches Emails Case-Insensitively", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := []byte(strings.Replace(string(recordedCommand("docs", fake.URL+"/respond")), "U2CERLKJA", "U0SHOUTY", 1))
+		router.ServeHTTP(w, signedSlackRequest(body, slackTestSecret, time.Now()))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "go/docs → https://docs.example.com", fake.reply(t).Text)
+	})
+
+	t.Run("Help", func(t *testing.T) {
+		assert.Contains(t, command(t, "").Text, "/go create foo")
+	})
+}
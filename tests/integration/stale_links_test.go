@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/jobs"
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkActionTokens(t *testing.T) {
+	tokens := services.NewLinkActionTokens("secret", time.Hour)
+	link := &models.Link{ID: 7, CreatedBy: 3, UpdatedAt: time.Now()}
+
+	token := tokens.Issue(link, services.LinkActionArchive)
+	claims, err := tokens.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), claims.LinkID)
+	assert.Equal(t, int64(3), claims.OwnerID)
+	assert.Equal(t, services.LinkActionArchive, claims.Action)
+	assert.NoError(t, claims.Check(link))
+
+	// Any change to the link, including one made through another token of
+	// the same digest, spends the token.
+	changed := *link
+	changed.UpdatedAt = link.UpdatedAt.Add(time.Millisecond)
+	assert.ErrorIs(t, claims.Check(&changed), services.ErrActionTokenUsed)
+	changed = *link
+	changed.CreatedBy = 4
+	assert.ErrorIs(t, claims.Check(&changed), services.ErrActionTokenUsed)
+
+	_, err = services.NewLinkActionTokens("other", time.Hour).Verify(token)
+	assert.ErrorIs(t, err, services.ErrInvalidActionToken)
+	_, err = tokens.Verify(strings.Replace(token, ".", "x.", 1))
+	assert.ErrorIs(t, err, services.ErrInvalidActionToken)
+	_, err = tokens.Verify("garbage")
+	assert.ErrorIs(t, err, services.ErrInvalidActionToken)
+
+	expired := services.NewLinkActionTokens("secret", -time.Minute).Issue(link, services.LinkActionDelete)
+	_, err = tokens.Verify(expired)
+	assert.ErrorIs(t, err, services.ErrActionTokenExpired)
+}
+
+func TestStaleLinkDigest(t *testing.T) {
+	tokens := services.NewLinkActionTokens("secret", time.Hour)
+	job := jobs.NewStaleLinkJob(nil, tokens, jobs.StaleLinkConfig{BaseURL: "https://go.example.com/"})
+
+	expires := time.Now().Add(24 * time.Hour)
+	digest := job.Digest([]*models.StaleLink{
+		{
+			Link:    &models.Link{ID: 1, Alias: "wiki", DestinationURL: "https://wiki.example.com", CreatedBy: 3, OwnerEmail: "a@example.com"},
+			Reasons: []string{models.StaleUnused},
+		},
+		{
+			Link:    &models.Link{ID: 2, Alias: "launch", DestinationURL: "https://launch.example.com", CreatedBy: 3, OwnerEmail: "a@example.com", ExpiresAt: &expires},
+			Reasons: []string{models.StaleExpiring},
+		},
+	})
+
+	assert.Equal(t, int64(3), digest.OwnerID)
+	assert.Equal(t, "a@example.com", digest.OwnerEmail)
+	require.Len(t, digest.Items, 2)
+	assert.Equal(t, []string{models.StaleExpiring}, digest.Items[1].Reasons)
+	assert.Equal(t, &expires, digest.Items[1].ExpiresAt)
+
+	item := digest.Items[0]
+	for action, actionURL := range map[string]string{
+		services.LinkActionExtend:  item.ExtendURL,
+		services.LinkActionArchive: item.ArchiveURL,
+		services.LinkActionDelete:  item.DeleteURL,
+	} {
+		require.True(t, strings.HasPrefix(actionURL, "https://go.example.com/link-actions/"), actionURL)
+		token, err := url.PathUnescape(strings.TrimPrefix(actionURL, "https://go.example.com/link-actions/"))
+		require.NoError(t, err)
+		claims, err := tokens.Verify(token)
+		require.NoError(t, err)
+		assert.Equal(t, action, claims.Action)
+		assert.Equal(t, int64(1), claims.LinkID)
+	}
+}
@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeActiveLinks []*models.Link
+
+func (f fakeActiveLinks) ListActive(ctx context.Context) ([]*models.Link, error) {
+	return f, nil
+}
+
+func (f fakeActiveLinks) FavoriteLinkIDs(ctx context.Context) (map[int64][]int64, error) {
+	// User 3 starred "docs"
+	return map[int64][]int64{3: {1}}, nil
+}
+
+func activeLink(alias string, clicks int) *models.Link {
+	return &models.Link{
+		Alias:          alias,
+		DestinationURL: "https://example.com/" + alias,
+		Stats:          &models.LinkStats{TotalCount: clicks},
+	}
+}
+
+func aliases(suggestions []services.Suggestion) []string {
+	result := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		result[i] = s.Alias
+	}
+	return result
+}
+
+func TestSuggestIndex(t *testing.T) {
+	docs := activeLink("docs", 10)
+	docs.ID = 1
+	index := services.NewSuggestIndex(fakeActiveLinks{
+		docs,
+		activeLink("docs-api", 500),
+		activeLink("dashboard", 50),
+		activeLink("design", 5),
+		activeLink("oncall", 0),
+	}, time.Hour)
+	require.NoError(t, index.Refresh(context.Background()))
+
+	t.Run("Prefix Ranked By Popularity", func(t *testing.T) {
+		assert.Equal(t, []string{"docs-api", "dashboard"}, aliases(index.Suggest(1, "D", 2)))
+		assert.Equal(t, []string{"docs-api", "docs"}, aliases(index.Suggest(1, "doc", 10)))
+	})
+
+	t.Run("Recent Use Boost", func(t *testing.T) {
+		index.RecordUse(2, "docs")
+		assert.Equal(t, []string{"docs", "docs-api"}, aliases(index.Suggest(2, "doc", 10)))
+		assert.Equal(t, []string{"docs-api", "docs"}, aliases(index.Suggest(1, "doc", 10)))
+	})
+
+	t.Run("Favorite Boost", func(t *testing.T) {
+		assert.Equal(t, []string{"docs", "docs-api"}, aliases(index.Suggest(3, "doc", 10)))
+	})
+
+	t.Run("Fuzzy Match", func(t *testing.T) {
+		suggestions := index.Suggest(1, "oncal1", 10)
+		require.Len(t, suggestions, 1)
+		assert.Equal(t, "oncall", suggestions[0].Alias)
+		assert.Equal(t, "fuzzy", suggestions[0].Match)
+	})
+
+	t.Run("Empty Prefix", func(t *testing.T) {
+		assert.Empty(t, index.Suggest(1, " ", 10))
+	})
+}
+
+func TestSuggestIndexPrefixBeforeFuzzy(t *testing.T) {
+	// A popular fuzzy match the user starred and just used still ranks
+	// below an unused prefix match.
+	wikki := activeLink("wikki", 20)
+	wikki.ID = 1
+	wikki.Stats.WeeklyCount = 20
+	index := services.NewSuggestIndex(fakeActiveLinks{wikki, activeLink("wiki-old", 0)}, time.Hour)
+	require.NoError(t, index.Refresh(context.Background()))
+	index.RecordUse(3, "wikki")
+
+	suggestions := index.Suggest(3, "wiki", 10)
+	assert.Equal(t, []string{"wiki-old", "wikki"}, aliases(suggestions))
+	assert.Equal(t, "prefix", suggestions[0].Match)
+	assert.Equal(t, "fuzzy", suggestions[1].Match)
+	assert.Greater(t, suggestions[1].Score, suggestions[0].Score)
+}
@@ -0,0 +1,75 @@
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	t.Run("Normalizes", func(t *testing.T) {
+		tags, err := models.NormalizeTags([]string{" Team Docs ", "team-docs", "API", "", "v1.2/beta_x"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"team-docs", "api", "v1.2/beta_x"}, tags)
+	})
+
+	t.Run("Rejects Invalid Tags", func(t *testing.T) {
+		_, err := models.NormalizeTags([]string{"no#hash"})
+		assert.ErrorContains(t, err, "may only contain")
+
+		_, err = models.NormalizeTags([]string{"-leading"})
+		assert.Error(t, err)
+
+		_, err = models.NormalizeTags([]string{strings.Repeat("a", models.MaxTagLength+1)})
+		assert.ErrorContains(t, err, "characters or less")
+
+		many := make([]string, models.MaxTagsPerLink+1)
+		for i := range many {
+			many[i] = "tag" + strings.Repeat("x", i)
+		}
+		_, err = models.NormalizeTags(many)
+		assert.ErrorContains(t, err, "at most")
+	})
+
+	t.Run("Lenient List Keeps Anything", func(t *testing.T) {
+		assert.Equal(t, []string{"no#hash", "team-docs"}, models.NormalizeTagList([]string{"No#Hash", "team  docs", " "}))
+		assert.Empty(t, models.NormalizeTagList(nil))
+	})
+}
+
+func TestListTags(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+
+	owner := &models.User{Email: "owner@example.com"}
+	other := &models.User{Email: "other@example.com"}
+	require.NoError(t, userRepo.Create(ctx, owner, "password123"))
+	require.NoError(t, userRepo.Create(ctx, other, "password123"))
+
+	require.NoError(t, linkRepo.Create(ctx, &models.Link{Alias: "docs", DestinationURL: "https://example.com/docs", CreatedBy: owner.ID, IsActive: true, Tags: []string{"team", "docs"}}))
+	require.NoError(t, linkRepo.Create(ctx, &models.Link{Alias: "plans", DestinationURL: "https://example.com/plans", CreatedBy: owner.ID, IsActive: true, Visibility: models.VisibilityPrivate, Tags: []string{"team", "secret-project"}}))
+
+	t.Run("Owner Sees Own Private Tags", func(t *testing.T) {
+		tags, err := linkRepo.ListTags(ctx, owner.ID, "", 20)
+		require.NoError(t, err)
+		assert.Equal(t, []models.TagCount{{Name: "team", Count: 2}, {Name: "docs", Count: 1}, {Name: "secret-project", Count: 1}}, tags)
+	})
+
+	t.Run("Others Only See Public Tags", func(t *testing.T) {
+		tags, err := linkRepo.ListTags(ctx, other.ID, "", 20)
+		require.NoError(t, err)
+		assert.Equal(t, []models.TagCount{{Name: "docs", Count: 1}, {Name: "team", Count: 1}}, tags)
+	})
+
+	t.Run("Filters By Prefix", func(t *testing.T) {
+		tags, err := linkRepo.ListTags(ctx, other.ID, "se", 20)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+	})
+}
@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLinkLookup map[string]string
+
+func (f fakeLinkLookup) GetByAlias(ctx context.Context, alias string) (*models.Link, error) {
+	destination, ok := f[alias]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	return &models.Link{Alias: alias, DestinationURL: destination}, nil
+}
+
+func TestURLPolicy(t *testing.T) {
+	links := fakeLinkLookup{
+		"a": "https://go.example.com/go/b",
+		"b": "http://go/c",
+		"d": "https://docs.example.com",
+	}
+	policy := services.NewURLPolicy(links, services.URLPolicyConfig{
+		AllowedSchemes: []string{"http", "https"},
+		DeniedDomains:  []string{"evil.com"},
+		ForceHTTPS:     true,
+		PublicURL:      "https://go.example.com",
+		SelfHosts:      []string{"go"},
+	})
+
+	tests := []struct {
+		name        string
+		alias       string
+		destination string
+		want        string
+		code        string
+	}{
+		{"Upgrade To HTTPS", "x", "http://example.com/path", "https://example.com/path", ""},
+		{"JavaScript Scheme", "x", "javascript:alert(1)", "", "scheme_not_allowed"},
+		{"Data Scheme", "x", "data:text/html,hi", "", "scheme_not_allowed"},
+		{"Relative URL", "x", "/just/a/path", "", "url_invalid"},
+		{"Denied Subdomain", "x", "https://www.evil.com", "", "domain_denied"},
+		{"Self Reference", "x", "https://go.example.com/go/x", "", "redirect_loop"},
+		{"Cycle Through Chain", "c", "https://go.example.com/go/a", "", "redirect_loop"},
+		{"Chain Without Cycle", "x", "http://go/d", "https://go/d", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.Apply(context.Background(), tt.alias, tt.destination)
+			if tt.code == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+				return
+			}
+
+			var policyErr *services.PolicyError
+			require.ErrorAs(t, err, &policyErr)
+			assert.Equal(t, tt.code, policyErr.Code)
+			assert.Equal(t, "destinationUrl", policyErr.Field)
+		})
+	}
+
+	t.Run("Allowed Domains", func(t *testing.T) {
+		restricted := services.NewURLPolicy(nil, services.URLPolicyConfig{
+			AllowedSchemes: []string{"https"},
+			AllowedDomains: []string{"example.com"},
+		})
+
+		_, err := restricted.Apply(context.Background(), "x", "https://wiki.example.com")
+		assert.NoError(t, err)
+
+		_, err = restricted.Apply(context.Background(), "x", "https://other.org")
+		var policyErr *services.PolicyError
+		require.ErrorAs(t, err, &policyErr)
+		assert.Equal(t, "domain_not_allowed", policyErr.Code)
+	})
+
+	t.Run("Blocklisted Domain", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "blocklist.txt")
+		require.NoError(t, os.WriteFile(path, []byte("# known bad\nmalware.test\n0.0.0.0 phish.test\n"), 0o644))
+
+		blocklist := services.NewDomainBlocklist(path)
+		changed, err := blocklist.Reload()
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.True(t, blocklist.Contains("cdn.malware.test"))
+		assert.True(t, blocklist.Contains("phish.test"))
+		assert.False(t, blocklist.Contains("test"))
+
+		changed, err = blocklist.Reload()
+		require.NoError(t, err)
+		assert.False(t, changed)
+
+		guarded := services.NewURLPolicy(nil, services.URLPolicyConfig{
+			AllowedSchemes: []string{"https"},
+			Blocklist:      blocklist,
+		})
+		_, err = guarded.Apply(context.Background(), "x", "https://login.phish.test/")
+		var policyErr *services.PolicyError
+		require.ErrorAs(t, err, &policyErr)
+		assert.Equal(t, "domain_blocklisted", policyErr.Code)
+	})
+}
@@ -0,0 +1,168 @@
+package integration
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/devingoodsell/go-links-free/internal/models"
+	"github.com/devingoodsell/go-links-free/internal/safehttp"
+	"github.com/devingoodsell/go-links-free/internal/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSend(t *testing.T) {
+	const secret = "s3cret"
+	payload := []byte(`{"id":"abc","type":"link.created"}`)
+
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, payload, body)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, models.WebhookLinkCreated, r.Header.Get(webhooks.HeaderEvent))
+		assert.Equal(t, "abc", r.Header.Get(webhooks.HeaderDelivery))
+
+		// Verify the signature the way a receiver would
+		timestamp, err := strconv.ParseInt(r.Header.Get(webhooks.HeaderTimestamp), 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, webhooks.Sign(secret, timestamp, body), r.Header.Get(webhooks.HeaderSignature))
+		assert.NotEqual(t, webhooks.Sign("wrong", timestamp, body), r.Header.Get(webhooks.HeaderSignature))
+
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	code, err := webhooks.Send(context.Background(), server.Client(), server.URL, secret, "abc", models.WebhookLinkCreated, payload)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, code)
+
+	status = http.StatusInternalServerError
+	code, err = webhooks.Send(context.Background(), server.Client(), server.URL, secret, "abc", models.WebhookLinkCreated, payload)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusInternalServerError, code)
+}
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{}`)
+	sig := webhooks.Sign("key", 1700000000, body)
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, sig)
+	assert.Equal(t, sig, webhooks.Sign("key", 1700000000, body))
+	assert.NotEqual(t, sig, webhooks.Sign("key", 1700000001, body))
+	assert.NotEqual(t, sig, webhooks.Sign("key", 1700000000, []byte(`{ }`)))
+
+	a, err := webhooks.NewSecret()
+	require.NoError(t, err)
+	b, err := webhooks.NewSecret()
+	require.NoError(t, err)
+	assert.Len(t, a, 64)
+	assert.NotEqual(t, a, b)
+}
+
+func TestWebhookSendRefusesInternalAddresses(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	_, err := webhooks.Send(context.Background(), safehttp.NewClient(time.Second), server.URL, "s3cret", "abc", models.WebhookLinkCreated, []byte(`{}`))
+	assert.ErrorIs(t, err, safehttp.ErrAddressNotAllowed)
+	assert.False(t, requested)
+}
+
+func TestWebhookLinkChangeOutbox(t *testing.T) {
+	resetTestDB(t)
+	ctx := context.Background()
+	userRepo := models.NewUserRepository(testDB)
+	linkRepo := models.NewLinkRepository(testDB)
+	webhookRepo := models.NewWebhookRepository(testDB)
+
+	owner := &models.User{Email: "owner@example.com"}
+	other := &models.User{Email: "other@example.com"}
+	require.NoError(t, userRepo.Create(ctx, owner, "password123"))
+	require.NoError(t, userRepo.Create(ctx, other, "password123"))
+
+	own := &models.WebhookEndpoint{URL: "https://hooks.example.com/own", Secret: "s", CreatedBy: owner.ID, IsActive: true,
+		Events: []string{models.WebhookLinkCreated, models.WebhookLinkUpdated, models.WebhookLinkDeleted}}
+	foreign := &models.WebhookEndpoint{URL: "https://hooks.example.com/foreign", Secret: "s", CreatedBy: other.ID, IsActive: true,
+		Events: []string{models.WebhookLinkCreated}}
+	everything := &models.WebhookEndpoint{URL: "https://hooks.example.com/all", Secret: "s", CreatedBy: other.ID, IsActive: true,
+		AllLinks: true, Events: []string{models.WebhookLinkDeleted}}
+	for _, e := range []*models.WebhookEndpoint{own, foreign, everything} {
+		require.NoError(t, webhookRepo.CreateEndpoint(ctx, e))
+	}
+
+	dispatcher := webhooks.NewDispatcher(webhookRepo, linkRepo, "https://go.example.com")
+	eventTypes := func(t *testing.T, e *models.WebhookEndpoint) []string {
+		deliveries, err := webhookRepo.ListDeliveries(ctx, e.ID, "", 10)
+		require.NoError(t, err)
+		var types []string
+		for _, d := range deliveries {
+			types = append(types, d.EventType)
+		}
+		return types
+	}
+
+	t.Run("Writes Record Changes Until Processed", func(t *testing.T) {
+		link := &models.Link{Alias: "docs", DestinationURL: "https://example.com/docs", CreatedBy: owner.ID, IsActive: true}
+		require.NoError(t, linkRepo.Create(ctx, link))
+		link.Description = "Docs"
+		require.NoError(t, linkRepo.Update(ctx, link))
+
+		pending, err := linkRepo.PendingChanges(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, pending, 2)
+		assert.Equal(t, []int64{link.ID}, pending[0].LinkIDs)
+
+		processed, err := dispatcher.ProcessChanges(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 2, processed)
+		assert.ElementsMatch(t, []string{models.WebhookLinkCreated, models.WebhookLinkUpdated}, eventTypes(t, own))
+		assert.Empty(t, eventTypes(t, foreign))
+
+		pending, err = linkRepo.PendingChanges(ctx, 10)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("Bulk Deletes Are One Change", func(t *testing.T) {
+		var ids []int64
+		for _, alias := range []string{"one", "two", "three"} {
+			link := &models.Link{Alias: alias, DestinationURL: "https://example.com/" + alias, CreatedBy: owner.ID, IsActive: true}
+			require.NoError(t, linkRepo.Create(ctx, link))
+			ids = append(ids, link.ID)
+		}
+		_, err := dispatcher.ProcessChanges(ctx, 10)
+		require.NoError(t, err)
+
+		require.NoError(t, linkRepo.BulkDelete(ctx, owner.ID, ids))
+		pending, err := linkRepo.PendingChanges(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, models.LinkChangeDeleted, pending[0].Type)
+		require.Len(t, pending[0].Removed, 3)
+		assert.Equal(t, "one", pending[0].Removed[0].Alias)
+
+		_, err = dispatcher.ProcessChanges(ctx, 10)
+		require.NoError(t, err)
+		assert.Len(t, eventTypes(t, everything), 3)
+	})
+
+	t.Run("Rolled Back Writes Record Nothing", func(t *testing.T) {
+		clash := &models.Link{Alias: "docs", DestinationURL: "https://example.com/clash", CreatedBy: owner.ID, IsActive: true}
+		fresh := &models.Link{Alias: "fresh", DestinationURL: "https://example.com/fresh", CreatedBy: owner.ID, IsActive: true}
+		_, err := linkRepo.SaveAll(ctx, []*models.Link{fresh, clash})
+		require.Error(t, err)
+
+		pending, err := linkRepo.PendingChanges(ctx, 10)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+}